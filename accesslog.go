@@ -0,0 +1,58 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package crawler
+
+import (
+	"errors"
+	"net"
+	"sync/atomic"
+
+	"github.com/vistone/crawler-system/internal/logging"
+	"github.com/vistone/crawler-system/internal/moduleinit"
+)
+
+// openAccessLog 按 [server] access_log_* 打开访问日志，未启用时返回 nil
+//
+// 访问日志同时记录 Fetch 发出的爬取请求和QUIC服务端的客户端会话，轮转文件后台处理的错误写入 logger。
+func openAccessLog(cfg *ServerConfig, logger Logger) (*logging.AccessLog, error) {
+	if !cfg.AccessLogEnabled {
+		return nil, nil
+	}
+	return logging.OpenAccessLog(cfg.AccessLogPath, cfg.AccessLogMaxSize, cfg.AccessLogMaxBackups,
+		cfg.AccessLogCompress, logging.ParseAccessFormat(cfg.AccessLogFormat),
+		moduleinit.RotateErrorHandler(logger, cfg.AccessLogPath))
+}
+
+// reportAccessLog 把访问日志设置加入日志模块的启动报告
+func reportAccessLog(report *StartupReport, cfg *ServerConfig) {
+	report.Set("access_log_enabled", "访问日志", cfg.AccessLogEnabled)
+	if cfg.AccessLogEnabled {
+		report.Set("access_log_path", "访问日志路径", cfg.AccessLogPath)
+		report.Set("access_log_format", "访问日志格式", cfg.AccessLogFormat)
+	}
+}
+
+// closeLogs 关闭访问日志和日志文件，日志模块最后停止
+func (s *System) closeLogs() error {
+	return errors.Join(s.AccessLog.Close(), s.Logger.Close())
+}
+
+// countingConn 统计读写字节数的连接，用于记录客户端会话
+type countingConn struct {
+	net.Conn
+	in, out atomic.Int64
+}
+
+func (c *countingConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	c.in.Add(int64(n))
+	return n, err
+}
+
+func (c *countingConn) Write(p []byte) (int, error) {
+	n, err := c.Conn.Write(p)
+	c.out.Add(int64(n))
+	return n, err
+}
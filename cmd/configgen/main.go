@@ -0,0 +1,91 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// configgen 根据 SystemConfig 及 internal/config 中的配置段类型生成配置文件的 JSON Schema 和带注释的参考配置
+//
+// 在仓库根目录执行 go generate 或:
+//
+//	go run ./cmd/configgen           # 重新生成 config.schema.json 和 config.reference.toml
+//	go run ./cmd/configgen -check    # 检查已提交的文件是否过期，过期时以非0状态退出
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"strings"
+
+	crawler "github.com/vistone/crawler-system"
+)
+
+func main() {
+	src := flag.String("src", "config.go,internal/config/types.go", "配置类型所在的源文件（逗号分隔），用于提取字段说明")
+	schemaPath := flag.String("schema", "config.schema.json", "JSON Schema 输出路径")
+	referencePath := flag.String("reference", "config.reference.toml", "参考配置输出路径")
+	check := flag.Bool("check", false, "只检查输出文件是否与当前代码一致，不写入")
+	flag.Parse()
+
+	if err := run(*src, *schemaPath, *referencePath, *check); err != nil {
+		fmt.Fprintf(os.Stderr, "configgen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(src, schemaPath, referencePath string, check bool) error {
+	docs := make(crawler.ConfigDocs)
+	for _, file := range strings.Split(src, ",") {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("读取源文件失败: %w", err)
+		}
+		fileDocs, err := crawler.ParseConfigDocs(file, data)
+		if err != nil {
+			return err
+		}
+		maps.Copy(docs, fileDocs)
+	}
+	if missing := docs.Missing(); len(missing) > 0 {
+		return fmt.Errorf("以下配置项缺少说明注释: %s", strings.Join(missing, ", "))
+	}
+
+	schema, err := crawler.GenerateJSONSchema(docs)
+	if err != nil {
+		return err
+	}
+	reference, err := crawler.GenerateReferenceConfig(docs)
+	if err != nil {
+		return err
+	}
+
+	outputs := []struct {
+		path string
+		data []byte
+	}{
+		{schemaPath, schema},
+		{referencePath, reference},
+	}
+
+	if check {
+		var stale []string
+		for _, out := range outputs {
+			current, err := os.ReadFile(out.path)
+			if err != nil || !bytes.Equal(current, out.data) {
+				stale = append(stale, out.path)
+			}
+		}
+		if len(stale) > 0 {
+			return fmt.Errorf("%s 已过期，请执行 go generate 重新生成", strings.Join(stale, ", "))
+		}
+		return nil
+	}
+
+	for _, out := range outputs {
+		if err := os.WriteFile(out.path, out.data, 0644); err != nil {
+			return fmt.Errorf("写入 %s 失败: %w", out.path, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,201 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/pelletier/go-toml/v2"
+	crawler "github.com/vistone/crawler-system"
+)
+
+// configSubcommands config 的子命令
+const configSubcommands = "validate, print, defaults, migrate"
+
+// runConfig 执行 config 子命令
+func runConfig(args []string, stdout, stderr io.Writer) error {
+	if len(args) == 0 {
+		return &usageError{msg: "缺少子命令，可用: " + configSubcommands}
+	}
+	switch args[0] {
+	case "validate":
+		return runConfigValidate(args[1:], stdout, stderr)
+	case "print":
+		return runConfigPrint(args[1:], stdout, stderr)
+	case "defaults":
+		return runConfigDefaults(args[1:], stdout, stderr)
+	case "migrate":
+		return runConfigMigrate(args[1:], stdout, stderr)
+	default:
+		return &usageError{msg: fmt.Sprintf("未知子命令 %q，可用: %s", args[0], configSubcommands)}
+	}
+}
+
+// runConfigValidate 加载并校验配置，逐项列出校验错误
+func runConfigValidate(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("config validate", "config validate [-config 文件] [-profile 名称] [-set key=value]", stderr)
+	var cf configFlags
+	cf.register(fs)
+	if err := parseFlags(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	_, err := cf.load()
+	var verr *crawler.ValidationError
+	if errors.As(err, &verr) {
+		fmt.Fprintf(stderr, "%s 校验失败:\n", cf.path)
+		for _, fe := range verr.Errors {
+			fmt.Fprintf(stderr, "  - %v\n", fe)
+		}
+		return fmt.Errorf("共 %d 个配置错误", len(verr.Errors))
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "%s: 配置有效\n", cf.path)
+	return nil
+}
+
+// runConfigPrint 输出叠加后的生效配置，敏感值已脱敏
+func runConfigPrint(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("config print", "config print [-config 文件] [-profile 名称] [-set key=value] [-sources]", stderr)
+	var cf configFlags
+	cf.register(fs)
+	withSources := fs.Bool("sources", false, "逐项输出生效值及其来源（默认值、文件、profile、环境变量或覆盖项）")
+	if err := parseFlags(fs, args, 0, 0); err != nil {
+		return err
+	}
+
+	sources := crawler.ConfigSources{}
+	cfg, err := cf.load(crawler.WithSources(sources))
+	if err != nil {
+		return err
+	}
+	if *withSources {
+		return sources.Dump(cfg.Redacted(), stdout)
+	}
+	return writeTOML(stdout, cfg.Redacted())
+}
+
+// runConfigDefaults 输出默认配置
+func runConfigDefaults(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("config defaults", "config defaults", stderr)
+	if err := parseFlags(fs, args, 0, 0); err != nil {
+		return err
+	}
+	return writeTOML(stdout, crawler.DefaultConfig())
+}
+
+func writeTOML(w io.Writer, cfg *crawler.SystemConfig) error {
+	data, err := toml.Marshal(cfg)
+	if err != nil {
+		return fmt.Errorf("序列化配置失败: %w", err)
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// runConfigMigrate 将配置文件升级到当前格式版本
+//
+// 默认把迁移结果输出到标准输出；-w 原地改写（原文件备份为 .bak），-o 写入指定文件。
+// 写入的文件与原文件权限相同，先写临时文件再重命名，写入中断时原文件不受影响。
+func runConfigMigrate(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("config migrate", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	write := fs.Bool("w", false, "原地改写配置文件，原文件备份为 <文件>.bak")
+	output := fs.String("o", "", "迁移结果写入的文件（默认输出到标准输出）")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "用法: crawler config migrate [-w] [-o 输出文件] [配置文件]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return &usageError{msg: err.Error()}
+	}
+	if fs.NArg() > 1 {
+		return &usageError{msg: "只能指定一个配置文件"}
+	}
+	if *write && *output != "" {
+		return &usageError{msg: "-w 和 -o 不能同时使用"}
+	}
+	path := "config.toml"
+	if fs.NArg() == 1 {
+		path = fs.Arg(0)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("读取配置文件失败: %w", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("读取配置文件失败: %w", err)
+	}
+	migrated, report, err := crawler.MigrateConfig(data)
+	if err != nil {
+		return err
+	}
+
+	if !report.Changed() {
+		fmt.Fprintf(stderr, "%s 已是当前版本 %d，无需迁移\n", path, report.To)
+	} else {
+		fmt.Fprintf(stderr, "%s: 版本 %d -> %d\n", path, report.From, report.To)
+		for _, step := range report.Applied {
+			fmt.Fprintf(stderr, "  - %s\n", step)
+		}
+	}
+
+	switch {
+	case *write:
+		if !report.Changed() {
+			return nil
+		}
+		// 备份和改写后的文件保持原文件的权限，配置文件中可能有敏感值
+		if err := writeFileAtomic(path+".bak", data, info.Mode().Perm()); err != nil {
+			return fmt.Errorf("备份配置文件失败: %w", err)
+		}
+		if err := writeFileAtomic(path, migrated, info.Mode().Perm()); err != nil {
+			return fmt.Errorf("写入配置文件失败: %w", err)
+		}
+		fmt.Fprintf(stderr, "已改写 %s，原文件备份为 %s.bak\n", path, path)
+	case *output != "":
+		if err := writeFileAtomic(*output, migrated, info.Mode().Perm()); err != nil {
+			return fmt.Errorf("写入 %s 失败: %w", *output, err)
+		}
+	default:
+		_, err := stdout.Write(migrated)
+		return err
+	}
+	return nil
+}
+
+// writeFileAtomic 先写入同目录下的临时文件再重命名为 path，写入中断时不会留下不完整的文件
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Chmod(perm)
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
@@ -0,0 +1,112 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfigMigrateWrite(t *testing.T) {
+	const v0 = "[fingerprint]\nrotation_interval = 300\n"
+	tests := []struct {
+		name     string
+		content  string
+		perm     os.FileMode
+		args     func(path string) []string
+		wantFile func(path string) string // 迁移结果写入的文件
+		wantBak  bool
+	}{
+		{
+			name:     "原地改写并备份",
+			content:  v0,
+			perm:     0o600,
+			args:     func(path string) []string { return []string{"-w", path} },
+			wantFile: func(path string) string { return path },
+			wantBak:  true,
+		},
+		{
+			name:     "写入指定文件",
+			content:  v0,
+			perm:     0o640,
+			args:     func(path string) []string { return []string{"-o", path + ".new", path} },
+			wantFile: func(path string) string { return path + ".new" },
+		},
+		{
+			name:    "已是当前版本时不改写",
+			content: "config_version = 2\n",
+			perm:    0o600,
+			args:    func(path string) []string { return []string{"-w", path} },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "config.toml")
+			if err := os.WriteFile(path, []byte(tt.content), tt.perm); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Chmod(path, tt.perm); err != nil {
+				t.Fatal(err)
+			}
+
+			var stdout, stderr bytes.Buffer
+			if code := run(append([]string{"config", "migrate"}, tt.args(path)...), &stdout, &stderr); code != 0 {
+				t.Fatalf("退出码 %d: %s", code, stderr.String())
+			}
+
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var names []string
+			for _, e := range entries {
+				names = append(names, e.Name())
+			}
+			wantFiles := 1
+			if tt.wantFile != nil && tt.wantFile(path) != path {
+				wantFiles++
+			}
+			if tt.wantBak {
+				wantFiles++
+			}
+			if len(names) != wantFiles {
+				t.Fatalf("目录中的文件 %v，want %d 个（不应留下临时文件）", names, wantFiles)
+			}
+
+			if tt.wantFile != nil {
+				checkFile(t, tt.wantFile(path), tt.perm, "config_version = 2")
+			}
+			if tt.wantBak {
+				checkFile(t, path+".bak", tt.perm, tt.content)
+			}
+			if tt.wantFile == nil {
+				checkFile(t, path, tt.perm, tt.content)
+			}
+		})
+	}
+}
+
+// checkFile 检查文件权限和内容
+func checkFile(t *testing.T, path string, perm os.FileMode, content string) {
+	t.Helper()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != perm {
+		t.Errorf("%s 的权限 %v，want %v", filepath.Base(path), info.Mode().Perm(), perm)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), content) {
+		t.Errorf("%s 的内容:\n%s\nwant 包含 %q", filepath.Base(path), data, content)
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	crawler "github.com/vistone/crawler-system"
+)
+
+// runDNS 执行 dns 子命令
+func runDNS(args []string, stdout, stderr io.Writer) error {
+	if len(args) == 0 {
+		return &usageError{msg: "缺少子命令，可用: resolve"}
+	}
+	switch args[0] {
+	case "resolve":
+		return runDNSResolve(args[1:], stdout, stderr)
+	default:
+		return &usageError{msg: fmt.Sprintf("未知子命令 %q，可用: resolve", args[0])}
+	}
+}
+
+// runDNSResolve 使用 [domaindns] 配置的DNS服务器解析域名，按记录类型输出IP
+func runDNSResolve(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("dns resolve", "dns resolve [-config 文件] [-timeout 时长] <域名>", stderr)
+	var cf configFlags
+	cf.register(fs)
+	timeout := fs.Duration("timeout", 30*time.Second, "等待解析结果的最长时间")
+	if err := parseFlags(fs, args, 1, 1); err != nil {
+		return err
+	}
+	cfg, err := cf.load()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	domain := fs.Arg(0)
+	pool, err := crawler.ResolveDomain(ctx, &cfg.DomainDNS, domain)
+	if err != nil {
+		return err
+	}
+
+	types := make([]string, 0, len(pool))
+	for t := range pool {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	total := 0
+	for _, t := range types {
+		for _, r := range pool[t] {
+			total++
+			if info := r.IPInfo; info != nil && (info.Country != "" || info.Org != "") {
+				fmt.Fprintf(stdout, "%s\t%s\t%s\t%s\n", t, r.IP, info.Country, info.Org)
+			} else {
+				fmt.Fprintf(stdout, "%s\t%s\n", t, r.IP)
+			}
+		}
+	}
+	if total == 0 {
+		return fmt.Errorf("域名 %s 没有解析结果", domain)
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	crawler "github.com/vistone/crawler-system"
+)
+
+// headerFlag 可重复的 -H "Name: value" 参数
+type headerFlag http.Header
+
+func (h headerFlag) String() string {
+	return fmt.Sprint(http.Header(h))
+}
+
+func (h headerFlag) Set(s string) error {
+	name, value, ok := strings.Cut(s, ":")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" {
+		return fmt.Errorf("请求头格式应为 \"Name: value\": %q", s)
+	}
+	http.Header(h).Add(name, strings.TrimSpace(value))
+	return nil
+}
+
+// runFetch 启动系统，通过指纹、本地IP池和连接配置发送一次请求
+//
+// 响应体输出到标准输出，状态行、指纹和出口IP等信息输出到标准错误。
+// 白名单为空时也允许启动（ip_status.allow_start_when_empty），一次性请求不受待机状态限制。
+func runFetch(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("fetch", "fetch [-config 文件] [-X 方法] [-H 请求头] [-d 请求体] [-i] <URL>", stderr)
+	var cf configFlags
+	cf.register(fs)
+	method := fs.String("X", http.MethodGet, "请求方法")
+	header := headerFlag{}
+	fs.Var(header, "H", "额外的请求头，如 \"Accept: application/json\"，可重复使用")
+	data := fs.String("d", "", "请求体，以 @ 开头时读取文件")
+	include := fs.Bool("i", false, "在响应体前输出响应头")
+	if err := parseFlags(fs, args, 1, 1); err != nil {
+		return err
+	}
+
+	req := crawler.FetchRequest{Method: strings.ToUpper(*method), URL: fs.Arg(0), Header: http.Header(header)}
+	if *data != "" {
+		body := []byte(*data)
+		if path, ok := strings.CutPrefix(*data, "@"); ok {
+			var err error
+			if body, err = os.ReadFile(path); err != nil {
+				return fmt.Errorf("读取请求体失败: %w", err)
+			}
+		}
+		req.Body = body
+		if !isFlagSet(fs, "X") {
+			req.Method = http.MethodPost
+		}
+	}
+
+	if cf.overrides == nil {
+		cf.overrides = crawler.OverrideFlag{}
+	}
+	if _, ok := cf.overrides["ip_status.allow_start_when_empty"]; !ok {
+		cf.overrides["ip_status.allow_start_when_empty"] = "true"
+	}
+
+	ctx := context.Background()
+	sys, err := crawler.NewSystem(ctx, cf.path, cf.options()...)
+	if err != nil {
+		return err
+	}
+	if err := sys.Start(ctx); err != nil {
+		_ = sys.Stop(ctx)
+		return err
+	}
+	defer sys.Stop(ctx)
+
+	resp, err := sys.Fetch(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(stderr, "%s %d %s\n", resp.Proto, resp.StatusCode, http.StatusText(resp.StatusCode))
+	fmt.Fprintf(stderr, "指纹: %s, 本地IP: %s, 目标: %s, 耗时: %v\n",
+		resp.Fingerprint, orDash(resp.LocalIP), resp.RemoteAddr, resp.Duration.Round(time.Millisecond))
+	if *include {
+		names := make([]string, 0, len(resp.Header))
+		for name := range resp.Header {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			for _, v := range resp.Header[name] {
+				fmt.Fprintf(stdout, "%s: %s\n", name, v)
+			}
+		}
+		fmt.Fprintln(stdout)
+	}
+	_, err = stdout.Write(resp.Body)
+	return err
+}
+
+func isFlagSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,81 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	crawler "github.com/vistone/crawler-system"
+)
+
+// configFlags 加载配置的公共参数
+type configFlags struct {
+	path      string
+	profile   string
+	overrides crawler.OverrideFlag
+	fs        *flag.FlagSet // 注册参数的参数集，用于判断是否显式指定了 -config
+}
+
+// register 在 fs 上注册 -config、-profile 和 -set 参数
+//
+// 显式指定的配置文件不存在时加载失败；未指定时 config.toml 不存在则使用默认配置。
+func (f *configFlags) register(fs *flag.FlagSet) {
+	f.fs = fs
+	fs.StringVar(&f.path, "config", "config.toml", "配置文件路径")
+	fs.StringVar(&f.profile, "profile", "", "选中的 profile（默认读取 CRAWLER_PROFILE）")
+	fs.Var(&f.overrides, "set", "覆盖配置项，如 server.listen_address=0.0.0.0:9443，可重复使用")
+}
+
+// options 返回对应的配置加载选项
+func (f *configFlags) options(extra ...crawler.LoadOption) []crawler.LoadOption {
+	opts := []crawler.LoadOption{crawler.WithOverrides(f.overrides), crawler.WithRequireFile(f.pathSet())}
+	if f.profile != "" {
+		opts = append(opts, crawler.WithProfile(f.profile))
+	}
+	return append(opts, extra...)
+}
+
+// pathSet 返回命令行是否显式指定了 -config
+func (f *configFlags) pathSet() bool {
+	set := false
+	if f.fs != nil {
+		f.fs.Visit(func(fl *flag.Flag) {
+			if fl.Name == "config" {
+				set = true
+			}
+		})
+	}
+	return set
+}
+
+// load 加载配置
+func (f *configFlags) load(extra ...crawler.LoadOption) (*crawler.SystemConfig, error) {
+	return crawler.LoadConfig(f.path, f.options(extra...)...)
+}
+
+// newFlagSet 创建子命令参数集，解析失败时输出用法
+func newFlagSet(name, usage string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "用法: crawler %s\n", usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFlags 解析参数并检查位置参数个数在 [min, max] 之间，max 小于 0 表示不限
+func parseFlags(fs *flag.FlagSet, args []string, min, max int) error {
+	if err := fs.Parse(args); err != nil {
+		return &usageError{msg: err.Error()}
+	}
+	if fs.NArg() < min || (max >= 0 && fs.NArg() > max) {
+		fs.Usage()
+		return &usageError{msg: "参数个数不正确"}
+	}
+	return nil
+}
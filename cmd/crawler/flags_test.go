@@ -0,0 +1,41 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExplicitConfigMustExist(t *testing.T) {
+	dir := t.TempDir()
+	missing := filepath.Join(dir, "does-not-exist.toml")
+	for _, args := range [][]string{
+		{"config", "validate", "-config", missing},
+		{"config", "print", "-config", missing},
+		{"run", "-config", missing},
+		{"serve", "-config", missing},
+		{"fetch", "-config", missing, "http://127.0.0.1/"},
+	} {
+		var stdout, stderr bytes.Buffer
+		if code := run(args, &stdout, &stderr); code != 1 {
+			t.Errorf("%v 退出码 %d，want 1（stdout %q）", args, code, stdout.String())
+		}
+		if !strings.Contains(stderr.String(), "does-not-exist.toml") {
+			t.Errorf("%v 错误输出 %q 没有指出缺失的配置文件", args, stderr.String())
+		}
+	}
+}
+
+func TestDefaultConfigMayBeMissing(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"config", "validate"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("未指定 -config 且 config.toml 不存在时退出码 %d，want 0: %s", code, stderr.String())
+	}
+}
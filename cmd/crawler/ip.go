@@ -0,0 +1,153 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strings"
+
+	crawler "github.com/vistone/crawler-system"
+)
+
+// ipSubcommands ip 的子命令
+const ipSubcommands = "status, whitelist, blacklist"
+
+// runIP 执行 ip 子命令，查看和修改 system.data_dir 下持久化的黑白名单
+func runIP(args []string, stdout, stderr io.Writer) error {
+	if len(args) == 0 {
+		return &usageError{msg: "缺少子命令，可用: " + ipSubcommands}
+	}
+	switch args[0] {
+	case "status":
+		return runIPStatus(args[1:], stdout, stderr)
+	case "whitelist", "blacklist":
+		return runIPList(args[0], args[1:], stdout, stderr)
+	default:
+		return &usageError{msg: fmt.Sprintf("未知子命令 %q，可用: %s", args[0], ipSubcommands)}
+	}
+}
+
+// runIPStatus 输出黑白名单概况和健康检查结果；指定IP时输出这些IP的状态
+func runIPStatus(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("ip status", "ip status [-config 文件] [IP...]", stderr)
+	var cf configFlags
+	cf.register(fs)
+	if err := parseFlags(fs, args, 0, -1); err != nil {
+		return err
+	}
+	cfg, err := cf.load()
+	if err != nil {
+		return err
+	}
+	manager, err := crawler.OpenIPStatusManager(cfg)
+	if err != nil {
+		return err
+	}
+
+	if fs.NArg() > 0 {
+		for _, ip := range fs.Args() {
+			fmt.Fprintf(stdout, "%s\t%s\n", ip, manager.GetStatus(ip))
+		}
+		return nil
+	}
+
+	fmt.Fprintf(stdout, "文件:     %s\n", crawler.IPStatusStorePath(cfg))
+	fmt.Fprintf(stdout, "白名单:   %d（最少 %d）\n", manager.GetWhitelistCount(), cfg.IPStatus.MinWhitelistCount)
+	fmt.Fprintf(stdout, "黑名单:   %d\n", len(manager.GetBlacklist()))
+	if err := manager.CheckSystemHealth(); err != nil {
+		fmt.Fprintf(stdout, "健康状态: 异常（%v）\n", err)
+	} else {
+		fmt.Fprintln(stdout, "健康状态: 正常")
+	}
+	return nil
+}
+
+// runIPList 执行 whitelist/blacklist 的 list、add、remove
+func runIPList(list string, args []string, stdout, stderr io.Writer) error {
+	const actions = "list, add, remove"
+	if len(args) == 0 {
+		return &usageError{msg: "缺少操作，可用: " + actions}
+	}
+	action := args[0]
+	usage := fmt.Sprintf("ip %s %s [-config 文件] IP...", list, action)
+	if list == "blacklist" && action == "add" {
+		usage = "ip blacklist add [-config 文件] [-reason 原因] IP..."
+	}
+	if action == "list" {
+		usage = fmt.Sprintf("ip %s list [-config 文件]", list)
+	}
+	fs := newFlagSet("ip "+list+" "+action, usage, stderr)
+	var cf configFlags
+	cf.register(fs)
+	reason := fs.String("reason", "manual", "加入或移出名单的原因")
+
+	switch action {
+	case "list":
+		if err := parseFlags(fs, args[1:], 0, 0); err != nil {
+			return err
+		}
+	case "add", "remove":
+		if err := parseFlags(fs, args[1:], 1, -1); err != nil {
+			return err
+		}
+		for _, ip := range fs.Args() {
+			if net.ParseIP(ip) == nil {
+				return &usageError{msg: fmt.Sprintf("无效的IP地址 %q", ip)}
+			}
+		}
+	default:
+		return &usageError{msg: fmt.Sprintf("未知操作 %q，可用: %s", action, actions)}
+	}
+
+	cfg, err := cf.load()
+	if err != nil {
+		return err
+	}
+	manager, err := crawler.OpenIPStatusManager(cfg)
+	if err != nil {
+		return err
+	}
+
+	if action == "list" {
+		if list == "whitelist" {
+			ips := manager.GetWhitelistIPs()
+			sort.Strings(ips)
+			fmt.Fprintln(stdout, strings.Join(ips, "\n"))
+			return nil
+		}
+		blacklist := manager.GetBlacklist()
+		ips := make([]string, 0, len(blacklist))
+		for ip := range blacklist {
+			ips = append(ips, ip)
+		}
+		sort.Strings(ips)
+		for _, ip := range ips {
+			fmt.Fprintf(stdout, "%s\t%s\n", ip, blacklist[ip])
+		}
+		return nil
+	}
+
+	for _, ip := range fs.Args() {
+		var err error
+		switch {
+		case list == "whitelist" && action == "add":
+			err = manager.AddToWhitelist(ip)
+		case list == "whitelist":
+			err = manager.RemoveFromWhitelist(ip, *reason)
+		case action == "add":
+			err = manager.AddToBlacklist(ip, *reason)
+		default:
+			err = manager.RemoveFromBlacklist(ip)
+		}
+		if err != nil {
+			return fmt.Errorf("更新 %s 失败: %w", ip, err)
+		}
+	}
+	fmt.Fprintf(stderr, "已更新 %s，共 %d 个IP；运行中的系统会自动加载修改\n", list, fs.NArg())
+	return nil
+}
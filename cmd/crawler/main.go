@@ -0,0 +1,87 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// crawler 爬虫系统命令行工具
+//
+// 用法:
+//
+//	crawler run [-config 文件] [-profile 名称] [-set key=value] [-watch 间隔] [-banner]
+//	crawler serve [-config 文件] [-profile 名称] [-set key=value] [-watch 间隔] [-banner]
+//	crawler config validate|print|defaults [-config 文件]
+//	crawler config migrate [-w] [-o 输出文件] [配置文件]
+//	crawler ip status [IP...]
+//	crawler ip whitelist|blacklist list|add|remove [IP...]
+//	crawler dns resolve <域名>
+//	crawler fetch [-X 方法] [-H 请求头] [-d 请求体] [-i] <URL>
+//
+// 各子命令加 -h 查看完整参数。
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// command 子命令
+type command struct {
+	name  string
+	usage string
+	run   func(args []string, stdout, stderr io.Writer) error
+}
+
+// usageError 命令行参数错误，以状态码 2 退出
+type usageError struct {
+	msg string
+}
+
+func (e *usageError) Error() string {
+	return e.msg
+}
+
+var commands = []command{
+	{name: "run", usage: "以客户端角色运行系统", run: runRun},
+	{name: "serve", usage: "以QUIC服务端角色运行系统（[server] 配置）", run: runServe},
+	{name: "config", usage: "配置文件工具（validate、print、defaults、migrate）", run: runConfig},
+	{name: "ip", usage: "查看和修改黑白名单（status、whitelist、blacklist）", run: runIP},
+	{name: "dns", usage: "解析域名（resolve）", run: runDNS},
+	{name: "fetch", usage: "通过指纹、本地IP池和连接配置发送一次请求", run: runFetch},
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run 执行子命令并返回退出状态码
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" || args[0] == "help" {
+		printUsage(stderr)
+		return 2
+	}
+	for _, cmd := range commands {
+		if cmd.name != args[0] {
+			continue
+		}
+		if err := cmd.run(args[1:], stdout, stderr); err != nil {
+			fmt.Fprintf(stderr, "crawler %s: %v\n", cmd.name, err)
+			if _, ok := err.(*usageError); ok {
+				return 2
+			}
+			return 1
+		}
+		return 0
+	}
+	fmt.Fprintf(stderr, "crawler: 未知命令 %q\n", args[0])
+	printUsage(stderr)
+	return 2
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "用法: crawler <命令> [参数]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "命令:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", cmd.name, cmd.usage)
+	}
+}
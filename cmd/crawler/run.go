@@ -0,0 +1,67 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"io"
+	"time"
+
+	crawler "github.com/vistone/crawler-system"
+)
+
+// runRun 以客户端角色运行系统，直到收到停止信号
+func runRun(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("run", "run [-config 文件] [-profile 名称] [-set key=value] [-watch 间隔] [-banner]", stderr)
+	var cf configFlags
+	cf.register(fs)
+	watch := fs.Duration("watch", 0, "配置文件检查间隔，为0时只在收到 SIGHUP 时重新加载")
+	banner := fs.Bool("banner", false, "启动时在标准输出打印各模块的初始化横幅")
+	if err := parseFlags(fs, args, 0, 0); err != nil {
+		return err
+	}
+	return runSystem(&cf, *watch, *banner, stdout)
+}
+
+// runServe 以QUIC服务端角色运行系统，按 [server] 配置监听客户端连接
+func runServe(args []string, stdout, stderr io.Writer) error {
+	fs := newFlagSet("serve", "serve [-config 文件] [-profile 名称] [-set key=value] [-watch 间隔] [-banner]", stderr)
+	var cf configFlags
+	cf.register(fs)
+	watch := fs.Duration("watch", 0, "配置文件检查间隔，为0时只在收到 SIGHUP 时重新加载")
+	banner := fs.Bool("banner", false, "启动时在标准输出打印各模块的初始化横幅")
+	if err := parseFlags(fs, args, 0, 0); err != nil {
+		return err
+	}
+	return runSystem(&cf, *watch, *banner, stdout, crawler.NewServerModule(nil))
+}
+
+// runSystem 创建系统、注册额外模块并阻塞运行，banner 为 true 时把启动报告渲染为横幅输出到 stdout
+func runSystem(cf *configFlags, watch time.Duration, banner bool, stdout io.Writer, modules ...crawler.Module) error {
+	ctx := context.Background()
+	sys, err := crawler.NewSystem(ctx, cf.path, cf.options()...)
+	if err != nil {
+		return err
+	}
+	if banner {
+		if err := sys.SetReportRenderer(crawler.ConsoleReportRenderer(stdout)); err != nil {
+			return err
+		}
+	}
+	for _, m := range modules {
+		if err := sys.RegisterModule(m); err != nil {
+			return err
+		}
+	}
+	if watch > 0 {
+		// 配置监听使用日志器，需在 Start 之后开启；Run 不会重复启动
+		if err := sys.Start(ctx); err != nil {
+			_ = sys.Stop(ctx)
+			return err
+		}
+		sys.WatchConfig(watch)
+	}
+	return sys.Run(ctx)
+}
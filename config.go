@@ -4,16 +4,20 @@
 
 package crawler
 
+//go:generate go run ./cmd/configgen
+
 import (
 	"fmt"
 	"os"
 	"time"
 
-	"github.com/pelletier/go-toml/v2"
+	"github.com/vistone/crawler-system/internal/config"
 )
 
 // SystemConfig 系统配置
 type SystemConfig struct {
+	ConfigVersion int `toml:"config_version"` // 配置文件格式版本（缺省视为 0，可用 crawler config migrate 升级）
+
 	Logs              LogsConfig              `toml:"logs"`
 	Fingerprint       FingerprintConfig       `toml:"fingerprint"`
 	DomainDNS         DomainDNSConfig         `toml:"domaindns"`
@@ -31,208 +35,161 @@ type SystemConfig struct {
 	System            SystemInfoConfig        `toml:"system"`
 }
 
-// LogsConfig 日志配置
-type LogsConfig struct {
-	Level       string `toml:"level"`       // debug, info, warn, error
-	OutputPath  string `toml:"output_path"` // 空表示输出到标准输出
-	FileEnabled bool   `toml:"file_enabled"`
-	FilePath    string `toml:"file_path"`
-	MaxSize     int    `toml:"max_size"` // MB
-	MaxBackups  int    `toml:"max_backups"`
-	Compress    bool   `toml:"compress"`
-	Format      string `toml:"format"` // json, text
-	ShowCaller  bool   `toml:"show_caller"`
-}
+// 各配置段的类型定义在 internal/config 中，模块初始化直接使用同一份类型
+type (
+	LogsConfig              = config.LogsConfig
+	FingerprintConfig       = config.FingerprintConfig
+	DomainDNSConfig         = config.DomainDNSConfig
+	LocalIPPoolConfig       = config.LocalIPPoolConfig
+	ConnConfig              = config.ConnConfig
+	NetConnPoolConfig       = config.NetConnPoolConfig
+	QUICConfig              = config.QUICConfig
+	CertificateConfig       = config.CertificateConfig
+	IPStatusConfig          = config.IPStatusConfig
+	IPPoolTestConfig        = config.IPPoolTestConfig
+	BlacklistRecoveryConfig = config.BlacklistRecoveryConfig
+	StatusReportConfig      = config.StatusReportConfig
+	ServerConfig            = config.ServerConfig
+	CrawlerConfig           = config.CrawlerConfig
+	SystemInfoConfig        = config.SystemInfoConfig
+)
 
-// FingerprintConfig 指纹配置
-type FingerprintConfig struct {
-	SelectionStrategy string   `toml:"selection_strategy"` // random, round_robin, least_used
-	EnableRotation    bool     `toml:"enable_rotation"`
-	RotationInterval  int      `toml:"rotation_interval"` // 秒
-	LibraryPath       string   `toml:"library_path"`
-	Browsers          []string `toml:"browsers"`
-	OSRandomization   bool     `toml:"os_randomization"`
-	UARandomization   bool     `toml:"ua_randomization"`
-}
+// LoadOption 配置加载选项
+type LoadOption func(*loadOptions)
 
-// DomainDNSConfig DNS解析配置
-type DomainDNSConfig struct {
-	DNSServers         []string `toml:"dns_servers"`
-	CacheEnabled       bool     `toml:"cache_enabled"`
-	CacheTTL           int      `toml:"cache_ttl"` // 秒
-	Timeout            int      `toml:"timeout"`   // 秒
-	MaxRetries         int      `toml:"max_retries"`
-	RetryInterval      int      `toml:"retry_interval"` // 秒
-	PollutionDetection bool     `toml:"pollution_detection"`
-	IPv6Enabled        bool     `toml:"ipv6_enabled"`
-	IPInfoToken        string   `toml:"ipinfo_token"` // IPInfo.io API Token
+// loadOptions 配置加载选项集合
+type loadOptions struct {
+	strict    bool              // 是否拒绝未知配置项
+	envPrefix string            // 环境变量前缀，为空表示不读取环境变量
+	profile   string            // 选中的 profile，为空时读取 <前缀>_PROFILE 环境变量
+	overrides map[string]string // 显式覆盖项，键为TOML路径
+	sources   ConfigSources     // 非nil时记录每个配置项的来源
+	require   bool              // 配置文件不存在时是否返回错误
 }
 
-// LocalIPPoolConfig 本地IP池配置
-type LocalIPPoolConfig struct {
-	IPs                   []string `toml:"ips"`
-	SelectionStrategy     string   `toml:"selection_strategy"` // random, round_robin, least_used
-	HealthCheckEnabled    bool     `toml:"health_check_enabled"`
-	HealthCheckInterval   int      `toml:"health_check_interval"` // 秒
-	HealthCheckTimeout    int      `toml:"health_check_timeout"`  // 秒
-	MaxFailures           int      `toml:"max_failures"`
-	RecoveryCheckInterval int      `toml:"recovery_check_interval"` // 秒
+// defaultLoadOptions 返回默认加载选项（严格模式开启，读取 CRAWLER_ 前缀的环境变量）
+func defaultLoadOptions() *loadOptions {
+	return &loadOptions{
+		strict:    true,
+		envPrefix: DefaultEnvPrefix,
+	}
 }
 
-// ConnConfig 连接配置
-type ConnConfig struct {
-	ConnectTimeout      int  `toml:"connect_timeout"` // 秒
-	ReadTimeout         int  `toml:"read_timeout"`    // 秒
-	WriteTimeout        int  `toml:"write_timeout"`   // 秒
-	KeepAlive           bool `toml:"keep_alive"`
-	KeepAliveTime       int  `toml:"keep_alive_time"` // 秒
-	MaxIdleConns        int  `toml:"max_idle_conns"`
-	MaxConnsPerHost     int  `toml:"max_conns_per_host"`
-	TLSHandshakeTimeout int  `toml:"tls_handshake_timeout"` // 秒
-	InsecureSkipVerify  bool `toml:"insecure_skip_verify"`
+// WithStrict 设置是否启用严格模式（默认启用）
+//
+// 严格模式下，配置文件中出现 SystemConfig 未定义的配置项（如拼写错误）
+// 会返回 *UnknownKeysError，并给出每个配置项所在的行号和列号。
+func WithStrict(strict bool) LoadOption {
+	return func(o *loadOptions) {
+		o.strict = strict
+	}
 }
 
-// NetConnPoolConfig TCP连接池配置
-type NetConnPoolConfig struct {
-	MaxConnections      int `toml:"max_connections"`
-	InitialConnections  int `toml:"initial_connections"`
-	AcquireTimeout      int `toml:"acquire_timeout"`       // 秒
-	IdleTimeout         int `toml:"idle_timeout"`          // 秒
-	MaxLifetime         int `toml:"max_lifetime"`          // 秒
-	HealthCheckInterval int `toml:"health_check_interval"` // 秒
-	HealthCheckTimeout  int `toml:"health_check_timeout"`  // 秒
+// WithEnvPrefix 设置环境变量前缀（默认 CRAWLER），传入空字符串表示不读取环境变量
+func WithEnvPrefix(prefix string) LoadOption {
+	return func(o *loadOptions) {
+		o.envPrefix = prefix
+	}
 }
 
-// QUICConfig QUIC连接池配置
-type QUICConfig struct {
-	MaxConnections      int  `toml:"max_connections"`
-	InitialConnections  int  `toml:"initial_connections"`
-	AcquireTimeout      int  `toml:"acquire_timeout"`       // 秒
-	IdleTimeout         int  `toml:"idle_timeout"`          // 秒
-	MaxLifetime         int  `toml:"max_lifetime"`          // 秒
-	HealthCheckInterval int  `toml:"health_check_interval"` // 秒
-	HealthCheckTimeout  int  `toml:"health_check_timeout"`  // 秒
-	HandshakeTimeout    int  `toml:"handshake_timeout"`     // 秒
-	Enable0RTT          bool `toml:"enable_0rtt"`
+// WithProfile 选择配置文件中 [profile.<name>] 定义的覆盖片段
+//
+// 未设置时读取环境变量 CRAWLER_PROFILE（前缀随 WithEnvPrefix 变化）。
+func WithProfile(name string) LoadOption {
+	return func(o *loadOptions) {
+		o.profile = name
+	}
 }
 
-// CertificateConfig 证书配置
-type CertificateConfig struct {
-	ServerDomain           string `toml:"server_domain"`
-	CertStoragePath        string `toml:"cert_storage_path"`
-	Provider               string `toml:"provider"` // letsencrypt, self-signed
-	AutoRenewal            bool   `toml:"auto_renewal"`
-	RenewalCheckInterval   int    `toml:"renewal_check_interval"` // 小时
-	RenewalBeforeDays      int    `toml:"renewal_before_days"`
-	LetsEncryptEmail       string `toml:"letsencrypt_email"`
-	LetsEncryptEnvironment string `toml:"letsencrypt_environment"` // production, staging
-	AutoDetectLocalIP      bool   `toml:"auto_detect_local_ip"`
-	SelfSignedValidityDays int    `toml:"self_signed_validity_days"`
+// selectedProfile 返回生效的 profile 名称
+func (o *loadOptions) selectedProfile() string {
+	if o.profile != "" || o.envPrefix == "" {
+		return o.profile
+	}
+	return os.Getenv(EnvVarName(o.envPrefix, "profile"))
 }
 
-// IPStatusConfig 黑白名单配置
-type IPStatusConfig struct {
-	MinWhitelistCount           int  `toml:"min_whitelist_count"`
-	AllowStartWhenEmpty         bool `toml:"allow_start_when_empty"`
-	WhitelistMonitoring         bool `toml:"whitelist_monitoring"`
-	WhitelistMonitoringInterval int  `toml:"whitelist_monitoring_interval"` // 秒
+// WithOverrides 设置显式覆盖项，键为TOML路径（如 server.listen_address），优先级最高
+func WithOverrides(overrides map[string]string) LoadOption {
+	return func(o *loadOptions) {
+		if o.overrides == nil {
+			o.overrides = make(map[string]string, len(overrides))
+		}
+		for k, v := range overrides {
+			o.overrides[k] = v
+		}
+	}
 }
 
-// IPPoolTestConfig IP池测试配置
-type IPPoolTestConfig struct {
-	TargetDomains        []string `toml:"target_domains"`
-	TestURL              string   `toml:"test_url"`
-	TestMethod           string   `toml:"test_method"` // GET, HEAD
-	MaxConcurrent        int      `toml:"max_concurrent"`
-	TestTimeout          int      `toml:"test_timeout"` // 秒
-	RetryCount           int      `toml:"retry_count"`
-	RetryInterval        int      `toml:"retry_interval"` // 秒
-	TestInterval         int      `toml:"test_interval"`  // 秒
-	UseFingerprint       bool     `toml:"use_fingerprint"`
-	SuccessStatusCodes   []int    `toml:"success_status_codes"`
-	ForbiddenStatusCodes []int    `toml:"forbidden_status_codes"`
+// WithSources 记录每个配置项最终生效值的来源到 dst
+func WithSources(dst ConfigSources) LoadOption {
+	return func(o *loadOptions) {
+		o.sources = dst
+	}
 }
 
-// BlacklistRecoveryConfig 黑名单恢复配置
-type BlacklistRecoveryConfig struct {
-	Enabled        bool   `toml:"enabled"`
-	CheckInterval  int    `toml:"check_interval"`   // 秒
-	IPTestInterval int    `toml:"ip_test_interval"` // 秒
-	MaxConcurrent  int    `toml:"max_concurrent"`
-	TestTimeout    int    `toml:"test_timeout"` // 秒
-	TestURL        string `toml:"test_url"`
-	TestMethod     string `toml:"test_method"` // GET, HEAD
-	UseFingerprint bool   `toml:"use_fingerprint"`
+// WithRequireFile 设置配置文件不存在时是否返回错误（默认使用默认配置）
+//
+// 命令行显式指定了配置文件路径时应启用，避免路径写错时静默地以默认配置运行。
+func WithRequireFile(require bool) LoadOption {
+	return func(o *loadOptions) {
+		o.require = require
+	}
 }
 
-// StatusReportConfig 状态报告配置
-type StatusReportConfig struct {
-	ReportInterval      int  `toml:"report_interval"` // 秒
-	ReportOnChange      bool `toml:"report_on_change"`
-	ReportClientDetails bool `toml:"report_client_details"`
-	ReportIPList        bool `toml:"report_ip_list"`
-	MaxReportIPs        int  `toml:"max_report_ips"`
-	CompressData        bool `toml:"compress_data"`
-}
+// LoadConfig 加载配置文件
+//
+// 配置按以下顺序分层叠加，后者覆盖前者：
+// DefaultConfig → include 的文件 → 配置文件 → 选中的 profile → 环境变量 → 显式覆盖项。
+// 叠加完成后解析 Secret 字段中的 env:/file: 引用，再调用 Validate 做语义校验，
+// 校验不通过时返回 *ValidationError。
+func LoadConfig(path string, opts ...LoadOption) (*SystemConfig, error) {
+	options := defaultLoadOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+	sources := options.sources
+	if sources == nil {
+		sources = ConfigSources{}
+	}
 
-// ServerConfig 服务端配置
-type ServerConfig struct {
-	ListenAddress     string `toml:"listen_address"`
-	QUICEnabled       bool   `toml:"quic_enabled"`
-	MaxClients        int    `toml:"max_clients"`
-	ClientTimeout     int    `toml:"client_timeout"` // 秒
-	ClientAuthEnabled bool   `toml:"client_auth_enabled"`
-	ClientCertPath    string `toml:"client_cert_path"`
-	AccessLogEnabled  bool   `toml:"access_log_enabled"`
-	AccessLogPath     string `toml:"access_log_path"`
-}
+	config := DefaultConfig()
 
-// CrawlerConfig 爬虫配置
-type CrawlerConfig struct {
-	DefaultTimeout       int      `toml:"default_timeout"` // 秒
-	MaxRetries           int      `toml:"max_retries"`
-	RetryInterval        int      `toml:"retry_interval"` // 秒
-	ProtocolPriority     []string `toml:"protocol_priority"`
-	ProtocolFallback     bool     `toml:"protocol_fallback"`
-	Concurrency          int      `toml:"concurrency"`
-	RateLimit            int      `toml:"rate_limit"` // 每秒请求数，0表示不限制
-	QueueEnabled         bool     `toml:"queue_enabled"`
-	QueueSize            int      `toml:"queue_size"`
-	DeduplicationEnabled bool     `toml:"deduplication_enabled"`
-	DeduplicationTTL     int      `toml:"deduplication_ttl"` // 秒
-}
+	// 加载配置文件及其包含的文件（配置文件不存在且未启用 WithRequireFile 时使用默认配置）
+	loader := &fileLoader{strict: options.strict, sources: sources}
+	if _, err := os.Stat(path); err == nil {
+		if err := loader.load(config, path); err != nil {
+			return nil, fmt.Errorf("解析配置文件失败: %w", err)
+		}
+	} else if !os.IsNotExist(err) || options.require {
+		return nil, fmt.Errorf("读取配置文件失败: %w", err)
+	}
 
-// SystemInfoConfig 系统信息配置
-type SystemInfoConfig struct {
-	Name                  string `toml:"name"`
-	Version               string `toml:"version"`
-	WorkDir               string `toml:"work_dir"`
-	DataDir               string `toml:"data_dir"`
-	PerformanceMonitoring bool   `toml:"performance_monitoring"`
-	PerformanceInterval   int    `toml:"performance_interval"` // 秒
-	HealthCheckEnabled    bool   `toml:"health_check_enabled"`
-	HealthCheckPort       int    `toml:"health_check_port"`
-	MetricsEnabled        bool   `toml:"metrics_enabled"`
-	MetricsPort           int    `toml:"metrics_port"`
-}
+	// 叠加选中的 profile
+	if profile := options.selectedProfile(); profile != "" {
+		if err := loader.applyProfile(config, profile); err != nil {
+			return nil, err
+		}
+	}
 
-// LoadConfig 加载配置文件
-func LoadConfig(path string) (*SystemConfig, error) {
-	// 如果文件不存在，返回默认配置
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		return DefaultConfig(), nil
+	// 环境变量覆盖
+	if err := applyEnv(config, options.envPrefix, sources); err != nil {
+		return nil, fmt.Errorf("应用环境变量失败: %w", err)
 	}
 
-	// 读取配置文件
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return nil, fmt.Errorf("读取配置文件失败: %w", err)
+	// 显式覆盖
+	if err := applyOverrides(config, options.overrides, sources); err != nil {
+		return nil, fmt.Errorf("应用覆盖项失败: %w", err)
+	}
+
+	// 解析敏感值引用（env:NAME、file:/path）
+	if err := resolveSecrets(config); err != nil {
+		return nil, fmt.Errorf("解析敏感配置失败: %w", err)
 	}
 
-	// 解析TOML配置
-	config := &SystemConfig{}
-	if err := toml.Unmarshal(data, config); err != nil {
-		return nil, fmt.Errorf("解析配置文件失败: %w", err)
+	// 语义校验
+	if err := config.Validate(); err != nil {
+		return nil, err
 	}
 
 	return config, nil
@@ -241,6 +198,7 @@ func LoadConfig(path string) (*SystemConfig, error) {
 // DefaultConfig 返回默认配置
 func DefaultConfig() *SystemConfig {
 	return &SystemConfig{
+		ConfigVersion: CurrentConfigVersion,
 		Logs: LogsConfig{
 			Level:       "info",
 			OutputPath:  "",
@@ -255,19 +213,21 @@ func DefaultConfig() *SystemConfig {
 		Fingerprint: FingerprintConfig{
 			SelectionStrategy: "random",
 			EnableRotation:    true,
-			RotationInterval:  300,
+			RotationInterval:  Duration(5 * time.Minute),
 			LibraryPath:       "",
 			Browsers:          []string{},
+			OperatingSystems:  []string{},
 			OSRandomization:   true,
 			UARandomization:   true,
+			HealthHalfLife:    Duration(30 * time.Minute),
 		},
 		DomainDNS: DomainDNSConfig{
 			DNSServers:         []string{"8.8.8.8", "8.8.4.4", "1.1.1.1", "1.0.0.1"},
 			CacheEnabled:       true,
-			CacheTTL:           3600,
-			Timeout:            5,
+			CacheTTL:           Duration(1 * time.Hour),
+			Timeout:            Duration(5 * time.Second),
 			MaxRetries:         3,
-			RetryInterval:      1,
+			RetryInterval:      Duration(1 * time.Second),
 			PollutionDetection: true,
 			IPv6Enabled:        true,
 		},
@@ -275,146 +235,113 @@ func DefaultConfig() *SystemConfig {
 			IPs:                   []string{},
 			SelectionStrategy:     "round_robin",
 			HealthCheckEnabled:    true,
-			HealthCheckInterval:   60,
-			HealthCheckTimeout:    5,
+			HealthCheckInterval:   Duration(1 * time.Minute),
+			HealthCheckTimeout:    Duration(5 * time.Second),
 			MaxFailures:           3,
-			RecoveryCheckInterval: 300,
+			RecoveryCheckInterval: Duration(5 * time.Minute),
 		},
 		Conn: ConnConfig{
-			ConnectTimeout:      10,
-			ReadTimeout:         30,
-			WriteTimeout:        30,
+			ConnectTimeout:      Duration(10 * time.Second),
+			ReadTimeout:         Duration(30 * time.Second),
+			WriteTimeout:        Duration(30 * time.Second),
 			KeepAlive:           true,
-			KeepAliveTime:       60,
+			KeepAliveTime:       Duration(1 * time.Minute),
 			MaxIdleConns:        100,
 			MaxConnsPerHost:     10,
-			TLSHandshakeTimeout: 10,
+			TLSHandshakeTimeout: Duration(10 * time.Second),
 			InsecureSkipVerify:  false,
 		},
 		NetConnPool: NetConnPoolConfig{
 			MaxConnections:      100,
 			InitialConnections:  10,
-			AcquireTimeout:      5,
-			IdleTimeout:         300,
-			MaxLifetime:         3600,
-			HealthCheckInterval: 60,
-			HealthCheckTimeout:  5,
+			AcquireTimeout:      Duration(5 * time.Second),
+			IdleTimeout:         Duration(5 * time.Minute),
+			MaxLifetime:         Duration(1 * time.Hour),
+			HealthCheckInterval: Duration(1 * time.Minute),
+			HealthCheckTimeout:  Duration(5 * time.Second),
 		},
 		QUIC: QUICConfig{
-			MaxConnections:      50,
-			InitialConnections:  5,
-			AcquireTimeout:      5,
-			IdleTimeout:         300,
-			MaxLifetime:         3600,
-			HealthCheckInterval: 60,
-			HealthCheckTimeout:  5,
-			HandshakeTimeout:    10,
-			Enable0RTT:          true,
+			MaxConnections:     50,
+			InitialConnections: 5,
+			IdleTimeout:        Duration(5 * time.Minute),
+			MaxLifetime:        Duration(1 * time.Hour),
 		},
 		Certificate: CertificateConfig{
 			ServerDomain:           "crawler.example.com",
 			CertStoragePath:        "./certs",
 			Provider:               "letsencrypt",
 			AutoRenewal:            true,
-			RenewalCheckInterval:   24,
-			RenewalBeforeDays:      30,
-			LetsEncryptEmail:       "admin@example.com",
-			LetsEncryptEnvironment: "production",
+			RenewalCheckInterval:   HourDuration(24 * time.Hour),
+			RenewalBeforeDays:      DayDuration(30 * day),
 			AutoDetectLocalIP:      true,
-			SelfSignedValidityDays: 365,
+			SelfSignedValidityDays: DayDuration(365 * day),
 		},
 		IPStatus: IPStatusConfig{
 			MinWhitelistCount:           1,
 			AllowStartWhenEmpty:         true,
 			WhitelistMonitoring:         true,
-			WhitelistMonitoringInterval: 60,
+			WhitelistMonitoringInterval: Duration(1 * time.Minute),
 		},
 		IPPoolTest: IPPoolTestConfig{
 			TargetDomains:        []string{},
 			TestURL:              "https://{domain}/",
 			TestMethod:           "HEAD",
 			MaxConcurrent:        10,
-			TestTimeout:          10,
+			TestTimeout:          Duration(10 * time.Second),
 			RetryCount:           2,
-			RetryInterval:        5,
-			TestInterval:         300,
+			RetryInterval:        Duration(5 * time.Second),
+			TestInterval:         Duration(5 * time.Minute),
 			UseFingerprint:       true,
 			SuccessStatusCodes:   []int{200, 201, 202, 204},
 			ForbiddenStatusCodes: []int{403},
 		},
 		BlacklistRecovery: BlacklistRecoveryConfig{
 			Enabled:        true,
-			CheckInterval:  1800, // 30分钟
-			IPTestInterval: 3600, // 1小时
+			CheckInterval:  Duration(30 * time.Minute),
+			IPTestInterval: Duration(1 * time.Hour),
 			MaxConcurrent:  5,
-			TestTimeout:    10,
+			TestTimeout:    Duration(10 * time.Second),
 			TestURL:        "https://{domain}/",
 			TestMethod:     "HEAD",
 			UseFingerprint: true,
 		},
 		StatusReport: StatusReportConfig{
-			ReportInterval:      5,
-			ReportOnChange:      true,
-			ReportClientDetails: true,
-			ReportIPList:        true,
-			MaxReportIPs:        1000,
-			CompressData:        false,
+			ReportInterval: Duration(5 * time.Second),
+			ReportOnChange: true,
+			ReportIPList:   true,
+			MaxReportIPs:   1000,
 		},
 		Server: ServerConfig{
-			ListenAddress:     "0.0.0.0:8443",
-			QUICEnabled:       true,
-			MaxClients:        1000,
-			ClientTimeout:     300,
-			ClientAuthEnabled: false,
-			ClientCertPath:    "",
-			AccessLogEnabled:  true,
-			AccessLogPath:     "./logs/access.log",
+			ListenAddress:       "0.0.0.0:8443",
+			QUICEnabled:         true,
+			MaxClients:          1000,
+			ClientTimeout:       Duration(5 * time.Minute),
+			ClientAuthEnabled:   false,
+			ClientCertPath:      "",
+			AccessLogEnabled:    true,
+			AccessLogPath:       "./logs/access.log",
+			AccessLogFormat:     "combined",
+			AccessLogMaxSize:    100,
+			AccessLogMaxBackups: 10,
+			AccessLogCompress:   true,
 		},
 		Crawler: CrawlerConfig{
-			DefaultTimeout:       30,
+			DefaultTimeout:       Duration(30 * time.Second),
 			MaxRetries:           3,
-			RetryInterval:        2,
-			ProtocolPriority:     []string{"http3", "http2", "http1.1"},
-			ProtocolFallback:     true,
+			RetryInterval:        Duration(2 * time.Second),
 			Concurrency:          10,
 			RateLimit:            0,
 			QueueEnabled:         true,
 			QueueSize:            1000,
 			DeduplicationEnabled: false,
-			DeduplicationTTL:     3600,
+			DeduplicationTTL:     Duration(1 * time.Hour),
 		},
 		System: SystemInfoConfig{
-			Name:                  "crawler-system",
-			Version:               "1.0.0",
-			WorkDir:               "./",
-			DataDir:               "./data",
-			PerformanceMonitoring: true,
-			PerformanceInterval:   60,
-			HealthCheckEnabled:    true,
-			HealthCheckPort:       8080,
-			MetricsEnabled:        true,
-			MetricsPort:           9090,
+			Name:              "crawler-system",
+			Version:           "1.0.0",
+			DataDir:           "./data",
+			ShutdownTimeout:   Duration(30 * time.Second),
+			ModuleStopTimeout: Duration(10 * time.Second),
 		},
 	}
 }
-
-// GetDuration 辅助函数：将秒转换为time.Duration
-func (c *ConnConfig) GetConnectTimeout() time.Duration {
-	return time.Duration(c.ConnectTimeout) * time.Second
-}
-
-func (c *ConnConfig) GetReadTimeout() time.Duration {
-	return time.Duration(c.ReadTimeout) * time.Second
-}
-
-func (c *ConnConfig) GetWriteTimeout() time.Duration {
-	return time.Duration(c.WriteTimeout) * time.Second
-}
-
-func (c *ConnConfig) GetKeepAliveTime() time.Duration {
-	return time.Duration(c.KeepAliveTime) * time.Second
-}
-
-func (c *ConnConfig) GetTLSHandshakeTimeout() time.Duration {
-	return time.Duration(c.TLSHandshakeTimeout) * time.Second
-}
@@ -0,0 +1,91 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package crawler
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// PositionError 带文件位置信息的配置错误
+type PositionError struct {
+	File    string // 配置文件路径
+	Line    int    // 行号（从1开始）
+	Column  int    // 列号（从1开始）
+	Key     string // 出错的配置项路径，如 netconnpool.max_conection
+	Message string // 错误说明
+}
+
+func (e *PositionError) Error() string {
+	if e.Key != "" {
+		return fmt.Sprintf("%s:%d:%d: %s: %s", e.File, e.Line, e.Column, e.Message, e.Key)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s", e.File, e.Line, e.Column, e.Message)
+}
+
+// UnknownKeysError 严格模式下配置文件中存在 SystemConfig 未定义的配置项
+type UnknownKeysError struct {
+	File string
+	Keys []*PositionError
+}
+
+func (e *UnknownKeysError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "配置文件 %s 中存在 %d 个未知配置项:", e.File, len(e.Keys))
+	for _, k := range e.Keys {
+		b.WriteString("\n  - ")
+		b.WriteString(k.Error())
+	}
+	return b.String()
+}
+
+// decodeTOML 解析TOML数据到v，strict为true时拒绝未知配置项
+//
+// 语法和类型错误返回 *PositionError，未知配置项返回 *UnknownKeysError。
+func decodeTOML(file string, data []byte, v interface{}, strict bool) error {
+	decoder := toml.NewDecoder(bytes.NewReader(data))
+	if strict {
+		decoder.DisallowUnknownFields()
+	}
+
+	err := decoder.Decode(v)
+	if err == nil {
+		return nil
+	}
+
+	var missing *toml.StrictMissingError
+	if errors.As(err, &missing) {
+		unknown := &UnknownKeysError{File: file}
+		for i := range missing.Errors {
+			de := &missing.Errors[i]
+			line, col := de.Position()
+			unknown.Keys = append(unknown.Keys, &PositionError{
+				File:    file,
+				Line:    line,
+				Column:  col,
+				Key:     strings.Join(de.Key(), "."),
+				Message: "未知配置项",
+			})
+		}
+		return unknown
+	}
+
+	var de *toml.DecodeError
+	if errors.As(err, &de) {
+		line, col := de.Position()
+		return &PositionError{
+			File:    file,
+			Line:    line,
+			Column:  col,
+			Message: strings.TrimPrefix(de.Error(), "toml: "),
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,320 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package crawler
+
+import (
+	"regexp"
+	"strings"
+)
+
+// tomlDocument 按行编辑的TOML文档，用于配置迁移
+//
+// 只识别表头（[a.b]）和单个键的赋值（key = value，值可以跨行），
+// 其余内容（注释、空行）原样保留。移动或删除配置项时，紧挨在其上方的注释随之移动或删除。
+// 不识别点分键（a.b = 1）和内联表中的配置项。
+type tomlDocument struct {
+	entries []*tomlEntry
+}
+
+// tomlEntry 文档中的一段内容：表头、赋值或其他行
+type tomlEntry struct {
+	table   string   // 所属表（表头行为表名本身，顶层为空）
+	header  bool     // 是否为表头行
+	key     string   // 赋值的键名
+	lead    string   // 原始的 "key = " 部分，键未重命名时原样输出以保留缩进和对齐
+	origKey string   // 解析时的键名
+	value   string   // 赋值的值文本（不含行尾注释）
+	comment string   // 行尾注释（含前导空白和 #）
+	lines   []string // 原始行，其他行和表头行使用
+}
+
+var (
+	tableHeaderPattern = regexp.MustCompile(`^\s*\[\[?\s*([^\[\]]+?)\s*\]\]?\s*(#.*)?$`)
+	keyValuePattern    = regexp.MustCompile(`^(\s*([A-Za-z0-9_-]+)\s*=\s*)(.*)$`)
+)
+
+// parseTOMLDocument 解析TOML文本
+func parseTOMLDocument(data []byte) *tomlDocument {
+	doc := &tomlDocument{}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	table := ""
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if m := tableHeaderPattern.FindStringSubmatch(line); m != nil {
+			table = m[1]
+			doc.entries = append(doc.entries, &tomlEntry{table: table, header: true, lines: []string{line}})
+			continue
+		}
+		m := keyValuePattern.FindStringSubmatch(line)
+		if m == nil {
+			doc.entries = append(doc.entries, &tomlEntry{table: table, lines: []string{line}})
+			continue
+		}
+
+		// 值可能跨行（多行数组、多行字符串），读到值完整为止
+		raw := m[3]
+		for !valueComplete(raw) && i+1 < len(lines) {
+			i++
+			raw += "\n" + lines[i]
+		}
+		value, comment := splitTrailingComment(raw)
+		doc.entries = append(doc.entries, &tomlEntry{
+			table: table, key: m[2], lead: m[1], origKey: m[2], value: value, comment: comment,
+		})
+	}
+	return doc
+}
+
+// Bytes 返回文档文本
+func (d *tomlDocument) Bytes() []byte {
+	var b strings.Builder
+	for _, e := range d.entries {
+		if e.key != "" {
+			lead := e.key + " = "
+			if e.key == e.origKey && e.lead != "" {
+				lead = e.lead
+			}
+			b.WriteString(lead + e.value + e.comment + "\n")
+			continue
+		}
+		for _, line := range e.lines {
+			b.WriteString(line + "\n")
+		}
+	}
+	return []byte(b.String())
+}
+
+// tables 返回与配置段匹配的所有表：配置段本身以及各 profile 中的同名配置段
+func (d *tomlDocument) tables(section string) []string {
+	var tables []string
+	seen := make(map[string]bool)
+	add := func(t string) {
+		if !seen[t] {
+			seen[t] = true
+			tables = append(tables, t)
+		}
+	}
+	for _, e := range d.entries {
+		if !e.header {
+			continue
+		}
+		if e.table == section {
+			add(e.table)
+		}
+		if name, ok := strings.CutPrefix(e.table, "profile."); ok {
+			if _, s, ok := strings.Cut(name, "."); ok && s == section {
+				add(e.table)
+			}
+		}
+	}
+	return tables
+}
+
+// find 返回表中键的位置，不存在返回 -1
+func (d *tomlDocument) find(table, key string) int {
+	for i, e := range d.entries {
+		if e.key == key && e.table == table {
+			return i
+		}
+	}
+	return -1
+}
+
+// Get 返回表中键的值文本
+func (d *tomlDocument) Get(table, key string) (string, bool) {
+	if i := d.find(table, key); i >= 0 {
+		return d.entries[i].value, true
+	}
+	return "", false
+}
+
+// Set 设置表中键的值文本，保留行尾注释；键不存在时追加到表末尾
+func (d *tomlDocument) Set(table, key, value string) {
+	if i := d.find(table, key); i >= 0 {
+		d.entries[i].value = value
+		return
+	}
+	d.insert(table, []*tomlEntry{{table: table, key: key, value: value}})
+}
+
+// RenameKey 重命名配置段（含各 profile 中的同名配置段）中的键
+func (d *tomlDocument) RenameKey(section, oldKey, newKey string) {
+	for _, table := range d.tables(section) {
+		if i := d.find(table, oldKey); i >= 0 {
+			d.entries[i].key = newKey
+		}
+	}
+}
+
+// RenameSection 重命名配置段（含各 profile 中的同名配置段）
+func (d *tomlDocument) RenameSection(oldSection, newSection string) {
+	for _, table := range d.tables(oldSection) {
+		renamed := strings.TrimSuffix(table, oldSection) + newSection
+		for _, e := range d.entries {
+			if e.table != table {
+				continue
+			}
+			e.table = renamed
+			if e.header {
+				e.lines[0] = strings.Replace(e.lines[0], table, renamed, 1)
+			}
+		}
+	}
+}
+
+// MoveKey 将配置项（连同其上方紧挨的注释）移动到另一个配置段并重命名，
+// 各 profile 中的同名配置段同样处理
+func (d *tomlDocument) MoveKey(fromSection, key, toSection, newKey string) {
+	for _, table := range d.tables(fromSection) {
+		moved := d.remove(table, key)
+		if moved == nil {
+			continue
+		}
+		target := strings.TrimSuffix(table, fromSection) + toSection
+		for _, e := range moved {
+			e.table = target
+		}
+		moved[len(moved)-1].key = newKey
+		d.insert(target, moved)
+	}
+}
+
+// DeleteKey 删除配置项及其上方紧挨的注释，各 profile 中的同名配置段同样处理
+func (d *tomlDocument) DeleteKey(section, key string) {
+	for _, table := range d.tables(section) {
+		d.remove(table, key)
+	}
+}
+
+// remove 删除表中的键，返回被删除的注释行和赋值
+func (d *tomlDocument) remove(table, key string) []*tomlEntry {
+	i := d.find(table, key)
+	if i < 0 {
+		return nil
+	}
+	start := i
+	for start > 0 && isCommentEntry(d.entries[start-1]) {
+		start--
+	}
+	removed := append([]*tomlEntry{}, d.entries[start:i+1]...)
+	d.entries = append(d.entries[:start], d.entries[i+1:]...)
+	return removed
+}
+
+// insert 将内容插入到表的最后一个赋值之后，表不存在时在文档末尾新建
+func (d *tomlDocument) insert(table string, entries []*tomlEntry) {
+	pos := -1
+	for i, e := range d.entries {
+		if e.table == table && (e.header || e.key != "") {
+			pos = i + 1
+		}
+	}
+	if pos < 0 {
+		if len(d.entries) > 0 && !isBlankEntry(d.entries[len(d.entries)-1]) {
+			d.entries = append(d.entries, &tomlEntry{table: table, lines: []string{""}})
+		}
+		if table != "" {
+			d.entries = append(d.entries, &tomlEntry{table: table, header: true, lines: []string{"[" + table + "]"}})
+		}
+		d.entries = append(d.entries, entries...)
+		return
+	}
+	d.entries = append(d.entries[:pos], append(entries, d.entries[pos:]...)...)
+}
+
+// SetTopLevel 设置顶层键的值，不存在时插入到第一个表（及其上方的注释）之前
+func (d *tomlDocument) SetTopLevel(key, value string) {
+	if i := d.find("", key); i >= 0 {
+		d.entries[i].value = value
+		return
+	}
+	pos := len(d.entries)
+	for i, e := range d.entries {
+		if e.header {
+			pos = i
+			break
+		}
+	}
+	for pos > 0 && isCommentEntry(d.entries[pos-1]) {
+		pos--
+	}
+	inserted := []*tomlEntry{{key: key, value: value}, {lines: []string{""}}}
+	d.entries = append(d.entries[:pos], append(inserted, d.entries[pos:]...)...)
+}
+
+func isCommentEntry(e *tomlEntry) bool {
+	return !e.header && e.key == "" && strings.HasPrefix(strings.TrimSpace(e.lines[0]), "#")
+}
+
+func isBlankEntry(e *tomlEntry) bool {
+	return !e.header && e.key == "" && strings.TrimSpace(e.lines[0]) == ""
+}
+
+// valueComplete 判断值文本是否完整：括号闭合且不在多行字符串内
+func valueComplete(raw string) bool {
+	depth := 0
+	for i := 0; i < len(raw); i++ {
+		switch c := raw[i]; {
+		case c == '#':
+			// 注释到行尾
+			for i < len(raw) && raw[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(raw[i:], `"""`) || strings.HasPrefix(raw[i:], "'''"):
+			delim := raw[i : i+3]
+			end := strings.Index(raw[i+3:], delim)
+			if end < 0 {
+				return false
+			}
+			i += 3 + end + 2
+		case c == '"' || c == '\'':
+			i = skipString(raw, i)
+		case c == '[' || c == '{':
+			depth++
+		case c == ']' || c == '}':
+			depth--
+		}
+	}
+	return depth <= 0
+}
+
+// skipString 跳过从 i 开始的单行字符串，返回结束引号的位置
+func skipString(raw string, i int) int {
+	quote := raw[i]
+	for j := i + 1; j < len(raw); j++ {
+		switch raw[j] {
+		case '\\':
+			if quote == '"' {
+				j++
+			}
+		case quote, '\n':
+			return j
+		}
+	}
+	return len(raw)
+}
+
+// splitTrailingComment 拆分值文本和最后一行的行尾注释
+func splitTrailingComment(raw string) (value, comment string) {
+	for i := 0; i < len(raw); i++ {
+		switch c := raw[i]; {
+		case strings.HasPrefix(raw[i:], `"""`) || strings.HasPrefix(raw[i:], "'''"):
+			if end := strings.Index(raw[i+3:], raw[i:i+3]); end >= 0 {
+				i += 3 + end + 2
+			}
+		case c == '"' || c == '\'':
+			i = skipString(raw, i)
+		case c == '#':
+			end := strings.IndexByte(raw[i:], '\n')
+			if end < 0 {
+				// 最后一行的行尾注释
+				value = strings.TrimRight(raw[:i], " \t")
+				return value, raw[len(value):]
+			}
+			i += end
+		}
+	}
+	return raw, ""
+}
@@ -0,0 +1,115 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package crawler
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// configField SystemConfig 中的一个叶子配置项
+type configField struct {
+	Path   string              // TOML路径，如 server.listen_address
+	Field  reflect.StructField // 结构体字段定义
+	Value  reflect.Value       // 字段值（可寻址）
+	Parent reflect.Type        // 所属配置段的结构体类型
+}
+
+// walkConfigFields 按声明顺序遍历配置的所有叶子配置项
+//
+// 嵌套的配置段（结构体）会被展开，其余类型（含切片、映射、实现了 TextUnmarshaler 的类型）视为叶子。
+func walkConfigFields(cfg *SystemConfig, fn func(f configField)) {
+	walkStruct(reflect.ValueOf(cfg).Elem(), "", fn)
+}
+
+func walkStruct(v reflect.Value, prefix string, fn func(f configField)) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		key := tomlKey(sf)
+		if key == "" {
+			continue
+		}
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		fv := v.Field(i)
+		if isConfigSection(sf.Type) {
+			walkStruct(fv, path, fn)
+			continue
+		}
+		fn(configField{Path: path, Field: sf, Value: fv, Parent: t})
+	}
+}
+
+// lookupConfigField 按TOML路径查找配置项
+func lookupConfigField(cfg *SystemConfig, path string) (configField, bool) {
+	var found configField
+	ok := false
+	walkConfigFields(cfg, func(f configField) {
+		if !ok && f.Path == path {
+			found, ok = f, true
+		}
+	})
+	return found, ok
+}
+
+// lookupMapEntry 按TOML路径查找映射类型配置项中的条目，如 logs.levels.domaindns，返回配置项和条目键
+func lookupMapEntry(cfg *SystemConfig, path string) (configField, string, bool) {
+	parent, key, ok := cutLast(path, ".")
+	if !ok || !isMapConfigField(parent) {
+		return configField{}, "", false
+	}
+	f, ok := lookupConfigField(cfg, parent)
+	return f, key, ok
+}
+
+// mapFieldPaths 映射类型配置项的TOML路径，这类配置项的键由用户定义
+var mapFieldPaths = sync.OnceValue(func() map[string]bool {
+	paths := make(map[string]bool)
+	walkConfigFields(&SystemConfig{}, func(f configField) {
+		if f.Value.Kind() == reflect.Map {
+			paths[f.Path] = true
+		}
+	})
+	return paths
+})
+
+// isMapConfigField 判断TOML路径是否为映射类型的配置项
+func isMapConfigField(path string) bool {
+	return mapFieldPaths()[path]
+}
+
+func cutLast(s, sep string) (before, after string, found bool) {
+	if i := strings.LastIndex(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
+
+// tomlKey 返回字段的TOML键名，未导出或标记为 "-" 的字段返回空
+func tomlKey(sf reflect.StructField) string {
+	if sf.PkgPath != "" {
+		return ""
+	}
+	tag := sf.Tag.Get("toml")
+	if tag == "-" {
+		return ""
+	}
+	if name, _, _ := strings.Cut(tag, ","); name != "" {
+		return name
+	}
+	return sf.Name
+}
+
+// isConfigSection 判断类型是否为需要展开的配置段
+func isConfigSection(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	return !reflect.PointerTo(t).Implements(textUnmarshalerType)
+}
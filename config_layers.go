@@ -0,0 +1,352 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package crawler
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+
+	"github.com/vistone/crawler-system/internal/config"
+)
+
+// DefaultEnvPrefix 环境变量覆盖的默认前缀
+//
+// 环境变量名由前缀和配置项的TOML路径生成，例如
+// server.listen_address 对应 CRAWLER_SERVER_LISTEN_ADDRESS。
+const DefaultEnvPrefix = "CRAWLER"
+
+// ConfigLayer 配置值的来源层，按优先级从低到高排列
+type ConfigLayer int
+
+const (
+	LayerDefault  ConfigLayer = iota // DefaultConfig 默认值
+	LayerFile                        // 配置文件（含 include 的文件）
+	LayerProfile                     // 选中的 profile 片段
+	LayerEnv                         // 环境变量
+	LayerOverride                    // 显式覆盖（如命令行参数）
+)
+
+func (l ConfigLayer) String() string {
+	switch l {
+	case LayerDefault:
+		return "default"
+	case LayerFile:
+		return "file"
+	case LayerProfile:
+		return "profile"
+	case LayerEnv:
+		return "env"
+	case LayerOverride:
+		return "override"
+	default:
+		return "unknown"
+	}
+}
+
+// ValueSource 某个配置项最终生效值的来源
+type ValueSource struct {
+	Layer  ConfigLayer
+	Origin string // 来源细节：文件路径、profile、环境变量名或覆盖键
+}
+
+// ConfigSources 记录每个配置项（TOML路径）最终生效值的来源
+type ConfigSources map[string]ValueSource
+
+// Dump 按 TOML 路径顺序输出每个配置项的生效值及其来源
+func (s ConfigSources) Dump(cfg *SystemConfig, w io.Writer) error {
+	var err error
+	walkConfigFields(cfg, func(f configField) {
+		if err != nil {
+			return
+		}
+		src, ok := s[f.Path]
+		if !ok {
+			src = ValueSource{Layer: LayerDefault}
+		}
+		origin := src.Layer.String()
+		if src.Origin != "" {
+			origin += " (" + src.Origin + ")"
+		}
+		_, err = fmt.Fprintf(w, "%s = %v  # %s\n", f.Path, formatFieldValue(f.Value), origin)
+	})
+	return err
+}
+
+// OverrideFlag 命令行覆盖参数，实现 flag.Value，可重复使用：
+//
+//	var overrides crawler.OverrideFlag
+//	flag.Var(&overrides, "set", "覆盖配置项，如 server.listen_address=0.0.0.0:9443")
+//	cfg, err := crawler.LoadConfig(path, crawler.WithOverrides(overrides))
+type OverrideFlag map[string]string
+
+func (f *OverrideFlag) String() string {
+	if f == nil || len(*f) == 0 {
+		return ""
+	}
+	pairs := make([]string, 0, len(*f))
+	for k, v := range *f {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// Set 解析 key=value 形式的覆盖参数
+func (f *OverrideFlag) Set(s string) error {
+	key, value, ok := strings.Cut(s, "=")
+	key = strings.TrimSpace(key)
+	if !ok || key == "" {
+		return fmt.Errorf("覆盖参数格式应为 key=value: %q", s)
+	}
+	if *f == nil {
+		*f = make(OverrideFlag)
+	}
+	(*f)[key] = value
+	return nil
+}
+
+// EnvVarName 返回配置项对应的环境变量名
+func EnvVarName(prefix, path string) string {
+	name := strings.ToUpper(strings.ReplaceAll(path, ".", "_"))
+	if prefix == "" {
+		return name
+	}
+	return strings.ToUpper(prefix) + "_" + name
+}
+
+// applyEnv 用环境变量覆盖配置，prefix为空时不读取环境变量
+func applyEnv(cfg *SystemConfig, prefix string, sources ConfigSources) error {
+	if prefix == "" {
+		return nil
+	}
+	var errs []error
+	walkConfigFields(cfg, func(f configField) {
+		name := EnvVarName(prefix, f.Path)
+		raw, ok := os.LookupEnv(name)
+		if !ok {
+			return
+		}
+		if err := setFieldFromString(f.Value, raw); err != nil {
+			errs = append(errs, fmt.Errorf("环境变量 %s: %w", name, err))
+			return
+		}
+		sources[f.Path] = ValueSource{Layer: LayerEnv, Origin: name}
+	})
+	return errors.Join(errs...)
+}
+
+// applyOverrides 用显式覆盖表覆盖配置，键为TOML路径
+func applyOverrides(cfg *SystemConfig, overrides map[string]string, sources ConfigSources) error {
+	keys := make([]string, 0, len(overrides))
+	for k := range overrides {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var errs []error
+	for _, key := range keys {
+		f, ok := lookupConfigField(cfg, key)
+		if !ok {
+			// 映射类型配置项可以只覆盖其中一个条目，如 logs.levels.domaindns=debug
+			if f, entry, ok := lookupMapEntry(cfg, key); ok {
+				if err := setMapEntryFromString(f.Value, entry, overrides[key]); err != nil {
+					errs = append(errs, fmt.Errorf("覆盖项 %s: %w", key, err))
+					continue
+				}
+				sources[f.Path] = ValueSource{Layer: LayerOverride, Origin: key}
+				continue
+			}
+			errs = append(errs, fmt.Errorf("覆盖项 %s: 未知配置项", key))
+			continue
+		}
+		if err := setFieldFromString(f.Value, overrides[key]); err != nil {
+			errs = append(errs, fmt.Errorf("覆盖项 %s: %w", key, err))
+			continue
+		}
+		sources[key] = ValueSource{Layer: LayerOverride, Origin: key}
+	}
+	return errors.Join(errs...)
+}
+
+var (
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	durationType        = reflect.TypeOf(time.Duration(0))
+)
+
+// setFieldFromString 将字符串形式的值写入配置字段
+//
+// 支持字符串、布尔、整数、time.Duration、实现了 TextUnmarshaler 的类型及其切片和以字符串为键的映射。
+// 切片既可以写成逗号分隔（a,b,c），也可以写成TOML数组（["a", "b"]）；
+// 映射既可以写成逗号分隔的键值对（a=x,b=y），也可以写成TOML内联表（{a = "x", b = "y"}）。
+func setFieldFromString(v reflect.Value, raw string) error {
+	if v.CanAddr() && v.Addr().Type().Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(raw))
+	}
+
+	switch {
+	case v.Type() == durationType:
+		d, err := config.ParseDuration(raw, time.Second)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	case v.Kind() == reflect.Slice:
+		return setSliceFromString(v, raw)
+	case v.Kind() == reflect.Map:
+		return setMapFromString(v, raw)
+	}
+
+	raw = strings.TrimSpace(raw)
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("无法解析为布尔值: %q", raw)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("无法解析为整数: %q", raw)
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("无法解析为非负整数: %q", raw)
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(raw, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("无法解析为浮点数: %q", raw)
+		}
+		v.SetFloat(n)
+	default:
+		return fmt.Errorf("不支持的配置类型: %s", v.Type())
+	}
+	return nil
+}
+
+// setSliceFromString 解析逗号分隔或TOML数组形式的切片
+func setSliceFromString(v reflect.Value, raw string) error {
+	trimmed := strings.TrimSpace(raw)
+	if strings.HasPrefix(trimmed, "[") {
+		holder := reflect.New(reflect.StructOf([]reflect.StructField{{
+			Name: "V",
+			Type: v.Type(),
+			Tag:  `toml:"v"`,
+		}}))
+		if err := toml.Unmarshal([]byte("v = "+trimmed), holder.Interface()); err != nil {
+			return fmt.Errorf("无法解析为数组: %q", raw)
+		}
+		v.Set(holder.Elem().Field(0))
+		return nil
+	}
+
+	slice := reflect.MakeSlice(v.Type(), 0, 0)
+	if trimmed != "" {
+		for _, part := range strings.Split(trimmed, ",") {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if err := setFieldFromString(elem, part); err != nil {
+				return err
+			}
+			slice = reflect.Append(slice, elem)
+		}
+	}
+	v.Set(slice)
+	return nil
+}
+
+// setMapFromString 解析逗号分隔的键值对或TOML内联表形式的映射，整体替换原映射
+func setMapFromString(v reflect.Value, raw string) error {
+	trimmed := strings.TrimSpace(raw)
+	if strings.HasPrefix(trimmed, "{") {
+		holder := reflect.New(reflect.StructOf([]reflect.StructField{{
+			Name: "V",
+			Type: v.Type(),
+			Tag:  `toml:"v"`,
+		}}))
+		if err := toml.Unmarshal([]byte("v = "+trimmed), holder.Interface()); err != nil {
+			return fmt.Errorf("无法解析为内联表: %q", raw)
+		}
+		v.Set(holder.Elem().Field(0))
+		return nil
+	}
+
+	m := reflect.MakeMap(v.Type())
+	if trimmed != "" {
+		for _, part := range strings.Split(trimmed, ",") {
+			key, value, ok := strings.Cut(part, "=")
+			key = strings.TrimSpace(key)
+			if !ok || key == "" {
+				return fmt.Errorf("映射条目格式应为 key=value: %q", part)
+			}
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if err := setFieldFromString(elem, value); err != nil {
+				return err
+			}
+			m.SetMapIndex(reflect.ValueOf(key).Convert(v.Type().Key()), elem)
+		}
+	}
+	v.Set(m)
+	return nil
+}
+
+// setMapEntryFromString 设置映射中的一个条目，复制原映射后再修改，不影响共享该映射的其他配置
+func setMapEntryFromString(v reflect.Value, key, raw string) error {
+	elem := reflect.New(v.Type().Elem()).Elem()
+	if err := setFieldFromString(elem, raw); err != nil {
+		return err
+	}
+	m := reflect.MakeMapWithSize(v.Type(), v.Len()+1)
+	iter := v.MapRange()
+	for iter.Next() {
+		m.SetMapIndex(iter.Key(), iter.Value())
+	}
+	m.SetMapIndex(reflect.ValueOf(key).Convert(v.Type().Key()), elem)
+	v.Set(m)
+	return nil
+}
+
+// formatFieldValue 格式化配置值用于输出
+func formatFieldValue(v reflect.Value) string {
+	if s, ok := v.Interface().(fmt.Stringer); ok {
+		return strconv.Quote(s.String())
+	}
+	if v.Kind() == reflect.String {
+		return strconv.Quote(v.String())
+	}
+	if v.Kind() == reflect.Slice {
+		parts := make([]string, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			parts[i] = formatFieldValue(v.Index(i))
+		}
+		return "[" + strings.Join(parts, ", ") + "]"
+	}
+	if v.Kind() == reflect.Map {
+		parts := make([]string, 0, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			parts = append(parts, fmt.Sprintf("%v = %s", iter.Key().Interface(), formatFieldValue(iter.Value())))
+		}
+		sort.Strings(parts)
+		return "{" + strings.Join(parts, ", ") + "}"
+	}
+	return fmt.Sprintf("%v", v.Interface())
+}
@@ -0,0 +1,178 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package crawler
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// configDocument 配置文件的完整结构
+//
+// 除 SystemConfig 的各配置段外，配置文件还可以包含：
+//   - include = ["base.toml", ...]：先加载的其他配置文件，路径相对于当前文件
+//   - [profile.<name>.<section>]：按名称选择的覆盖片段
+type configDocument struct {
+	Include []string                `toml:"include"`
+	Profile map[string]SystemConfig `toml:"profile"`
+	SystemConfig
+}
+
+// profileOverlay 某个配置文件中定义的 profile 片段
+type profileOverlay struct {
+	file  string
+	data  []byte
+	names map[string]bool
+}
+
+// fileLoader 按 include 顺序加载配置文件并收集 profile 片段
+//
+// 优先级（后者覆盖前者）：被包含的文件（按列出顺序）→ 包含它的文件 →
+// 选中的 profile（按文件加载顺序依次叠加）。
+type fileLoader struct {
+	strict   bool
+	sources  ConfigSources
+	stack    []string // 当前包含链，用于循环检测
+	profiles []profileOverlay
+}
+
+// load 加载配置文件及其包含的文件到 cfg
+func (l *fileLoader) load(cfg *SystemConfig, path string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("解析配置文件路径失败: %w", err)
+	}
+	for i, p := range l.stack {
+		if p == abs {
+			chain := append(append([]string{}, l.stack[i:]...), abs)
+			return fmt.Errorf("配置文件存在循环包含: %s", strings.Join(chain, " -> "))
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("读取配置文件失败: %w", err)
+	}
+
+	// 先按完整结构解析一遍，检查未知配置项（含 profile 内的配置项）并取出 include
+	doc := &configDocument{}
+	if err := decodeTOML(path, data, doc, l.strict); err != nil {
+		var unknown *UnknownKeysError
+		if errors.As(err, &unknown) {
+			if v, verr := configFileVersion(data); verr == nil && v < CurrentConfigVersion {
+				return fmt.Errorf("%w\n配置文件版本为 %d，低于当前版本 %d，可执行 crawler config migrate %s 升级",
+					err, v, CurrentConfigVersion, path)
+			}
+		}
+		return err
+	}
+	if doc.ConfigVersion > CurrentConfigVersion {
+		return fmt.Errorf("配置文件 %s 的版本 %d 高于当前程序支持的版本 %d，请升级程序", path, doc.ConfigVersion, CurrentConfigVersion)
+	}
+
+	// 被包含的文件先加载，当前文件覆盖其值
+	l.stack = append(l.stack, abs)
+	for _, inc := range doc.Include {
+		if !filepath.IsAbs(inc) {
+			inc = filepath.Join(filepath.Dir(path), inc)
+		}
+		if err := l.load(cfg, inc); err != nil {
+			l.stack = l.stack[:len(l.stack)-1]
+			return fmt.Errorf("加载 %s 包含的文件失败: %w", path, err)
+		}
+	}
+	l.stack = l.stack[:len(l.stack)-1]
+
+	if err := decodeTOML(path, data, cfg, false); err != nil {
+		return err
+	}
+	recordDocumentSources(data, path, l.sources)
+
+	if len(doc.Profile) > 0 {
+		names := make(map[string]bool, len(doc.Profile))
+		for name := range doc.Profile {
+			names[name] = true
+		}
+		l.profiles = append(l.profiles, profileOverlay{file: path, data: data, names: names})
+	}
+	return nil
+}
+
+// applyProfile 按加载顺序叠加所有文件中名为 name 的 profile 片段
+func (l *fileLoader) applyProfile(cfg *SystemConfig, name string) error {
+	found := false
+	for _, overlay := range l.profiles {
+		if !overlay.names[name] {
+			continue
+		}
+		found = true
+
+		// 构造 struct{ Profile struct{ Sel *SystemConfig `toml:"<name>"` } `toml:"profile"` }，
+		// 让解码器直接把选中的 profile 写入 cfg，其他配置项忽略
+		selected := reflect.StructOf([]reflect.StructField{{
+			Name: "Sel",
+			Type: reflect.TypeOf(cfg),
+			Tag:  reflect.StructTag(fmt.Sprintf(`toml:%q`, name)),
+		}})
+		holder := reflect.New(reflect.StructOf([]reflect.StructField{{
+			Name: "Profile",
+			Type: selected,
+			Tag:  `toml:"profile"`,
+		}}))
+		holder.Elem().Field(0).Field(0).Set(reflect.ValueOf(cfg))
+		if err := toml.Unmarshal(overlay.data, holder.Interface()); err != nil {
+			return fmt.Errorf("应用 %s 中的 profile %s 失败: %w", overlay.file, name, err)
+		}
+		recordProfileSources(overlay.data, overlay.file, name, l.sources)
+	}
+	if !found {
+		return fmt.Errorf("未找到配置 profile: %s", name)
+	}
+	return nil
+}
+
+// recordDocumentSources 记录配置文件中出现过的配置项（不含 include 和 profile）
+func recordDocumentSources(data []byte, file string, sources ConfigSources) {
+	doc := map[string]interface{}{}
+	if err := toml.Unmarshal(data, &doc); err != nil {
+		return
+	}
+	delete(doc, "include")
+	delete(doc, "profile")
+	recordTableSources(doc, "", ValueSource{Layer: LayerFile, Origin: file}, sources)
+}
+
+// recordProfileSources 记录 profile 片段中出现过的配置项
+func recordProfileSources(data []byte, file, name string, sources ConfigSources) {
+	doc := map[string]interface{}{}
+	if err := toml.Unmarshal(data, &doc); err != nil {
+		return
+	}
+	profiles, _ := doc["profile"].(map[string]interface{})
+	table, _ := profiles[name].(map[string]interface{})
+	src := ValueSource{Layer: LayerProfile, Origin: fmt.Sprintf("%s [profile.%s]", file, name)}
+	recordTableSources(table, "", src, sources)
+}
+
+// recordTableSources 递归记录表中所有叶子配置项的来源，映射类型的配置项整体记录
+func recordTableSources(table map[string]interface{}, prefix string, src ValueSource, sources ConfigSources) {
+	for k, v := range table {
+		path := k
+		if prefix != "" {
+			path = prefix + "." + k
+		}
+		if sub, ok := v.(map[string]interface{}); ok && !isMapConfigField(path) {
+			recordTableSources(sub, path, src, sources)
+			continue
+		}
+		sources[path] = src
+	}
+}
@@ -0,0 +1,428 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package crawler
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ConfigDocs 配置项说明，键为TOML路径（配置段为段名，如 logs；配置项如 logs.level）
+type ConfigDocs map[string]string
+
+// ParseConfigDocs 从配置类型的源码（config.go、internal/config/types.go）中提取配置段和配置项的文档注释
+//
+// 配置段说明取自结构体类型的文档注释（去掉类型名），配置项说明取自字段的行尾注释或文档注释。
+func ParseConfigDocs(filename string, src []byte) (ConfigDocs, error) {
+	file, err := parser.ParseFile(token.NewFileSet(), filename, src, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("解析配置源码失败: %w", err)
+	}
+
+	typeDocs := make(map[string]string)  // 类型名 -> 说明
+	fieldDocs := make(map[string]string) // 类型名.字段名 -> 说明
+	for _, decl := range file.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || gd.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gd.Specs {
+			ts := spec.(*ast.TypeSpec)
+			st, ok := ts.Type.(*ast.StructType)
+			if !ok {
+				continue
+			}
+			doc := ts.Doc
+			if doc == nil && len(gd.Specs) == 1 {
+				doc = gd.Doc
+			}
+			typeDocs[ts.Name.Name] = strings.TrimSpace(strings.TrimPrefix(commentText(doc), ts.Name.Name))
+			for _, field := range st.Fields.List {
+				text := commentText(field.Comment)
+				if text == "" {
+					text = commentText(field.Doc)
+				}
+				for _, name := range field.Names {
+					fieldDocs[ts.Name.Name+"."+name.Name] = text
+				}
+			}
+		}
+	}
+
+	docs := make(ConfigDocs)
+	t := reflect.TypeOf(SystemConfig{})
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if key := tomlKey(sf); key != "" && typeDocs[sf.Type.Name()] != "" {
+			docs[key] = typeDocs[sf.Type.Name()]
+		}
+	}
+	walkConfigFields(DefaultConfig(), func(f configField) {
+		if text := fieldDocs[f.Parent.Name()+"."+f.Field.Name]; text != "" {
+			docs[f.Path] = text
+		}
+	})
+	return docs, nil
+}
+
+// Missing 返回没有说明的配置项路径
+func (d ConfigDocs) Missing() []string {
+	var missing []string
+	walkConfigFields(DefaultConfig(), func(f configField) {
+		if d[f.Path] == "" {
+			missing = append(missing, f.Path)
+		}
+	})
+	return missing
+}
+
+// commentText 将注释合并为一行文本
+func commentText(cg *ast.CommentGroup) string {
+	if cg == nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(cg.Text()), " ")
+}
+
+// jsonSchemaDraft 生成的 JSON Schema 版本
+const jsonSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// durationPattern 时长字符串格式：可选的天数加 Go 时长格式，如 30d、1d12h、1500ms
+const durationPattern = `^(\d+d)?(\d+(\.\d+)?(ns|us|µs|ms|s|m|h))*$`
+
+// GenerateJSONSchema 根据 SystemConfig 生成配置文件的 JSON Schema，用于编辑器补全和校验
+//
+// 默认值取自 DefaultConfig，可选值取自配置校验使用的枚举表，说明取自 docs。
+func GenerateJSONSchema(docs ConfigDocs) ([]byte, error) {
+	cfg := reflect.ValueOf(DefaultConfig()).Elem()
+	t := cfg.Type()
+
+	defs := schemaObject{}
+	sections := schemaObject{}
+	topLevel := schemaObject{}
+	for i := 0; i < t.NumField(); i++ {
+		key := tomlKey(t.Field(i))
+		if key == "" {
+			continue
+		}
+		if !isConfigSection(t.Field(i).Type) {
+			f := configField{Path: key, Field: t.Field(i), Value: cfg.Field(i), Parent: t}
+			fs := fieldSchema(f, docs[key])
+			if key == "config_version" {
+				fs = fs.set("minimum", 0).set("maximum", CurrentConfigVersion)
+			}
+			topLevel = topLevel.set(key, fs)
+			continue
+		}
+		defs = defs.set(key, sectionSchema(cfg.Field(i), key, docs))
+		sections = sections.set(key, schemaObject{}.set("$ref", "#/$defs/"+key))
+	}
+	defs = defs.set("profile", schemaObject{}.
+		set("description", "profile 覆盖片段，只需写出要覆盖的配置项").
+		set("type", "object").
+		set("additionalProperties", false).
+		set("properties", sections))
+
+	properties := schemaObject{}.
+		set("include", schemaObject{}.
+			set("description", "先加载的其他配置文件，路径相对于当前文件，当前文件的值覆盖被包含文件的值").
+			set("type", "array").
+			set("items", schemaObject{}.set("type", "string")))
+	for _, kv := range append(topLevel, sections...) {
+		properties = properties.set(kv.key, kv.value)
+	}
+	properties = properties.set("profile", schemaObject{}.
+		set("description", "按名称选择的覆盖片段，通过 CRAWLER_PROFILE 或 WithProfile 启用").
+		set("type", "object").
+		set("additionalProperties", schemaObject{}.set("$ref", "#/$defs/profile")))
+
+	schema := schemaObject{}.
+		set("$schema", jsonSchemaDraft).
+		set("title", "crawler-system 配置").
+		set("description", "由 cmd/configgen 根据 SystemConfig 生成，请勿手动修改").
+		set("type", "object").
+		set("additionalProperties", false).
+		set("properties", properties).
+		set("$defs", defs)
+
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(schema); err != nil {
+		return nil, fmt.Errorf("生成 JSON Schema 失败: %w", err)
+	}
+	return b.Bytes(), nil
+}
+
+// sectionSchema 生成配置段的 Schema
+func sectionSchema(v reflect.Value, section string, docs ConfigDocs) schemaObject {
+	properties := schemaObject{}
+	walkStruct(v, section, func(f configField) {
+		properties = properties.set(tomlKey(f.Field), fieldSchema(f, docs[f.Path]))
+	})
+	s := schemaObject{}
+	if doc := docs[section]; doc != "" {
+		s = s.set("description", doc)
+	}
+	return s.set("type", "object").
+		set("additionalProperties", false).
+		set("properties", properties)
+}
+
+// fieldSchema 生成配置项的 Schema
+func fieldSchema(f configField, doc string) schemaObject {
+	s := schemaObject{}
+	if unit, ok := durationUnits[f.Field.Type]; ok {
+		if doc != "" {
+			doc += "；"
+		}
+		doc += fmt.Sprintf("整数按%s解析，也可写时长字符串", unit)
+	}
+	if doc != "" {
+		s = s.set("description", doc)
+	}
+
+	enum := configEnums[f.Path]
+	switch {
+	case durationUnits[f.Field.Type] != "":
+		s = s.set("type", []string{"integer", "string"}).
+			set("minimum", 0).
+			set("pattern", durationPattern)
+	case f.Value.Kind() == reflect.Slice:
+		items := schemaObject{}.set("type", jsonType(f.Field.Type.Elem().Kind()))
+		if enum != nil {
+			items = items.set("enum", enum)
+		}
+		s = s.set("type", "array").set("items", items)
+	case f.Value.Kind() == reflect.Map:
+		values := schemaObject{}.set("type", jsonType(f.Field.Type.Elem().Kind()))
+		if enum != nil {
+			values = values.set("enum", enum)
+		}
+		s = s.set("type", "object").set("additionalProperties", values)
+	default:
+		s = s.set("type", jsonType(f.Value.Kind()))
+		if enum != nil {
+			s = s.set("enum", enum)
+		}
+	}
+	return s.set("default", defaultValue(f.Value))
+}
+
+// durationUnits 时长类型的整数单位
+var durationUnits = map[reflect.Type]string{
+	reflect.TypeOf(Duration(0)):     "秒",
+	reflect.TypeOf(HourDuration(0)): "小时",
+	reflect.TypeOf(DayDuration(0)):  "天",
+}
+
+// jsonType 返回 Go 类型对应的 JSON Schema 类型
+func jsonType(kind reflect.Kind) string {
+	switch kind {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	default:
+		return "string"
+	}
+}
+
+// defaultValue 返回配置项默认值的可序列化形式，时长类型输出时长字符串
+func defaultValue(v reflect.Value) interface{} {
+	if m, ok := v.Interface().(encoding.TextMarshaler); ok {
+		text, _ := m.MarshalText()
+		return string(text)
+	}
+	if v.Kind() == reflect.Slice && v.IsNil() {
+		return reflect.MakeSlice(v.Type(), 0, 0).Interface()
+	}
+	if v.Kind() == reflect.Map && v.IsNil() {
+		return reflect.MakeMap(v.Type()).Interface()
+	}
+	return v.Interface()
+}
+
+// GenerateReferenceConfig 根据 SystemConfig 生成带完整注释的参考配置（TOML）
+//
+// 所有配置项均为 DefaultConfig 中的默认值，说明取自 docs。
+func GenerateReferenceConfig(docs ConfigDocs) ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteString("# crawler-system 参考配置\n")
+	b.WriteString("#\n")
+	b.WriteString("# 由 cmd/configgen 根据 SystemConfig 的配置类型和 DefaultConfig 生成，请勿手动修改。\n")
+	b.WriteString("# 所有配置项均为默认值；时长可写整数（按各项注明的单位解析）或时长字符串，如 \"30s\"、\"5m\"、\"1h30m\"、\"30d\"。\n")
+	b.WriteString("# 可用 include = [\"base.toml\"] 引入其他配置文件，用 [profile.<name>.<section>] 定义覆盖片段，详见 CONFIG.md。\n")
+
+	cfg := reflect.ValueOf(DefaultConfig()).Elem()
+	t := cfg.Type()
+	var errs []string
+	writeField := func(f configField) {
+		if doc := docs[f.Path]; doc != "" {
+			fmt.Fprintf(&b, "# %s\n", doc)
+		}
+		value, err := tomlValue(defaultValue(f.Value))
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", f.Path, err))
+			return
+		}
+		fmt.Fprintf(&b, "%s = %s\n\n", tomlKey(f.Field), value)
+	}
+
+	// 顶层配置项必须写在所有表之前
+	b.WriteString("\n")
+	for i := 0; i < t.NumField(); i++ {
+		if key := tomlKey(t.Field(i)); key != "" && !isConfigSection(t.Field(i).Type) {
+			writeField(configField{Path: key, Field: t.Field(i), Value: cfg.Field(i), Parent: t})
+		}
+	}
+	b.Truncate(b.Len() - 1)
+
+	for i := 0; i < t.NumField(); i++ {
+		section := tomlKey(t.Field(i))
+		if section == "" || !isConfigSection(t.Field(i).Type) {
+			continue
+		}
+		b.WriteString("\n# ============================================\n")
+		if doc := docs[section]; doc != "" {
+			fmt.Fprintf(&b, "# %s\n", doc)
+		}
+		b.WriteString("# ============================================\n")
+		fmt.Fprintf(&b, "[%s]\n", section)
+
+		walkStruct(cfg.Field(i), section, writeField)
+		b.Truncate(b.Len() - 1)
+	}
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return nil, fmt.Errorf("生成参考配置失败: %s", strings.Join(errs, "; "))
+	}
+	return b.Bytes(), nil
+}
+
+// tomlValue 返回单个值的TOML文本，字符串使用双引号
+func tomlValue(v interface{}) (string, error) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.String:
+		var b bytes.Buffer
+		if err := marshalJSONTo(&b, rv.String()); err != nil {
+			return "", err
+		}
+		return b.String(), nil
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return fmt.Sprint(v), nil
+	case reflect.Slice:
+		items := make([]string, rv.Len())
+		for i := range items {
+			item, err := tomlValue(rv.Index(i).Interface())
+			if err != nil {
+				return "", err
+			}
+			items[i] = item
+		}
+		return "[" + strings.Join(items, ", ") + "]", nil
+	case reflect.Map:
+		items := make([]string, 0, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			key, err := tomlKeyText(fmt.Sprint(iter.Key().Interface()))
+			if err != nil {
+				return "", err
+			}
+			value, err := tomlValue(iter.Value().Interface())
+			if err != nil {
+				return "", err
+			}
+			items = append(items, key+" = "+value)
+		}
+		sort.Strings(items)
+		if len(items) == 0 {
+			return "{}", nil
+		}
+		return "{ " + strings.Join(items, ", ") + " }", nil
+	default:
+		return "", fmt.Errorf("不支持的类型: %s", rv.Type())
+	}
+}
+
+// tomlKeyText 返回TOML键的文本，不是裸键时加双引号
+func tomlKeyText(key string) (string, error) {
+	bare := key != ""
+	for _, r := range key {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-') {
+			bare = false
+			break
+		}
+	}
+	if bare {
+		return key, nil
+	}
+	return tomlValue(key)
+}
+
+// schemaObject 保持键顺序的 JSON 对象
+type schemaObject []schemaMember
+
+type schemaMember struct {
+	key   string
+	value interface{}
+}
+
+// set 设置键值并返回对象，键已存在时覆盖
+func (o schemaObject) set(key string, value interface{}) schemaObject {
+	for i := range o {
+		if o[i].key == key {
+			o[i].value = value
+			return o
+		}
+	}
+	return append(o, schemaMember{key: key, value: value})
+}
+
+// MarshalJSON 实现 json.Marshaler，按设置顺序输出键
+func (o schemaObject) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, m := range o {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		if err := marshalJSONTo(&b, m.key); err != nil {
+			return nil, err
+		}
+		b.WriteByte(':')
+		if err := marshalJSONTo(&b, m.value); err != nil {
+			return nil, err
+		}
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// marshalJSONTo 将 v 编码为不转义HTML字符的紧凑JSON写入 b
+func marshalJSONTo(b *bytes.Buffer, v interface{}) error {
+	enc := json.NewEncoder(b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	b.Truncate(b.Len() - 1) // 去掉 Encode 追加的换行
+	return nil
+}
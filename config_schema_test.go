@@ -0,0 +1,64 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package crawler
+
+import (
+	"bytes"
+	"maps"
+	"os"
+	"testing"
+)
+
+// loadConfigDocs 与 cmd/configgen 相同，从配置类型的源文件提取字段说明
+func loadConfigDocs(t *testing.T) ConfigDocs {
+	t.Helper()
+	docs := make(ConfigDocs)
+	for _, file := range []string{"config.go", "internal/config/types.go"} {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fileDocs, err := ParseConfigDocs(file, data)
+		if err != nil {
+			t.Fatalf("ParseConfigDocs(%s): %v", file, err)
+		}
+		maps.Copy(docs, fileDocs)
+	}
+	return docs
+}
+
+func TestConfigDocsComplete(t *testing.T) {
+	if missing := loadConfigDocs(t).Missing(); len(missing) > 0 {
+		t.Errorf("配置项缺少说明注释: %v", missing)
+	}
+}
+
+func TestGeneratedConfigFilesUpToDate(t *testing.T) {
+	docs := loadConfigDocs(t)
+	schema, err := GenerateJSONSchema(docs)
+	if err != nil {
+		t.Fatalf("GenerateJSONSchema: %v", err)
+	}
+	reference, err := GenerateReferenceConfig(docs)
+	if err != nil {
+		t.Fatalf("GenerateReferenceConfig: %v", err)
+	}
+
+	for _, out := range []struct {
+		path string
+		data []byte
+	}{
+		{"config.schema.json", schema},
+		{"config.reference.toml", reference},
+	} {
+		current, err := os.ReadFile(out.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(current, out.data) {
+			t.Errorf("%s 已过期，请执行 go generate 重新生成", out.path)
+		}
+	}
+}
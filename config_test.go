@@ -0,0 +1,524 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package crawler
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadConfigMissingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing.toml")
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("未要求配置文件存在时应使用默认配置: %v", err)
+	}
+	if cfg.Logs.Level != DefaultConfig().Logs.Level {
+		t.Fatalf("logs.level = %q，want 默认值", cfg.Logs.Level)
+	}
+
+	if _, err := LoadConfig(path, WithRequireFile(true)); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("WithRequireFile(true) 时 LoadConfig 返回 %v，want 文件不存在的错误", err)
+	}
+}
+
+func TestLoadConfigAggregatesValidationErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		wantPaths []string
+	}{
+		{
+			name:    "合法配置",
+			content: "",
+		},
+		{
+			name: "同一段内多处错误",
+			content: `
+[conn]
+connect_timeout = "0s"
+read_timeout = "-1s"
+max_idle_conns = -1
+`,
+			wantPaths: []string{"conn.connect_timeout", "conn.read_timeout", "conn.max_idle_conns"},
+		},
+		{
+			name: "多个段的错误按段顺序汇总",
+			content: `
+[logs]
+level = "verbose"
+
+[logs.levels]
+domaindns = "trace"
+
+[netconnpool]
+max_connections = 2
+initial_connections = 3
+
+[system]
+name = " "
+`,
+			wantPaths: []string{"logs.level", "logs.levels.domaindns", "netconnpool.initial_connections", "system.name"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.toml")
+			writeTestConfig(t, path, tt.content)
+
+			_, err := LoadConfig(path)
+			if len(tt.wantPaths) == 0 {
+				if err != nil {
+					t.Fatalf("LoadConfig: %v", err)
+				}
+				return
+			}
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("LoadConfig error = %v, want *ValidationError", err)
+			}
+			var paths []string
+			for _, fe := range verr.Errors {
+				paths = append(paths, fe.Path)
+				if !strings.Contains(err.Error(), fe.Error()) {
+					t.Errorf("错误信息 %q 中缺少 %q", err, fe)
+				}
+			}
+			if !slices.Equal(paths, tt.wantPaths) {
+				t.Fatalf("字段错误 %v，want %v", paths, tt.wantPaths)
+			}
+		})
+	}
+}
+
+func TestLoadConfigUnknownKeys(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		strict   bool
+		wantKeys []PositionError
+		wantHint bool
+	}{
+		{
+			name: "段内拼写错误的配置项",
+			content: `config_version = 2
+
+[netconnpool]
+max_conection = 10
+`,
+			strict:   true,
+			wantKeys: []PositionError{{Line: 4, Column: 1, Key: "netconnpool.max_conection"}},
+		},
+		{
+			name: "未知的段和 profile 中的未知配置项",
+			content: `config_version = 2
+
+[unknown]
+  a = 1
+
+[profile.dev.logs]
+lvl = "debug"
+`,
+			strict: true,
+			wantKeys: []PositionError{
+				{Line: 3, Column: 2, Key: "unknown"},
+				{Line: 7, Column: 1, Key: "profile.dev.logs.lvl"},
+			},
+		},
+		{
+			name: "旧版本配置文件提示迁移",
+			content: `[system]
+work_dir = "/tmp"
+`,
+			strict:   true,
+			wantKeys: []PositionError{{Line: 2, Column: 1, Key: "system.work_dir"}},
+			wantHint: true,
+		},
+		{
+			name: "非严格模式忽略未知配置项",
+			content: `[netconnpool]
+max_conection = 10
+`,
+			strict: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.toml")
+			writeTestConfig(t, path, tt.content)
+
+			_, err := LoadConfig(path, WithStrict(tt.strict))
+			if len(tt.wantKeys) == 0 {
+				if err != nil {
+					t.Fatalf("LoadConfig: %v", err)
+				}
+				return
+			}
+			var unknown *UnknownKeysError
+			if !errors.As(err, &unknown) {
+				t.Fatalf("LoadConfig error = %v, want *UnknownKeysError", err)
+			}
+			if len(unknown.Keys) != len(tt.wantKeys) {
+				t.Fatalf("未知配置项 %v，want %d 个", unknown.Keys, len(tt.wantKeys))
+			}
+			for i, want := range tt.wantKeys {
+				got := unknown.Keys[i]
+				if got.File != path || got.Line != want.Line || got.Column != want.Column || got.Key != want.Key {
+					t.Errorf("第 %d 个未知配置项 %s，want %s:%d:%d %s", i, got, path, want.Line, want.Column, want.Key)
+				}
+			}
+			if hint := strings.Contains(err.Error(), "crawler config migrate"); hint != tt.wantHint {
+				t.Errorf("错误信息 %q 中迁移提示为 %v，want %v", err, hint, tt.wantHint)
+			}
+		})
+	}
+}
+
+func TestSetFieldFromString(t *testing.T) {
+	tests := []struct {
+		name    string
+		field   interface{} // 指向字段的指针
+		raw     string
+		want    interface{}
+		wantErr string
+	}{
+		{"字符串去除首尾空白", new(string), " 0.0.0.0:8080 ", "0.0.0.0:8080", ""},
+		{"布尔", new(bool), "true", true, ""},
+		{"非法布尔", new(bool), "yes", false, "无法解析为布尔值"},
+		{"整数", new(int), "-3", -3, ""},
+		{"非法整数", new(int), "3.5", 0, "无法解析为整数"},
+		{"非负整数", new(uint16), "443", uint16(443), ""},
+		{"负数写入非负整数", new(uint16), "-1", uint16(0), "无法解析为非负整数"},
+		{"浮点数", new(float64), "0.5", 0.5, ""},
+		{"Duration 时长字符串", new(Duration), "1m30s", Duration(90 * time.Second), ""},
+		{"Duration 整数按秒", new(Duration), "2", Duration(2 * time.Second), ""},
+		{"Duration 非法单位", new(Duration), "5x", Duration(0), "无法解析为时长"},
+		{"time.Duration 整数按秒", new(time.Duration), "2", 2 * time.Second, ""},
+		{"逗号分隔的切片", new([]string), "chrome, firefox", []string{"chrome", "firefox"}, ""},
+		{"TOML数组", new([]string), `["chrome", "safari"]`, []string{"chrome", "safari"}, ""},
+		{"空字符串清空切片", new([]string), "", []string{}, ""},
+		{"时长切片", new([]Duration), "1s,2", []Duration{Duration(time.Second), Duration(2 * time.Second)}, ""},
+		{"非法TOML数组", new([]string), `["chrome"`, []string(nil), "无法解析为数组"},
+		{"逗号分隔的键值对", new(map[string]string), "domaindns=debug, fetch=warn",
+			map[string]string{"domaindns": "debug", "fetch": "warn"}, ""},
+		{"TOML内联表", new(map[string]string), `{domaindns = "debug"}`, map[string]string{"domaindns": "debug"}, ""},
+		{"缺少等号的键值对", new(map[string]string), "domaindns", map[string]string(nil), "映射条目格式应为 key=value"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := reflect.ValueOf(tt.field).Elem()
+			err := setFieldFromString(v, tt.raw)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("setFieldFromString(%q) error = %v, want %q", tt.raw, err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("setFieldFromString(%q): %v", tt.raw, err)
+			}
+			if got := v.Interface(); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("setFieldFromString(%q) = %#v, want %#v", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfigEnvAndOverrides(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		prefix    string
+		env       map[string]string
+		overrides map[string]string
+		check     func(t *testing.T, cfg *SystemConfig, sources ConfigSources)
+		wantErr   string
+	}{
+		{
+			name:    "环境变量覆盖配置文件",
+			content: "[conn]\nmax_idle_conns = 3\nconnect_timeout = \"20s\"\n",
+			prefix:  "TEST",
+			env:     map[string]string{"TEST_CONN_MAX_IDLE_CONNS": "5"},
+			check: func(t *testing.T, cfg *SystemConfig, sources ConfigSources) {
+				if cfg.Conn.MaxIdleConns != 5 {
+					t.Errorf("conn.max_idle_conns = %d，want 5", cfg.Conn.MaxIdleConns)
+				}
+				if cfg.Conn.ConnectTimeout.Duration() != 20*time.Second {
+					t.Errorf("conn.connect_timeout = %s，want 20s", cfg.Conn.ConnectTimeout)
+				}
+				wantSource(t, sources, "conn.max_idle_conns", LayerEnv, "TEST_CONN_MAX_IDLE_CONNS")
+				wantSource(t, sources, "conn.connect_timeout", LayerFile, "")
+			},
+		},
+		{
+			name:      "覆盖项优先于环境变量",
+			prefix:    "TEST",
+			env:       map[string]string{"TEST_LOGS_LEVEL": "warn"},
+			overrides: map[string]string{"logs.level": "debug"},
+			check: func(t *testing.T, cfg *SystemConfig, sources ConfigSources) {
+				if cfg.Logs.Level != "debug" {
+					t.Errorf("logs.level = %q，want debug", cfg.Logs.Level)
+				}
+				wantSource(t, sources, "logs.level", LayerOverride, "logs.level")
+			},
+		},
+		{
+			name:      "覆盖映射中的单个条目",
+			content:   "[logs.levels]\nfetch = \"warn\"\n",
+			overrides: map[string]string{"logs.levels.domaindns": "debug"},
+			check: func(t *testing.T, cfg *SystemConfig, sources ConfigSources) {
+				want := map[string]string{"fetch": "warn", "domaindns": "debug"}
+				if !reflect.DeepEqual(cfg.Logs.Levels, want) {
+					t.Errorf("logs.levels = %v，want %v", cfg.Logs.Levels, want)
+				}
+				wantSource(t, sources, "logs.levels", LayerOverride, "logs.levels.domaindns")
+			},
+		},
+		{
+			name:   "前缀为空时不读取环境变量",
+			prefix: "",
+			env:    map[string]string{"LOGS_LEVEL": "warn", "CRAWLER_LOGS_LEVEL": "warn"},
+			check: func(t *testing.T, cfg *SystemConfig, sources ConfigSources) {
+				if cfg.Logs.Level != DefaultConfig().Logs.Level {
+					t.Errorf("logs.level = %q，want 默认值", cfg.Logs.Level)
+				}
+				if _, ok := sources["logs.level"]; ok {
+					t.Errorf("logs.level 的来源 %v，want 未记录", sources["logs.level"])
+				}
+			},
+		},
+		{
+			name:    "非法环境变量值",
+			prefix:  "TEST",
+			env:     map[string]string{"TEST_CONN_MAX_IDLE_CONNS": "many"},
+			wantErr: "环境变量 TEST_CONN_MAX_IDLE_CONNS: 无法解析为整数",
+		},
+		{
+			name:      "未知覆盖项",
+			overrides: map[string]string{"conn.max_idle_con": "1"},
+			wantErr:   "覆盖项 conn.max_idle_con: 未知配置项",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			path := filepath.Join(t.TempDir(), "config.toml")
+			writeTestConfig(t, path, tt.content)
+
+			sources := ConfigSources{}
+			cfg, err := LoadConfig(path, WithEnvPrefix(tt.prefix), WithOverrides(tt.overrides), WithSources(sources))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("LoadConfig error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig: %v", err)
+			}
+			tt.check(t, cfg, sources)
+		})
+	}
+}
+
+// wantSource 检查配置项的来源，origin 为空时只检查来源层
+func wantSource(t *testing.T, sources ConfigSources, path string, layer ConfigLayer, origin string) {
+	t.Helper()
+	got, ok := sources[path]
+	if !ok || got.Layer != layer || (origin != "" && got.Origin != origin) {
+		t.Errorf("%s 的来源 %+v，want %s %s", path, got, layer, origin)
+	}
+}
+
+func TestLoadConfigIncludeAndProfile(t *testing.T) {
+	files := map[string]string{
+		"base/base.toml": `
+[logs]
+level = "warn"
+
+[conn]
+max_idle_conns = 3
+
+[profile.dev.conn]
+max_idle_conns = 7
+`,
+		"main.toml": `
+include = ["base/base.toml"]
+
+[conn]
+max_idle_conns = 4
+
+[profile.dev.logs]
+level = "debug"
+`,
+		"cycle_a.toml": `include = ["cycle_b.toml"]`,
+		"cycle_b.toml": `include = ["cycle_a.toml"]`,
+	}
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		writeTestConfig(t, path, content)
+	}
+	basePath, mainPath := filepath.Join(dir, "base/base.toml"), filepath.Join(dir, "main.toml")
+
+	tests := []struct {
+		name         string
+		file         string
+		opts         []LoadOption
+		env          map[string]string
+		wantLevel    string
+		wantIdle     int
+		wantSources  map[string]ValueSource
+		wantErr      string
+		wantErrChain []string
+	}{
+		{
+			name:      "包含的文件先加载，当前文件覆盖其值",
+			file:      "main.toml",
+			wantLevel: "warn",
+			wantIdle:  4,
+			wantSources: map[string]ValueSource{
+				"logs.level":          {LayerFile, basePath},
+				"conn.max_idle_conns": {LayerFile, mainPath},
+			},
+		},
+		{
+			name:      "按文件加载顺序叠加 profile",
+			file:      "main.toml",
+			opts:      []LoadOption{WithProfile("dev")},
+			wantLevel: "debug",
+			wantIdle:  7,
+			wantSources: map[string]ValueSource{
+				"logs.level":          {LayerProfile, mainPath + " [profile.dev]"},
+				"conn.max_idle_conns": {LayerProfile, basePath + " [profile.dev]"},
+			},
+		},
+		{
+			name:      "从环境变量选择 profile",
+			file:      "main.toml",
+			opts:      []LoadOption{WithEnvPrefix("TEST")},
+			env:       map[string]string{"TEST_PROFILE": "dev"},
+			wantLevel: "debug",
+			wantIdle:  7,
+		},
+		{
+			name:    "未定义的 profile",
+			file:    "main.toml",
+			opts:    []LoadOption{WithProfile("prod")},
+			wantErr: "未找到配置 profile: prod",
+		},
+		{
+			name:         "循环包含",
+			file:         "cycle_a.toml",
+			wantErr:      "配置文件存在循环包含",
+			wantErrChain: []string{"cycle_a.toml", "cycle_b.toml", "cycle_a.toml"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			sources := ConfigSources{}
+			cfg, err := LoadConfig(filepath.Join(dir, tt.file), append(tt.opts, WithSources(sources))...)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("LoadConfig error = %v, want %q", err, tt.wantErr)
+				}
+				if tt.wantErrChain != nil {
+					var chain []string
+					for _, name := range tt.wantErrChain {
+						chain = append(chain, filepath.Join(dir, name))
+					}
+					if want := strings.Join(chain, " -> "); !strings.Contains(err.Error(), want) {
+						t.Fatalf("LoadConfig error = %v, want 包含 %q", err, want)
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig: %v", err)
+			}
+			if cfg.Logs.Level != tt.wantLevel || cfg.Conn.MaxIdleConns != tt.wantIdle {
+				t.Errorf("logs.level = %q, conn.max_idle_conns = %d，want %q, %d",
+					cfg.Logs.Level, cfg.Conn.MaxIdleConns, tt.wantLevel, tt.wantIdle)
+			}
+			for path, want := range tt.wantSources {
+				wantSource(t, sources, path, want.Layer, want.Origin)
+			}
+		})
+	}
+}
+
+func TestLoadConfigIntegerDurations(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		got     func(cfg *SystemConfig) time.Duration
+		want    time.Duration
+		wantErr string
+	}{
+		{
+			name:    "Duration 整数按秒",
+			content: "[conn]\nconnect_timeout = 30\n",
+			got:     func(cfg *SystemConfig) time.Duration { return cfg.Conn.ConnectTimeout.Duration() },
+			want:    30 * time.Second,
+		},
+		{
+			name:    "Duration 时长字符串",
+			content: "[conn]\nconnect_timeout = \"1m30s\"\n",
+			got:     func(cfg *SystemConfig) time.Duration { return cfg.Conn.ConnectTimeout.Duration() },
+			want:    90 * time.Second,
+		},
+		{
+			name:    "HourDuration 整数按小时",
+			content: "[certificate]\nrenewal_check_interval = 12\n",
+			got:     func(cfg *SystemConfig) time.Duration { return cfg.Certificate.RenewalCheckInterval.Duration() },
+			want:    12 * time.Hour,
+		},
+		{
+			name:    "DayDuration 整数按天",
+			content: "[certificate]\nrenewal_before_days = 30\n",
+			got:     func(cfg *SystemConfig) time.Duration { return cfg.Certificate.RenewalBeforeDays.Duration() },
+			want:    30 * 24 * time.Hour,
+		},
+		{
+			name:    "非法单位",
+			content: "[conn]\nconnect_timeout = \"30x\"\n",
+			wantErr: "无法解析为时长",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.toml")
+			writeTestConfig(t, path, tt.content)
+
+			cfg, err := LoadConfig(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("LoadConfig error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfig: %v", err)
+			}
+			if got := tt.got(cfg); got != tt.want {
+				t.Fatalf("时长 = %v，want %v", got, tt.want)
+			}
+		})
+	}
+}
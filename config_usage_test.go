@@ -0,0 +1,229 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package crawler
+
+import (
+	"bytes"
+	"encoding/json"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// unimplementedMarkers 表示配置项尚未实现的注释用语，配置项必须实现后再加入配置结构
+var unimplementedMarkers = []string{"预留", "尚未实现", "暂未实现"}
+
+// consumerPackages 读取配置并驱动各模块的包
+var consumerPackages = []string{
+	"github.com/vistone/crawler-system",
+	"github.com/vistone/crawler-system/internal/moduleinit",
+}
+
+// isConfigDescriptionFile 判断文件是否只描述配置本身（加载、校验、迁移、生成文档），这些文件读取配置项不算使用
+func isConfigDescriptionFile(path string) bool {
+	name := filepath.Base(path)
+	return strings.HasPrefix(name, "config") || name == "migrate.go"
+}
+
+// TestConfigFieldsReachModules 检查每个配置项都有模块读取
+//
+// 按类型信息收集对各配置段字段的访问，写入启动报告（StartupReport 的方法调用）的参数、
+// 以及只决定是否写入启动报告的 if 条件不算使用。
+// 每个配置项都必须有模块读取，注释中也不能声明配置项尚未实现。
+func TestConfigFieldsReachModules(t *testing.T) {
+	configPkg := reflect.TypeOf(SystemConfig{}.Logs).PkgPath()
+	used := make(map[string]map[string]bool) // 配置段类型名 -> 被读取的字段名
+	for _, pkg := range loadConsumerPackages(t) {
+		for _, file := range pkg.files {
+			if isConfigDescriptionFile(pkg.fset.File(file.Pos()).Name()) {
+				continue
+			}
+			collectConfigUsage(file, pkg.info, configPkg, used)
+		}
+	}
+
+	docs := loadConfigDocs(t)
+	var missing, unimplemented, unreceived []string
+	sys := reflect.TypeOf(SystemConfig{})
+	for i := 0; i < sys.NumField(); i++ {
+		section := sys.Field(i)
+		if !isConfigSection(section.Type) {
+			continue
+		}
+		key := tomlKey(section)
+		fields := used[section.Type.Name()]
+		if len(fields) == 0 {
+			unreceived = append(unreceived, key)
+		}
+		for j := 0; j < section.Type.NumField(); j++ {
+			f := section.Type.Field(j)
+			path := key + "." + tomlKey(f)
+			if !fields[f.Name] {
+				missing = append(missing, path)
+			}
+			for _, marker := range unimplementedMarkers {
+				if strings.Contains(docs[path], marker) {
+					unimplemented = append(unimplemented, path)
+					break
+				}
+			}
+		}
+	}
+
+	for _, list := range [][]string{missing, unimplemented, unreceived} {
+		sort.Strings(list)
+	}
+	if len(unreceived) > 0 {
+		t.Errorf("以下配置段没有模块接收: %s", strings.Join(unreceived, ", "))
+	}
+	if len(missing) > 0 {
+		t.Errorf("以下配置项没有模块读取（未实现的配置项不应加入配置结构）: %s", strings.Join(missing, ", "))
+	}
+	if len(unimplemented) > 0 {
+		t.Errorf("以下配置项的注释声明尚未实现: %s", strings.Join(unimplemented, ", "))
+	}
+}
+
+// checkedPackage 完成类型检查的包
+type checkedPackage struct {
+	fset  *token.FileSet
+	files []*ast.File
+	info  *types.Info
+}
+
+// loadConsumerPackages 解析并类型检查 consumerPackages，依赖包的类型取自 go list -export 生成的导出数据
+func loadConsumerPackages(t *testing.T) []checkedPackage {
+	t.Helper()
+	args := append([]string{"list", "-export", "-deps", "-json=ImportPath,Dir,GoFiles,Export"}, consumerPackages...)
+	out, err := exec.Command("go", args...).Output()
+	if err != nil {
+		t.Fatalf("go list 失败: %v", err)
+	}
+	type listedPackage struct {
+		ImportPath string
+		Dir        string
+		GoFiles    []string
+		Export     string
+	}
+	listed := make(map[string]listedPackage)
+	for dec := json.NewDecoder(bytes.NewReader(out)); ; {
+		var p listedPackage
+		if err := dec.Decode(&p); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("解析 go list 输出失败: %v", err)
+		}
+		listed[p.ImportPath] = p
+	}
+
+	fset := token.NewFileSet()
+	imp := importer.ForCompiler(fset, "gc", func(path string) (io.ReadCloser, error) {
+		return os.Open(listed[path].Export)
+	})
+	var pkgs []checkedPackage
+	for _, path := range consumerPackages {
+		p := listed[path]
+		var files []*ast.File
+		for _, name := range p.GoFiles {
+			file, err := parser.ParseFile(fset, filepath.Join(p.Dir, name), nil, 0)
+			if err != nil {
+				t.Fatalf("解析源码失败: %v", err)
+			}
+			files = append(files, file)
+		}
+		info := &types.Info{Selections: make(map[*ast.SelectorExpr]*types.Selection)}
+		conf := types.Config{Importer: imp}
+		if _, err := conf.Check(path, fset, files, info); err != nil {
+			t.Fatalf("类型检查 %s 失败: %v", path, err)
+		}
+		pkgs = append(pkgs, checkedPackage{fset: fset, files: files, info: info})
+	}
+	return pkgs
+}
+
+// collectConfigUsage 收集文件中对 configPkg 中配置段类型字段的读取，跳过启动报告方法调用的参数
+func collectConfigUsage(file *ast.File, info *types.Info, configPkg string, used map[string]map[string]bool) {
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.CallExpr:
+			if isReportCall(n, info) {
+				return false
+			}
+		case *ast.IfStmt:
+			if isReportOnly(n, info) {
+				return false
+			}
+		case *ast.SelectorExpr:
+			sel, ok := info.Selections[n]
+			if !ok || sel.Kind() != types.FieldVal {
+				return true
+			}
+			recv := sel.Recv()
+			if p, ok := recv.(*types.Pointer); ok {
+				recv = p.Elem()
+			}
+			named, ok := types.Unalias(recv).(*types.Named)
+			if !ok || named.Obj().Pkg() == nil || named.Obj().Pkg().Path() != configPkg {
+				return true
+			}
+			name := named.Obj().Name()
+			if used[name] == nil {
+				used[name] = make(map[string]bool)
+			}
+			used[name][n.Sel.Name] = true
+		}
+		return true
+	})
+}
+
+// isReportOnly 判断 if 语句的各分支是否只写入启动报告，这类语句的条件也不算使用
+func isReportOnly(stmt ast.Stmt, info *types.Info) bool {
+	switch stmt := stmt.(type) {
+	case *ast.ExprStmt:
+		call, ok := stmt.X.(*ast.CallExpr)
+		return ok && isReportCall(call, info)
+	case *ast.IfStmt:
+		if stmt.Init != nil || !isReportOnly(stmt.Body, info) {
+			return false
+		}
+		return stmt.Else == nil || isReportOnly(stmt.Else, info)
+	case *ast.BlockStmt:
+		for _, s := range stmt.List {
+			if !isReportOnly(s, info) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// isReportCall 判断调用是否为 StartupReport 的方法
+func isReportCall(call *ast.CallExpr, info *types.Info) bool {
+	fun, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	sel, ok := info.Selections[fun]
+	if !ok || sel.Kind() != types.MethodVal {
+		return false
+	}
+	recv := sel.Recv()
+	if p, ok := recv.(*types.Pointer); ok {
+		recv = p.Elem()
+	}
+	named, ok := types.Unalias(recv).(*types.Named)
+	return ok && named.Obj().Name() == "StartupReport"
+}
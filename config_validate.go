@@ -0,0 +1,384 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package crawler
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FieldError 单个配置字段的校验错误
+type FieldError struct {
+	Path    string      // TOML路径，如 conn.connect_timeout
+	Value   interface{} // 当前值
+	Message string      // 错误说明
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s: %s（当前值: %v）", e.Path, e.Message, e.Value)
+}
+
+// ValidationError 配置校验错误，汇总所有字段错误
+type ValidationError struct {
+	Errors []*FieldError
+}
+
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "配置校验失败，共 %d 处错误:", len(e.Errors))
+	for _, fe := range e.Errors {
+		b.WriteString("\n  - ")
+		b.WriteString(fe.Error())
+	}
+	return b.String()
+}
+
+// configEnums 枚举类配置项的可选值（TOML路径 -> 可选值），数组配置项约束其中每个元素
+//
+// 校验和 JSON Schema 生成共用此表。
+var configEnums = map[string][]string{
+	"logs.level":                       {"debug", "info", "warn", "error"},
+	"logs.levels":                      {"debug", "info", "warn", "error"},
+	"logs.format":                      {"json", "text"},
+	"fingerprint.selection_strategy":   {"random", "round_robin", "least_used", "least_blocked"},
+	"fingerprint.browsers":             {"chrome", "firefox", "safari", "edge", "opera"},
+	"fingerprint.operating_systems":    {"windows", "macos", "linux", "ios"},
+	"local_ip_pool.selection_strategy": {"random", "round_robin", "least_used"},
+	"certificate.provider":             {"letsencrypt", "self-signed"},
+	"ip_pool_test.test_method":         {"GET", "HEAD"},
+	"blacklist_recovery.test_method":   {"GET", "HEAD"},
+	"server.access_log_format":         {"combined", "json"},
+}
+
+// Validate 对配置做语义校验，返回汇总了所有字段错误的 *ValidationError
+func (c *SystemConfig) Validate() error {
+	v := &validator{}
+
+	if c.ConfigVersion < 0 || c.ConfigVersion > CurrentConfigVersion {
+		v.errs = append(v.errs, &FieldError{
+			Path:    "config_version",
+			Value:   c.ConfigVersion,
+			Message: fmt.Sprintf("必须在 0-%d 之间", CurrentConfigVersion),
+		})
+	}
+	validateLogs(&c.Logs, v.section("logs"))
+	validateFingerprint(&c.Fingerprint, v.section("fingerprint"))
+	validateDomainDNS(&c.DomainDNS, v.section("domaindns"))
+	validateLocalIPPool(&c.LocalIPPool, v.section("local_ip_pool"))
+	validateConn(&c.Conn, v.section("conn"))
+	validateNetConnPool(&c.NetConnPool, v.section("netconnpool"))
+	validateQUIC(&c.QUIC, v.section("quic"))
+	validateCertificate(&c.Certificate, v.section("certificate"))
+	validateIPStatus(&c.IPStatus, v.section("ip_status"))
+	validateIPPoolTest(&c.IPPoolTest, v.section("ip_pool_test"))
+	validateBlacklistRecovery(&c.BlacklistRecovery, v.section("blacklist_recovery"))
+	validateStatusReport(&c.StatusReport, v.section("status_report"))
+	validateServer(&c.Server, v.section("server"))
+	validateCrawler(&c.Crawler, v.section("crawler"))
+	validateSystem(&c.System, v.section("system"))
+
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return &ValidationError{Errors: v.errs}
+}
+
+func validateLogs(c *LogsConfig, v *sectionValidator) {
+	v.oneOf("level", c.Level, configEnums["logs.level"]...)
+	names := make([]string, 0, len(c.Levels))
+	for name := range c.Levels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		v.oneOf("levels."+name, c.Levels[name], configEnums["logs.levels"]...)
+	}
+	v.oneOf("format", c.Format, configEnums["logs.format"]...)
+	if c.FileEnabled {
+		v.required("file_path", c.FilePath)
+		v.positive("max_size", c.MaxSize)
+	}
+	v.nonNegative("max_backups", c.MaxBackups)
+}
+
+func validateFingerprint(c *FingerprintConfig, v *sectionValidator) {
+	v.oneOf("selection_strategy", c.SelectionStrategy, configEnums["fingerprint.selection_strategy"]...)
+	if c.EnableRotation {
+		v.positiveDuration("rotation_interval", c.RotationInterval)
+	}
+	for i, b := range c.Browsers {
+		v.oneOf(indexPath("browsers", i), b, configEnums["fingerprint.browsers"]...)
+	}
+	for i, name := range c.OperatingSystems {
+		v.oneOf(indexPath("operating_systems", i), name, configEnums["fingerprint.operating_systems"]...)
+	}
+	v.positiveDuration("health_half_life", c.HealthHalfLife)
+}
+
+func validateDomainDNS(c *DomainDNSConfig, v *sectionValidator) {
+	if len(c.DNSServers) == 0 {
+		v.addf("dns_servers", c.DNSServers, "至少需要配置一个DNS服务器")
+	}
+	for i, server := range c.DNSServers {
+		if !isValidDNSServer(server) {
+			v.addf(indexPath("dns_servers", i), server, "不是合法的IP或 IP:端口")
+		}
+	}
+	if c.CacheEnabled {
+		v.minDuration("cache_ttl", c.CacheTTL, time.Minute)
+	}
+	v.positiveDuration("timeout", c.Timeout)
+	v.nonNegative("max_retries", c.MaxRetries)
+	v.nonNegativeDuration("retry_interval", c.RetryInterval)
+}
+
+func validateLocalIPPool(c *LocalIPPoolConfig, v *sectionValidator) {
+	for i, ip := range c.IPs {
+		if net.ParseIP(ip) == nil {
+			v.addf(indexPath("ips", i), ip, "不是合法的IP地址")
+		}
+	}
+	v.oneOf("selection_strategy", c.SelectionStrategy, configEnums["local_ip_pool.selection_strategy"]...)
+	if c.HealthCheckEnabled {
+		v.positiveDuration("health_check_interval", c.HealthCheckInterval)
+		v.positiveDuration("health_check_timeout", c.HealthCheckTimeout)
+		v.positive("max_failures", c.MaxFailures)
+		v.positiveDuration("recovery_check_interval", c.RecoveryCheckInterval)
+	}
+}
+
+func validateConn(c *ConnConfig, v *sectionValidator) {
+	v.positiveDuration("connect_timeout", c.ConnectTimeout)
+	v.positiveDuration("read_timeout", c.ReadTimeout)
+	v.positiveDuration("write_timeout", c.WriteTimeout)
+	if c.KeepAlive {
+		v.positiveDuration("keep_alive_time", c.KeepAliveTime)
+	}
+	v.nonNegative("max_idle_conns", c.MaxIdleConns)
+	v.positive("max_conns_per_host", c.MaxConnsPerHost)
+	v.positiveDuration("tls_handshake_timeout", c.TLSHandshakeTimeout)
+}
+
+func validateNetConnPool(c *NetConnPoolConfig, v *sectionValidator) {
+	validatePool(v, c.MaxConnections, c.InitialConnections)
+	v.positiveDuration("acquire_timeout", c.AcquireTimeout)
+	v.positiveDuration("idle_timeout", c.IdleTimeout)
+	v.positiveDuration("max_lifetime", c.MaxLifetime)
+	v.positiveDuration("health_check_interval", c.HealthCheckInterval)
+	v.positiveDuration("health_check_timeout", c.HealthCheckTimeout)
+}
+
+func validateQUIC(c *QUICConfig, v *sectionValidator) {
+	validatePool(v, c.MaxConnections, c.InitialConnections)
+	v.positiveDuration("idle_timeout", c.IdleTimeout)
+	v.positiveDuration("max_lifetime", c.MaxLifetime)
+}
+
+// validatePool 校验连接池容量：最大连接数必须为正，初始连接数不能超过最大连接数
+func validatePool(v *sectionValidator, maxConns, initialConns int) {
+	v.positive("max_connections", maxConns)
+	v.nonNegative("initial_connections", initialConns)
+	if maxConns > 0 && initialConns > maxConns {
+		v.addf("initial_connections", initialConns, "不能大于 max_connections (%d)", maxConns)
+	}
+}
+
+func validateCertificate(c *CertificateConfig, v *sectionValidator) {
+	v.required("server_domain", c.ServerDomain)
+	v.required("cert_storage_path", c.CertStoragePath)
+	v.oneOf("provider", c.Provider, configEnums["certificate.provider"]...)
+	if c.AutoRenewal {
+		v.positiveDuration("renewal_check_interval", c.RenewalCheckInterval)
+		v.positiveDuration("renewal_before_days", c.RenewalBeforeDays)
+	}
+	// 证书库暂不支持 Let's Encrypt，两种提供商都会签发自签名证书
+	v.positiveDuration("self_signed_validity_days", c.SelfSignedValidityDays)
+}
+
+func validateIPStatus(c *IPStatusConfig, v *sectionValidator) {
+	v.nonNegative("min_whitelist_count", c.MinWhitelistCount)
+	if c.WhitelistMonitoring {
+		v.positiveDuration("whitelist_monitoring_interval", c.WhitelistMonitoringInterval)
+	}
+}
+
+func validateIPPoolTest(c *IPPoolTestConfig, v *sectionValidator) {
+	for i, domain := range c.TargetDomains {
+		v.required(indexPath("target_domains", i), domain)
+	}
+	v.required("test_url", c.TestURL)
+	v.oneOf("test_method", c.TestMethod, configEnums["ip_pool_test.test_method"]...)
+	v.positive("max_concurrent", c.MaxConcurrent)
+	v.positiveDuration("test_timeout", c.TestTimeout)
+	v.nonNegative("retry_count", c.RetryCount)
+	v.nonNegativeDuration("retry_interval", c.RetryInterval)
+	v.positiveDuration("test_interval", c.TestInterval)
+	for i, code := range c.SuccessStatusCodes {
+		v.statusCode(indexPath("success_status_codes", i), code)
+	}
+	for i, code := range c.ForbiddenStatusCodes {
+		v.statusCode(indexPath("forbidden_status_codes", i), code)
+	}
+}
+
+func validateBlacklistRecovery(c *BlacklistRecoveryConfig, v *sectionValidator) {
+	if !c.Enabled {
+		return
+	}
+	v.positiveDuration("check_interval", c.CheckInterval)
+	v.positiveDuration("ip_test_interval", c.IPTestInterval)
+	v.positive("max_concurrent", c.MaxConcurrent)
+	v.positiveDuration("test_timeout", c.TestTimeout)
+	v.required("test_url", c.TestURL)
+	v.oneOf("test_method", c.TestMethod, configEnums["blacklist_recovery.test_method"]...)
+}
+
+func validateStatusReport(c *StatusReportConfig, v *sectionValidator) {
+	v.positiveDuration("report_interval", c.ReportInterval)
+	v.nonNegative("max_report_ips", c.MaxReportIPs)
+}
+
+func validateServer(c *ServerConfig, v *sectionValidator) {
+	if _, port, err := net.SplitHostPort(c.ListenAddress); err != nil {
+		v.addf("listen_address", c.ListenAddress, "不是合法的 主机:端口 地址")
+	} else if p, err := strconv.Atoi(port); err != nil || p < 1 || p > 65535 {
+		v.addf("listen_address", c.ListenAddress, "端口必须在 1-65535 之间")
+	}
+	v.positive("max_clients", c.MaxClients)
+	v.positiveDuration("client_timeout", c.ClientTimeout)
+	if c.ClientAuthEnabled {
+		v.required("client_cert_path", c.ClientCertPath)
+	}
+	if c.AccessLogEnabled {
+		v.required("access_log_path", c.AccessLogPath)
+		v.oneOf("access_log_format", c.AccessLogFormat, configEnums["server.access_log_format"]...)
+		v.positive("access_log_max_size", c.AccessLogMaxSize)
+		v.nonNegative("access_log_max_backups", c.AccessLogMaxBackups)
+	}
+}
+
+func validateCrawler(c *CrawlerConfig, v *sectionValidator) {
+	v.positiveDuration("default_timeout", c.DefaultTimeout)
+	v.nonNegative("max_retries", c.MaxRetries)
+	v.nonNegativeDuration("retry_interval", c.RetryInterval)
+	v.positive("concurrency", c.Concurrency)
+	v.nonNegative("rate_limit", c.RateLimit)
+	if c.QueueEnabled {
+		v.positive("queue_size", c.QueueSize)
+	}
+	if c.DeduplicationEnabled {
+		v.positiveDuration("deduplication_ttl", c.DeduplicationTTL)
+	}
+}
+
+func validateSystem(c *SystemInfoConfig, v *sectionValidator) {
+	v.required("name", c.Name)
+	v.required("data_dir", c.DataDir)
+	v.positiveDuration("shutdown_timeout", c.ShutdownTimeout)
+	v.positiveDuration("module_stop_timeout", c.ModuleStopTimeout)
+}
+
+// isValidDNSServer 判断DNS服务器地址是否为 IP 或 IP:端口
+func isValidDNSServer(server string) bool {
+	if net.ParseIP(server) != nil {
+		return true
+	}
+	host, port, err := net.SplitHostPort(server)
+	if err != nil || net.ParseIP(host) == nil {
+		return false
+	}
+	p, err := strconv.Atoi(port)
+	return err == nil && p >= 1 && p <= 65535
+}
+
+// indexPath 生成数组元素的TOML路径，如 browsers[0]
+func indexPath(key string, i int) string {
+	return fmt.Sprintf("%s[%d]", key, i)
+}
+
+// validator 收集所有字段错误
+type validator struct {
+	errs []*FieldError
+}
+
+// section 返回以指定表名为前缀的校验器
+func (v *validator) section(name string) *sectionValidator {
+	return &sectionValidator{v: v, prefix: name}
+}
+
+// sectionValidator 某个配置段的校验器，字段路径自动加上段前缀
+type sectionValidator struct {
+	v      *validator
+	prefix string
+}
+
+func (s *sectionValidator) addf(key string, value interface{}, format string, args ...interface{}) {
+	s.v.errs = append(s.v.errs, &FieldError{
+		Path:    s.prefix + "." + key,
+		Value:   value,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+func (s *sectionValidator) required(key, value string) {
+	if strings.TrimSpace(value) == "" {
+		s.addf(key, value, "不能为空")
+	}
+}
+
+func (s *sectionValidator) oneOf(key, value string, allowed ...string) {
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
+	}
+	s.addf(key, value, "必须是 %s 之一", strings.Join(allowed, ", "))
+}
+
+func (s *sectionValidator) positive(key string, value int) {
+	if value <= 0 {
+		s.addf(key, value, "必须大于0")
+	}
+}
+
+func (s *sectionValidator) nonNegative(key string, value int) {
+	if value < 0 {
+		s.addf(key, value, "不能为负数")
+	}
+}
+
+// durationValue 时长类配置项
+type durationValue interface {
+	Duration() time.Duration
+	String() string
+}
+
+func (s *sectionValidator) positiveDuration(key string, value durationValue) {
+	if value.Duration() <= 0 {
+		s.addf(key, value, "必须大于0")
+	}
+}
+
+func (s *sectionValidator) minDuration(key string, value durationValue, min time.Duration) {
+	if value.Duration() < min {
+		s.addf(key, value, "不能小于 %v", min)
+	}
+}
+
+func (s *sectionValidator) nonNegativeDuration(key string, value durationValue) {
+	if value.Duration() < 0 {
+		s.addf(key, value, "不能为负数")
+	}
+}
+
+func (s *sectionValidator) statusCode(key string, value int) {
+	if value < 100 || value > 599 {
+		s.addf(key, value, "不是合法的HTTP状态码")
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/vistone/domaindns"
+
+	"github.com/vistone/crawler-system/internal/moduleinit"
+)
+
+// ResolveDomain 使用 [domaindns] 配置的DNS服务器解析域名，不启动系统
+//
+// 返回 domaindns 的IP池数据（按记录类型分组），ctx 结束前仍未得到结果时返回错误。
+// 解析过程中的临时文件写入临时目录，返回前删除。
+func ResolveDomain(ctx context.Context, cfg *DomainDNSConfig, domain string) (map[string][]domaindns.IPRecord, error) {
+	dir, err := os.MkdirTemp("", "crawler-dns-")
+	if err != nil {
+		return nil, fmt.Errorf("创建临时目录失败: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	dnsCfg := moduleinit.DNSMonitorConfig(cfg, []string{domain})
+	dnsCfg.StorageDir = dir
+
+	monitor, err := domaindns.NewMonitorWithConfig(dnsCfg)
+	if err != nil {
+		return nil, fmt.Errorf("创建DNS监控器失败: %w", err)
+	}
+	monitor.Start()
+	defer monitor.Stop()
+
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		if pool, ok := monitor.GetDomainPool(domain); ok {
+			return pool, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("解析域名 %s 失败: %w", domain, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,19 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package crawler
+
+import "github.com/vistone/crawler-system/internal/config"
+
+// Duration 时长配置，整数按秒解析，也可写时长字符串（如 "1500ms"、"5m"、"30d"）
+type Duration = config.Duration
+
+// HourDuration 时长配置，整数按小时解析
+type HourDuration = config.HourDuration
+
+// DayDuration 时长配置，整数按天解析
+type DayDuration = config.DayDuration
+
+// day 一天的时长
+const day = config.Day
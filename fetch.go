@@ -0,0 +1,511 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package crawler
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/andybalholm/brotli"
+	fhttp "github.com/bogdanfinn/fhttp"
+	"github.com/bogdanfinn/fhttp/http2"
+	utls "github.com/bogdanfinn/utls"
+	"github.com/klauspost/compress/zstd"
+	"github.com/vistone/fingerprint"
+
+	"github.com/vistone/crawler-system/internal/logging"
+	"github.com/vistone/crawler-system/internal/moduleinit"
+)
+
+// maxFetchBodySize Fetch 读取的响应体上限
+const maxFetchBodySize = 32 << 20
+
+// FetchRequest 一次性请求
+type FetchRequest struct {
+	Method string      // 请求方法，默认 GET
+	URL    string      // 请求地址，支持 http 和 https
+	Header http.Header // 额外的请求头，覆盖指纹生成的同名请求头
+	Body   []byte      // 请求体
+}
+
+// FetchResponse 一次性请求的响应
+type FetchResponse struct {
+	StatusCode  int
+	Proto       string // HTTP/1.1 或 HTTP/2.0
+	Header      http.Header
+	Body        []byte        // 已按 Content-Encoding 解压的响应体
+	Fingerprint string        // 使用的指纹ID
+	LocalIP     string        // 绑定的本地出口IP，未绑定时为空
+	RemoteAddr  string        // 实际连接的目标地址
+	Duration    time.Duration // 从解析域名到读完响应体的耗时
+}
+
+// Fetch 通过完整的指纹、本地IP池和连接配置发送一次请求
+//
+// 目标域名优先使用DNS监控器的解析结果，跳过黑名单中的IP并优先使用白名单中的IP；
+// 连接绑定本地IP池中的出口IP（地址族与目标一致时），TLS 握手和 HTTP/2 帧使用所选指纹。
+// HTTP/1.1 连接按 [netconnpool] 和 conn.max_idle_conns 放回TCP连接池，同一目标和指纹的后续请求复用。
+// 同一目标主机在 fingerprint.rotation_interval 内使用同一个指纹；请求结果（正常响应、403、人机验证页面、
+// TLS 握手被重置）记录到指纹在该主机上的健康统计，目标拒绝当前指纹时强制轮换。
+// 系统需处于 Running 或 Standby 状态；请求会登记为进行中的请求，Stop 时等待其结束。
+// 同时进行的请求不超过 crawler.concurrency，发起速率不超过 crawler.rate_limit；
+// 没有收到响应（解析、连接、握手或发送失败）时间隔 crawler.retry_interval 重试，最多 crawler.max_retries 次。
+// 请求超时取 ctx 和 crawler.default_timeout 中较早者，包括排队和重试的时间。
+// 启用 crawler.deduplication_enabled 时，deduplication_ttl 内方法、URL 和请求体都相同的请求返回错误，失败的请求不计入。
+// 无论成功与否，请求都会记录到访问日志（启用时）。
+func (s *System) Fetch(ctx context.Context, req FetchRequest) (result *FetchResponse, err error) {
+	done, err := s.beginRequest(StateRunning, StateStandby)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	start := time.Now()
+	record := logging.RequestRecord{Time: start, Method: req.Method, URL: req.URL}
+	if record.Method == "" {
+		record.Method = http.MethodGet
+	}
+	defer func() {
+		record.Duration = time.Since(start)
+		if err != nil {
+			record.Error = err.Error()
+		}
+		s.AccessLog.LogRequest(record)
+	}()
+
+	cfg := s.CurrentConfig()
+	if timeout := cfg.Crawler.DefaultTimeout.Duration(); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		return nil, fmt.Errorf("解析URL失败: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("不支持的协议 %q，只支持 http 和 https", u.Scheme)
+	}
+
+	forget, err := s.dedup.claim(record.Method, req.URL, req.Body)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			forget()
+		}
+	}()
+
+	release, err := s.limiter.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	for attempt := 0; ; attempt++ {
+		result, err = s.fetchOnce(ctx, req, u, cfg, &record)
+		var retryable *noResponseError
+		if !errors.As(err, &retryable) || attempt >= cfg.Crawler.MaxRetries || ctx.Err() != nil {
+			break
+		}
+		record.Retries++
+		select {
+		case <-ctx.Done():
+		case <-time.After(cfg.Crawler.RetryInterval.Duration()):
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	result.Duration = time.Since(start)
+	return result, nil
+}
+
+// noResponseError 没有收到响应的失败，Fetch 按 crawler.max_retries 重试这类失败
+type noResponseError struct{ err error }
+
+func (e *noResponseError) Error() string { return e.err.Error() }
+func (e *noResponseError) Unwrap() error { return e.err }
+
+// noResponse 标记没有收到响应的失败
+func noResponse(err error) error {
+	return &noResponseError{err: err}
+}
+
+// fetchOnce 选择指纹、从TCP连接池取出到目标的连接并发送一次请求，结果写入 record
+//
+// 连接按协议、主机、端口和指纹分组复用。复用的空闲连接没有收到响应时（对方已关闭连接），
+// 关闭该连接并换一个连接重发，不计入重试。
+func (s *System) fetchOnce(ctx context.Context, req FetchRequest, u *url.URL, cfg *SystemConfig, record *logging.RequestRecord) (*FetchResponse, error) {
+	host, port := u.Hostname(), u.Port()
+	if port == "" {
+		port = map[string]string{"http": "80", "https": "443"}[u.Scheme]
+	}
+
+	fp, err := s.FingerprintManager.Next(ctx, host)
+	if err != nil {
+		return nil, fmt.Errorf("选择指纹失败: %w", err)
+	}
+	record.Fingerprint = fp.ID
+
+	release, err := s.ConnManager.Acquire(ctx, host)
+	if err != nil {
+		return nil, fmt.Errorf("等待目标主机的连接名额失败: %w", err)
+	}
+	defer release()
+
+	key := u.Scheme + "://" + net.JoinHostPort(host, port) + "#" + fp.ID
+	dial := func(ctx context.Context) (moduleinit.PooledConn, error) {
+		return s.dialFetchConn(ctx, u.Scheme, host, port, fp, &cfg.Conn)
+	}
+	for {
+		pooled, err := s.NetConnPool.Get(ctx, key, dial)
+		if err != nil {
+			var failed *dialError
+			if errors.As(err, &failed) {
+				record.Retries += failed.retries
+			}
+			return nil, noResponse(err)
+		}
+		conn := pooled.GetConn().(*fetchConn)
+		reused := pooled.GetReuseCount() > 0
+		if !reused {
+			record.Retries += conn.dialRetries
+		}
+		result, reusable, err := s.exchange(ctx, conn, req, fp, record)
+		s.NetConnPool.Put(key, pooled, reusable)
+
+		var stale *noResponseError
+		if err != nil && reused && errors.As(err, &stale) && ctx.Err() == nil {
+			continue
+		}
+		return result, err
+	}
+}
+
+// exchange 在连接上发送请求并读完响应，返回响应和连接能否复用
+func (s *System) exchange(ctx context.Context, conn *fetchConn, req FetchRequest, fp *Fingerprint, record *logging.RequestRecord) (*FetchResponse, bool, error) {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+	deadline, _ := ctx.Deadline()
+	conn.tc.SetRequestDeadline(deadline)
+	defer conn.tc.SetRequestDeadline(time.Time{})
+
+	record.TargetIP, record.LocalIP = "", ""
+	if addr, ok := conn.raw.RemoteAddr().(*net.TCPAddr); ok {
+		record.TargetIP = addr.IP.String()
+	}
+	if conn.localIP != nil {
+		record.LocalIP = conn.localIP.String()
+	}
+
+	freq, err := buildFetchRequest(ctx, req, fp)
+	if err != nil {
+		return nil, true, err
+	}
+	resp, err := conn.roundTrip(freq, fp)
+	if err != nil {
+		return nil, false, noResponse(fmt.Errorf("发送请求失败: %w", err))
+	}
+	defer resp.Body.Close()
+	record.Proto, record.Status = resp.Proto, resp.StatusCode
+
+	host := freq.URL.Hostname()
+	body, err := readFetchBody(resp)
+	if outcome, ok := classifyResponse(resp, body); ok {
+		s.recordFingerprintOutcome(fp.ID, host, outcome)
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("读取响应失败: %w", err)
+	}
+	record.Bytes = int64(len(body))
+	reusable := !conn.h2() && !resp.Close && bodyDrained(resp)
+
+	return &FetchResponse{
+		StatusCode:  resp.StatusCode,
+		Proto:       resp.Proto,
+		Header:      http.Header(resp.Header),
+		Body:        body,
+		Fingerprint: fp.ID,
+		LocalIP:     record.LocalIP,
+		RemoteAddr:  conn.raw.RemoteAddr().String(),
+	}, reusable, nil
+}
+
+// recordFingerprintOutcome 记录指纹在目标主机上的请求结果，目标拒绝该指纹时强制轮换，下次请求换一个
+func (s *System) recordFingerprintOutcome(id, host string, outcome FingerprintOutcome) {
+	s.FingerprintManager.RecordOutcome(id, host, outcome)
+	if outcome.Blocked() {
+		s.FingerprintManager.Rotate(host)
+	}
+}
+
+// challengeMarkers 人机验证页面（Cloudflare、DataDome、PerimeterX、reCAPTCHA、hCaptcha、Turnstile）中的特征字符串
+var challengeMarkers = [][]byte{
+	[]byte("challenge-platform"),
+	[]byte("<title>Just a moment...</title>"),
+	[]byte("captcha-delivery.com"),
+	[]byte("px-captcha"),
+	[]byte("g-recaptcha"),
+	[]byte("h-captcha"),
+	[]byte("cf-turnstile"),
+}
+
+// classifyResponse 判断响应对指纹健康统计的意义，返回 false 表示不计入统计
+//
+// 人机验证由 Cloudflare 的 cf-mitigated 和 AWS WAF 的 x-amzn-waf-action 响应头，
+// 或 403、429、503 响应体中的验证页面特征识别；其余 403 计为被拒绝。
+// 429 和 5xx 通常与出口IP或目标自身状态有关，不计入指纹的统计。
+func classifyResponse(resp *fhttp.Response, body []byte) (FingerprintOutcome, bool) {
+	if strings.EqualFold(resp.Header.Get("Cf-Mitigated"), "challenge") {
+		return OutcomeChallenge, true
+	}
+	switch strings.ToLower(resp.Header.Get("X-Amzn-Waf-Action")) {
+	case "challenge", "captcha":
+		return OutcomeChallenge, true
+	}
+	switch status := resp.StatusCode; {
+	case status == http.StatusForbidden || status == http.StatusTooManyRequests || status == http.StatusServiceUnavailable:
+		for _, marker := range challengeMarkers {
+			if bytes.Contains(body, marker) {
+				return OutcomeChallenge, true
+			}
+		}
+		return OutcomeForbidden, status == http.StatusForbidden
+	case status >= 500:
+		return 0, false
+	}
+	return OutcomeSuccess, true
+}
+
+// isConnReset 判断 TLS 握手错误是否为目标重置或直接关闭了连接（常见于按 ClientHello 拦截的目标）
+func isConnReset(err error) bool {
+	return errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// resolveTarget 解析目标主机，返回按优先级排列的IP：白名单优先，跳过黑名单
+func (s *System) resolveTarget(ctx context.Context, host string) ([]net.IP, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		return []net.IP{ip}, nil
+	}
+
+	candidates, err := s.lookupHost(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	var whitelisted, others []net.IP
+	for _, ip := range candidates {
+		status := "unknown"
+		if s.IPStatusManager != nil {
+			status = s.IPStatusManager.GetStatus(ip.String())
+		}
+		switch status {
+		case "blacklist":
+		case "whitelist":
+			whitelisted = append(whitelisted, ip)
+		default:
+			others = append(others, ip)
+		}
+	}
+	targets := append(whitelisted, others...)
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("域名 %s 没有可用的IP（解析出 %d 个，均在黑名单中）", host, len(candidates))
+	}
+	return targets, nil
+}
+
+// lookupHost 返回域名解析出的全部IP，不区分黑白名单
+//
+// 域名在DNS监控器中有缓存的解析结果时直接使用，否则通过 dns_servers 实时解析。
+func (s *System) lookupHost(ctx context.Context, host string) ([]net.IP, error) {
+	var candidates []net.IP
+	if s.DNSMonitor != nil {
+		if pool, ok := s.DNSMonitor.GetDomainPool(host); ok {
+			for _, records := range pool {
+				for _, r := range records {
+					if ip := net.ParseIP(r.IP); ip != nil {
+						candidates = append(candidates, ip)
+					}
+				}
+			}
+		}
+		candidates = s.DNSResolver.Filter(candidates)
+	}
+	if len(candidates) > 0 {
+		return candidates, nil
+	}
+	return s.DNSResolver.LookupIP(ctx, host)
+}
+
+// dialTarget 依次连接目标IP直到成功，返回连接、绑定的本地出口IP和连接失败后改用下一个IP的次数
+func (s *System) dialTarget(ctx context.Context, targets []net.IP, port string, cfg *ConnConfig) (net.Conn, net.IP, int, error) {
+	var localIP net.IP
+	if s.LocalIPPool != nil {
+		localIP = s.LocalIPPool.GetIP()
+	}
+
+	var lastErr error
+	for i, ip := range targets {
+		dialer := &net.Dialer{Timeout: cfg.ConnectTimeout.Duration()}
+		if cfg.KeepAlive {
+			dialer.KeepAlive = cfg.KeepAliveTime.Duration()
+		} else {
+			dialer.KeepAlive = -1
+		}
+		// 只在地址族一致时绑定本地IP
+		var bound net.IP
+		if localIP != nil && (localIP.To4() == nil) == (ip.To4() == nil) {
+			dialer.LocalAddr = &net.TCPAddr{IP: localIP}
+			bound = localIP
+		}
+		addr := net.JoinHostPort(ip.String(), port)
+		conn, err := dialer.DialContext(ctx, "tcp", addr)
+		if bound != nil && ctx.Err() == nil { // 请求被取消不算出口IP的失败
+			s.LocalIPPool.ReportResult(bound, addr, err)
+		}
+		if err == nil {
+			return conn, bound, i, nil
+		}
+		lastErr = err
+	}
+	return nil, nil, len(targets) - 1, fmt.Errorf("连接目标失败（尝试 %d 个IP）: %w", len(targets), lastErr)
+}
+
+// buildFetchRequest 构造带指纹请求头的请求，请求头按浏览器的顺序发送
+func buildFetchRequest(ctx context.Context, req FetchRequest, fp *Fingerprint) (*fhttp.Request, error) {
+	method := req.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	var body io.Reader
+	if len(req.Body) > 0 {
+		body = bytes.NewReader(req.Body)
+	}
+	freq, err := fhttp.NewRequestWithContext(ctx, method, req.URL, body)
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+
+	header, order := fingerprintHeaders(fp)
+	for key, values := range req.Header {
+		key = http.CanonicalHeaderKey(key)
+		if _, ok := header[key]; !ok {
+			order = append(order, strings.ToLower(key))
+		}
+		header[key] = values
+	}
+	header[fhttp.HeaderOrderKey] = order
+	header[fhttp.PHeaderOrderKey] = fp.Profile.GetPseudoHeaderOrder()
+	freq.Header = header
+	return freq, nil
+}
+
+// fingerprintHeaders 返回指纹对应的请求头及其发送顺序
+func fingerprintHeaders(fp *Fingerprint) (fhttp.Header, []string) {
+	header := fhttp.Header{}
+	order := make([]string, 0, len(fp.Headers))
+	for _, f := range fp.Headers {
+		header[f.Name] = []string{f.Value}
+		order = append(order, strings.ToLower(f.Name))
+	}
+	return header, order
+}
+
+// fingerprintHandshake 在连接上按指纹的 ClientHello 完成 TLS 握手，超时取 conn.tls_handshake_timeout
+func fingerprintHandshake(ctx context.Context, conn net.Conn, serverName string, fp *Fingerprint, cfg *ConnConfig) (*utls.UConn, error) {
+	tlsConn := utls.UClient(conn, &utls.Config{
+		ServerName:         serverName,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+		OmitEmptyPsk:       true, // 带 PSK 扩展的指纹在首次连接时没有会话票据
+	}, fp.Profile.GetClientHelloId(), false, false, true)
+	if timeout := cfg.TLSHandshakeTimeout.Duration(); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		return nil, fmt.Errorf("TLS握手失败: %w", err)
+	}
+	return tlsConn, nil
+}
+
+// roundTripTLS 在已完成握手的连接上按协商的协议发送请求
+func roundTripTLS(conn *utls.UConn, req *fhttp.Request, profile fingerprint.ClientProfile) (*fhttp.Response, error) {
+	if conn.ConnectionState().NegotiatedProtocol == "h2" {
+		return roundTripHTTP2(conn, req, profile)
+	}
+	return roundTripHTTP1(conn, bufio.NewReader(conn), req)
+}
+
+// roundTripHTTP1 在连接上发送 HTTP/1.1 请求，从 br 读取响应
+func roundTripHTTP1(w io.Writer, br *bufio.Reader, req *fhttp.Request) (*fhttp.Response, error) {
+	if err := req.Write(w); err != nil {
+		return nil, err
+	}
+	return fhttp.ReadResponse(br, req)
+}
+
+// roundTripHTTP2 在连接上按指纹的 HTTP/2 参数（SETTINGS、窗口、优先级、伪头顺序）发送请求
+func roundTripHTTP2(conn net.Conn, req *fhttp.Request, profile fingerprint.ClientProfile) (*fhttp.Response, error) {
+	t := &http2.Transport{
+		Settings:          profile.GetSettings(),
+		SettingsOrder:     profile.GetSettingsOrder(),
+		PseudoHeaderOrder: profile.GetPseudoHeaderOrder(),
+		ConnectionFlow:    profile.GetConnectionFlow(),
+		HeaderPriority:    profile.GetHeaderPriority(),
+		Priorities:        profile.GetPriorities(),
+	}
+	cc, err := t.NewClientConn(conn)
+	if err != nil {
+		return nil, err
+	}
+	return cc.RoundTrip(req)
+}
+
+// readFetchBody 读取响应体并按 Content-Encoding 解压
+func readFetchBody(resp *fhttp.Response) ([]byte, error) {
+	var r io.Reader = io.LimitReader(resp.Body, maxFetchBodySize)
+	switch strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding"))) {
+	case "", "identity":
+	case "gzip":
+		gr, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		defer gr.Close()
+		r = gr
+	case "deflate":
+		fr := flate.NewReader(r)
+		defer fr.Close()
+		r = fr
+	case "br":
+		r = brotli.NewReader(r)
+	case "zstd":
+		zr, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		r = zr
+	default:
+		return io.ReadAll(r) // 未知编码原样返回
+	}
+	return io.ReadAll(io.LimitReader(r, maxFetchBodySize))
+}
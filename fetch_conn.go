@@ -0,0 +1,121 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package crawler
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	fhttp "github.com/bogdanfinn/fhttp"
+	utls "github.com/bogdanfinn/utls"
+
+	"github.com/vistone/crawler-system/internal/moduleinit"
+)
+
+// fetchConn Fetch 使用的连接，放在TCP连接池中复用
+//
+// 只有 HTTP/1.1 连接会被复用；协商为 HTTP/2 的连接用完即关闭。
+type fetchConn struct {
+	mu sync.Mutex // 请求或健康检查期间持有
+
+	raw         net.Conn
+	tc          *moduleinit.TimeoutConn
+	tls         *utls.UConn   // http 目标为 nil
+	br          *bufio.Reader // 读取响应，复用时保留已缓冲的数据
+	localIP     net.IP        // 绑定的本地出口IP，未绑定时为 nil
+	dialRetries int           // 建立连接时改用下一个目标IP的次数
+}
+
+// dialError 建立连接失败，retries 为改用下一个目标IP的次数
+type dialError struct {
+	err     error
+	retries int
+}
+
+func (e *dialError) Error() string { return e.err.Error() }
+func (e *dialError) Unwrap() error { return e.err }
+
+// dialFetchConn 解析并连接目标，https 目标按指纹完成 TLS 握手
+//
+// 由连接池在分组需要新连接时调用，只能依赖分组所代表的协议、主机、端口和指纹。
+func (s *System) dialFetchConn(ctx context.Context, scheme, host, port string, fp *Fingerprint, cfg *ConnConfig) (*fetchConn, error) {
+	targets, err := s.resolveTarget(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	raw, localIP, retries, err := s.dialTarget(ctx, targets, port, cfg)
+	if err != nil {
+		return nil, &dialError{err: err, retries: retries}
+	}
+	deadline, _ := ctx.Deadline()
+	c := &fetchConn{raw: raw, tc: s.ConnManager.WrapConn(raw, deadline), localIP: localIP, dialRetries: retries}
+	var conn net.Conn = c.tc
+	if scheme == "https" {
+		if c.tls, err = fingerprintHandshake(ctx, c.tc, host, fp, cfg); err != nil {
+			raw.Close()
+			if isConnReset(err) {
+				s.recordFingerprintOutcome(fp.ID, host, OutcomeReset)
+			}
+			return nil, &dialError{err: err, retries: retries}
+		}
+		conn = c.tls
+	}
+	c.tc.SetRequestDeadline(time.Time{})
+	c.br = bufio.NewReader(conn)
+	return c, nil
+}
+
+// h2 判断连接是否协商为 HTTP/2
+func (c *fetchConn) h2() bool {
+	return c.tls != nil && c.tls.ConnectionState().NegotiatedProtocol == "h2"
+}
+
+// roundTrip 在连接上发送请求，调用方持有 mu
+func (c *fetchConn) roundTrip(req *fhttp.Request, fp *Fingerprint) (*fhttp.Response, error) {
+	if c.br.Buffered() > 0 {
+		return nil, errors.New("空闲连接上收到了未请求的数据")
+	}
+	if c.h2() {
+		return roundTripHTTP2(c.tls, req, fp.Profile)
+	}
+	if c.tls != nil {
+		return roundTripHTTP1(c.tls, c.br, req)
+	}
+	return roundTripHTTP1(c.tc, c.br, req)
+}
+
+// Close 关闭连接
+func (c *fetchConn) Close() error {
+	return c.raw.Close()
+}
+
+// Healthy 检查空闲连接是否已被对方关闭或收到了未请求的数据，正在使用的连接视为健康
+func (c *fetchConn) Healthy() bool {
+	if !c.mu.TryLock() {
+		return true
+	}
+	defer c.mu.Unlock()
+	if c.br.Buffered() > 0 {
+		return false
+	}
+	// 读超时说明连接上没有数据也没有关闭；TLS 连接的读超时不会破坏连接状态
+	c.tc.SetRequestDeadline(time.Now().Add(time.Millisecond))
+	defer c.tc.SetRequestDeadline(time.Time{})
+	_, err := c.br.Peek(1)
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// bodyDrained 判断响应体是否已读完，未读完的 HTTP/1.1 连接不能复用
+func bodyDrained(resp *fhttp.Response) bool {
+	var b [1]byte
+	n, err := resp.Body.Read(b[:])
+	return n == 0 && err == io.EOF
+}
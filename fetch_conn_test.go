@@ -0,0 +1,150 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package crawler
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/vistone/crawler-system/internal/logging"
+	"github.com/vistone/crawler-system/internal/moduleinit"
+)
+
+// newFetchTestSystem 创建可以调用 Fetch 的系统，返回本地 HTTP 服务和服务收到的连接数
+func newFetchTestSystem(t *testing.T, maxIdleConns, initialConns int, handler http.HandlerFunc) (*System, *httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var conns atomic.Int32
+	server := httptest.NewUnstartedServer(handler)
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	server.Start()
+	t.Cleanup(server.Close)
+
+	s, _ := newIPTestSystem(t, handler)
+	s.Config.Conn.MaxIdleConns = maxIdleConns
+	s.Config.NetConnPool.InitialConnections = initialConns
+	var err error
+	if s.NetConnPool, _, err = moduleinit.InitNetConnPool(&s.Config.NetConnPool, &s.Config.Conn, logging.Discard()); err != nil {
+		t.Fatalf("InitNetConnPool: %v", err)
+	}
+	t.Cleanup(func() { s.NetConnPool.Close() })
+	for _, state := range []State{StateInitializing, StateRunning} {
+		if err := s.transition(state, "测试", nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return s, server, &conns
+}
+
+func TestFetchReusesConnections(t *testing.T) {
+	tests := []struct {
+		name         string
+		maxIdleConns int
+		initialConns int
+		keepAlive    bool
+		wantConns    int32
+	}{
+		{"复用空闲连接", 2, 0, true, 1},
+		{"预热的连接被复用", 2, 2, true, 2},
+		{"max_idle_conns 为0时不复用", 0, 0, true, 3},
+		{"服务器要求关闭时不复用", 2, 0, false, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, server, conns := newFetchTestSystem(t, tt.maxIdleConns, tt.initialConns, func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("ok"))
+			})
+			server.Config.SetKeepAlivesEnabled(tt.keepAlive)
+			for i := 0; i < 3; i++ {
+				resp, err := s.Fetch(context.Background(), FetchRequest{URL: server.URL + "/"})
+				if err != nil {
+					t.Fatalf("第 %d 次 Fetch: %v", i+1, err)
+				}
+				if string(resp.Body) != "ok" {
+					t.Fatalf("响应体 %q，want ok", resp.Body)
+				}
+			}
+			if got := conns.Load(); got != tt.wantConns {
+				t.Errorf("服务器收到 %d 个连接，want %d", got, tt.wantConns)
+			}
+		})
+	}
+}
+
+func TestFetchRedialsClosedIdleConnection(t *testing.T) {
+	s, server, conns := newFetchTestSystem(t, 2, 0, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+	if _, err := s.Fetch(context.Background(), FetchRequest{URL: server.URL + "/"}); err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	// 服务器关闭空闲连接，连接池中的连接失效
+	server.CloseClientConnections()
+
+	var record logging.RequestRecord
+	u, err := url.Parse(server.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.fetchOnce(context.Background(), FetchRequest{URL: u.String()}, u, s.CurrentConfig(), &record); err != nil {
+		t.Fatalf("连接被服务器关闭后 fetchOnce: %v", err)
+	}
+	if record.Retries != 0 {
+		t.Errorf("换用新连接重发计入了 %d 次重试，want 0", record.Retries)
+	}
+	if got := conns.Load(); got != 2 {
+		t.Errorf("服务器收到 %d 个连接，want 2", got)
+	}
+}
+
+func TestFetchRecordsNoResponseRetries(t *testing.T) {
+	s, server, _ := newFetchTestSystem(t, 2, 0, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+	// 目标只有一个IP，连接失败时没有下一个目标IP可换，重试次数全部来自 crawler.max_retries
+	s.Config.Crawler.MaxRetries = 2
+	s.Config.Crawler.RetryInterval = Duration(time.Millisecond)
+	path := filepath.Join(t.TempDir(), "access.log")
+	accessLog, err := logging.OpenAccessLog(path, 1, 1, false, logging.AccessFormatJSON, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.AccessLog = accessLog
+	target := server.URL + "/"
+	server.Close()
+
+	if _, err := s.Fetch(context.Background(), FetchRequest{URL: target}); err == nil {
+		t.Fatal("目标已关闭时 Fetch 应失败")
+	}
+	if err := accessLog.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var record struct {
+		Retries int    `json:"retries"`
+		Error   string `json:"error"`
+	}
+	if err := json.Unmarshal(data, &record); err != nil {
+		t.Fatalf("解析访问日志 %q: %v", data, err)
+	}
+	if record.Retries != 2 || record.Error == "" {
+		t.Fatalf("访问日志 retries=%d error=%q，want retries=2 且记录失败原因", record.Retries, record.Error)
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package crawler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// maxDedupEntries 去重缓存的条目上限，超过时丢弃最早登记的请求
+const maxDedupEntries = 100000
+
+// requestDeduper 在 crawler.deduplication_ttl 内拒绝重复的爬取请求
+//
+// 请求方法、URL 和请求体都相同时视为重复。所有条目的 TTL 相同，
+// 登记顺序也就是过期顺序，清理时从最早登记的条目开始。
+type requestDeduper struct {
+	ttl time.Duration
+
+	mu    sync.Mutex
+	seen  map[string]time.Time // 请求键 -> 过期时间
+	order []dedupEntry         // 按登记顺序排列
+}
+
+type dedupEntry struct {
+	key     string
+	expires time.Time
+}
+
+// newRequestDeduper 创建请求去重器，未启用 crawler.deduplication_enabled 时返回 nil
+func newRequestDeduper(cfg *CrawlerConfig) *requestDeduper {
+	if !cfg.DeduplicationEnabled {
+		return nil
+	}
+	return &requestDeduper{
+		ttl:  cfg.DeduplicationTTL.Duration(),
+		seen: make(map[string]time.Time),
+	}
+}
+
+// claim 登记一个请求，同一请求在 TTL 内已登记过时返回错误
+//
+// 返回的函数撤销登记，请求失败时调用，使失败的请求可以重新发起。d 为 nil 时不做任何事。
+func (d *requestDeduper) claim(method, rawURL string, body []byte) (func(), error) {
+	if d == nil {
+		return func() {}, nil
+	}
+	key := method + " " + rawURL
+	if len(body) > 0 {
+		sum := sha256.Sum256(body)
+		key += " " + hex.EncodeToString(sum[:])
+	}
+
+	now := time.Now()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.pruneLocked(now)
+	if expires, ok := d.seen[key]; ok {
+		return nil, fmt.Errorf("重复的请求 %s %s（crawler.deduplication_ttl 内已请求过，%v 后可重新请求）",
+			method, rawURL, expires.Sub(now).Round(time.Second))
+	}
+	// 撤销的条目仍留在 order 中直到过期，按 order 的长度限制才能同时限制两者
+	for len(d.order) >= maxDedupEntries {
+		d.popLocked()
+	}
+	expires := now.Add(d.ttl)
+	d.seen[key] = expires
+	d.order = append(d.order, dedupEntry{key: key, expires: expires})
+
+	return func() {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		if d.seen[key].Equal(expires) {
+			delete(d.seen, key)
+		}
+	}, nil
+}
+
+// pruneLocked 删除已过期的条目，调用方持有 mu
+func (d *requestDeduper) pruneLocked(now time.Time) {
+	for len(d.order) > 0 && !now.Before(d.order[0].expires) {
+		d.popLocked()
+	}
+}
+
+// popLocked 删除最早登记的条目，调用方持有 mu
+func (d *requestDeduper) popLocked() {
+	e := d.order[0]
+	d.order = d.order[1:]
+	// 撤销后重新登记的请求有新的过期时间，不能删除
+	if d.seen[e.key].Equal(e.expires) {
+		delete(d.seen, e.key)
+	}
+}
+
+// entries 返回去重缓存中的条目数
+func (d *requestDeduper) entries() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.seen)
+}
@@ -0,0 +1,81 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package crawler
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRequestDeduperRejectsRepeatWithinTTL(t *testing.T) {
+	d := newRequestDeduper(&CrawlerConfig{DeduplicationEnabled: true, DeduplicationTTL: Duration(50 * time.Millisecond)})
+
+	if _, err := d.claim("GET", "https://example.com/a", nil); err != nil {
+		t.Fatalf("第一次请求: %v", err)
+	}
+	if _, err := d.claim("GET", "https://example.com/a", nil); err == nil {
+		t.Fatal("TTL 内的重复请求应返回错误")
+	}
+	if _, err := d.claim("HEAD", "https://example.com/a", nil); err != nil {
+		t.Fatalf("方法不同不算重复: %v", err)
+	}
+	if _, err := d.claim("POST", "https://example.com/a", []byte("1")); err != nil {
+		t.Fatalf("POST 第一次请求: %v", err)
+	}
+	if _, err := d.claim("POST", "https://example.com/a", []byte("2")); err != nil {
+		t.Fatalf("请求体不同不算重复: %v", err)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if _, err := d.claim("GET", "https://example.com/a", nil); err != nil {
+		t.Fatalf("TTL 过期后应允许重新请求: %v", err)
+	}
+}
+
+func TestRequestDeduperForgetAllowsRetry(t *testing.T) {
+	d := newRequestDeduper(&CrawlerConfig{DeduplicationEnabled: true, DeduplicationTTL: Duration(time.Hour)})
+
+	forget, err := d.claim("GET", "https://example.com/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forget()
+	forget2, err := d.claim("GET", "https://example.com/", nil)
+	if err != nil {
+		t.Fatalf("撤销登记后应允许重新请求: %v", err)
+	}
+	// 旧的撤销函数不能删除重新登记的条目
+	forget()
+	if _, err := d.claim("GET", "https://example.com/", nil); err == nil {
+		t.Fatal("重新登记的请求被旧的撤销函数删除")
+	}
+	forget2()
+}
+
+func TestRequestDeduperBounded(t *testing.T) {
+	d := newRequestDeduper(&CrawlerConfig{DeduplicationEnabled: true, DeduplicationTTL: Duration(time.Hour)})
+	for i := 0; i < maxDedupEntries+10; i++ {
+		if _, err := d.claim("GET", fmt.Sprintf("https://example.com/%d", i), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := d.entries(); n > maxDedupEntries {
+		t.Fatalf("去重缓存有 %d 条，超过上限 %d", n, maxDedupEntries)
+	}
+	// 最早的请求已被丢弃，可以重新请求
+	if _, err := d.claim("GET", "https://example.com/0", nil); err != nil {
+		t.Fatalf("超出上限时应丢弃最早的请求: %v", err)
+	}
+}
+
+func TestRequestDeduperDisabled(t *testing.T) {
+	d := newRequestDeduper(&CrawlerConfig{DeduplicationTTL: Duration(time.Hour)})
+	for i := 0; i < 2; i++ {
+		if _, err := d.claim("GET", "https://example.com/", nil); err != nil {
+			t.Fatalf("未启用去重时不应拒绝请求: %v", err)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// requestLimiter 按 [crawler] 配置限制爬取请求的并发数和发起速率
+//
+// 限制可以通过 update 在线修改：并发数调小时进行中的请求不受影响，
+// 新请求等到进行中的请求数降到新限制以下再发起。
+type requestLimiter struct {
+	mu           sync.Mutex
+	limit        int           // 并发请求数上限（crawler.concurrency）
+	queueSize    int           // 排队请求数上限（crawler.queue_size），未启用队列时为0
+	queueEnabled bool          // 是否启用请求队列
+	interval     time.Duration // 相邻两个请求的最小间隔，0表示不限制
+	active       int           // 进行中的请求数
+	waiting      int           // 排队中的请求数
+	released     chan struct{} // 有请求结束或限制变化时关闭并替换，唤醒排队的请求
+	next         time.Time     // 下一个请求最早的发起时间
+}
+
+func newRequestLimiter(cfg *CrawlerConfig) *requestLimiter {
+	l := &requestLimiter{released: make(chan struct{})}
+	l.update(cfg)
+	return l
+}
+
+// update 应用新的并发、队列和速率限制
+func (l *requestLimiter) update(cfg *CrawlerConfig) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limit = cfg.Concurrency
+	l.queueEnabled = cfg.QueueEnabled
+	l.queueSize = 0
+	if cfg.QueueEnabled {
+		l.queueSize = cfg.QueueSize
+	}
+	l.interval = 0
+	if cfg.RateLimit > 0 {
+		l.interval = time.Second / time.Duration(cfg.RateLimit)
+	}
+	// 速率调高时，按旧间隔排好的下一个发起时间不应晚于按新间隔计算的时间
+	if latest := time.Now().Add(l.interval); l.next.After(latest) {
+		l.next = latest
+	}
+	l.wakeLocked()
+}
+
+// wakeLocked 唤醒所有排队的请求重新检查名额，调用方持有 mu
+func (l *requestLimiter) wakeLocked() {
+	close(l.released)
+	l.released = make(chan struct{})
+}
+
+// acquire 占用一个并发名额并等到速率限制允许发起请求，返回释放名额的函数
+//
+// 并发名额已满时：启用队列则排队等待，队列也满时返回错误；未启用队列时直接返回错误。
+func (l *requestLimiter) acquire(ctx context.Context) (func(), error) {
+	l.mu.Lock()
+	if l.active >= l.limit {
+		if !l.queueEnabled {
+			l.mu.Unlock()
+			return nil, fmt.Errorf("并发请求数已达 crawler.concurrency (%d)", l.limit)
+		}
+		if l.waiting >= l.queueSize {
+			l.mu.Unlock()
+			return nil, fmt.Errorf("请求队列已满（crawler.queue_size = %d）", l.queueSize)
+		}
+		l.waiting++
+		for l.active >= l.limit {
+			released := l.released
+			l.mu.Unlock()
+			select {
+			case <-released:
+			case <-ctx.Done():
+				l.mu.Lock()
+				l.waiting--
+				l.mu.Unlock()
+				return nil, fmt.Errorf("排队等待请求名额失败: %w", ctx.Err())
+			}
+			l.mu.Lock()
+		}
+		l.waiting--
+	}
+	l.active++
+	l.mu.Unlock()
+
+	var once sync.Once
+	release := func() {
+		once.Do(func() {
+			l.mu.Lock()
+			l.active--
+			l.wakeLocked()
+			l.mu.Unlock()
+		})
+	}
+
+	if err := l.wait(ctx); err != nil {
+		release()
+		return nil, err
+	}
+	return release, nil
+}
+
+// wait 等到距上一个请求至少 interval 后返回
+func (l *requestLimiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+	if l.interval <= 0 {
+		l.mu.Unlock()
+		return nil
+	}
+	now := time.Now()
+	at := l.next
+	if at.Before(now) {
+		at = now
+	}
+	l.next = at.Add(l.interval)
+	l.mu.Unlock()
+
+	delay := time.Until(at)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("等待速率限制失败: %w", ctx.Err())
+	}
+}
+
+// queuedRequests 返回排队中的请求数
+func (l *requestLimiter) queuedRequests() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.waiting
+}
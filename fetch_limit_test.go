@@ -0,0 +1,110 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package crawler
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRequestLimiterConcurrencyAndQueue(t *testing.T) {
+	l := newRequestLimiter(&CrawlerConfig{Concurrency: 1, QueueEnabled: true, QueueSize: 1})
+	ctx := context.Background()
+
+	release, err := l.acquire(ctx)
+	if err != nil {
+		t.Fatalf("第一个请求: %v", err)
+	}
+
+	queued := make(chan error, 1)
+	go func() {
+		r, err := l.acquire(ctx)
+		if err == nil {
+			r()
+		}
+		queued <- err
+	}()
+	// 等第二个请求进入队列
+	for deadline := time.Now().Add(time.Second); l.queuedRequests() == 0; {
+		if time.Now().After(deadline) {
+			t.Fatal("第二个请求没有进入队列")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if _, err := l.acquire(ctx); err == nil {
+		t.Fatal("队列已满时应返回错误")
+	}
+
+	release()
+	if err := <-queued; err != nil {
+		t.Fatalf("排队的请求: %v", err)
+	}
+}
+
+func TestRequestLimiterWithoutQueue(t *testing.T) {
+	l := newRequestLimiter(&CrawlerConfig{Concurrency: 1})
+	release, err := l.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("第一个请求: %v", err)
+	}
+	defer release()
+	if _, err := l.acquire(context.Background()); err == nil {
+		t.Fatal("未启用队列且并发已满时应返回错误")
+	}
+}
+
+func TestRequestLimiterRateLimit(t *testing.T) {
+	l := newRequestLimiter(&CrawlerConfig{Concurrency: 3, RateLimit: 20})
+	start := time.Now()
+	for range 3 {
+		release, err := l.acquire(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		release()
+	}
+	// 20 次/秒，第三个请求至少在第一个之后 100ms 发起
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Fatalf("3 个请求用时 %v，未按 rate_limit 限速", elapsed)
+	}
+}
+
+func TestRequestLimiterUpdateWakesQueuedRequests(t *testing.T) {
+	l := newRequestLimiter(&CrawlerConfig{Concurrency: 1, QueueEnabled: true, QueueSize: 1})
+	ctx := context.Background()
+	release, err := l.acquire(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+
+	queued := make(chan error, 1)
+	go func() {
+		r, err := l.acquire(ctx)
+		if err == nil {
+			r()
+		}
+		queued <- err
+	}()
+	for deadline := time.Now().Add(time.Second); l.queuedRequests() == 0; {
+		if time.Now().After(deadline) {
+			t.Fatal("第二个请求没有进入队列")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// 第一个请求仍在进行，调大并发数后排队的请求应立即发起
+	l.update(&CrawlerConfig{Concurrency: 2, QueueEnabled: true, QueueSize: 1})
+	select {
+	case err := <-queued:
+		if err != nil {
+			t.Fatalf("排队的请求: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("调大 concurrency 后排队的请求没有被唤醒")
+	}
+}
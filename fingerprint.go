@@ -0,0 +1,25 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package crawler
+
+import (
+	"github.com/vistone/crawler-system/internal/moduleinit"
+)
+
+// Fingerprint 一次请求使用的浏览器指纹，由 FingerprintManager.Next 返回
+type Fingerprint = moduleinit.Fingerprint
+
+// FingerprintOutcome 一次请求的结果，由 Fetch 记录到指纹健康统计，也可通过 FingerprintManager.RecordOutcome 记录
+type FingerprintOutcome = moduleinit.FingerprintOutcome
+
+// FingerprintHealth 指纹在一个目标上的请求统计，由 FingerprintManager.Health 返回
+type FingerprintHealth = moduleinit.FingerprintHealth
+
+const (
+	OutcomeSuccess   = moduleinit.OutcomeSuccess   // 目标正常响应
+	OutcomeForbidden = moduleinit.OutcomeForbidden // 目标返回 403
+	OutcomeChallenge = moduleinit.OutcomeChallenge // 目标返回人机验证页面
+	OutcomeReset     = moduleinit.OutcomeReset     // TLS 握手时连接被目标重置或关闭
+)
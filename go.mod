@@ -3,32 +3,32 @@ module github.com/vistone/crawler-system
 go 1.25.4
 
 require (
+	github.com/andybalholm/brotli v1.2.0
+	github.com/bogdanfinn/fhttp v0.6.3
+	github.com/bogdanfinn/utls v1.7.4-barnius
+	github.com/klauspost/compress v1.18.2
 	github.com/pelletier/go-toml/v2 v2.2.4
 	github.com/vistone/certs v1.0.0
 	github.com/vistone/domaindns v1.0.0
 	github.com/vistone/fingerprint v1.0.0
 	github.com/vistone/localippool v1.0.0
-	github.com/vistone/logs v1.0.0
 	github.com/vistone/netconnpool v1.0.1
 	github.com/vistone/quic v1.0.0
+	golang.org/x/sys v0.39.0
 )
 
 require (
 	github.com/BurntSushi/toml v1.5.0 // indirect
-	github.com/andybalholm/brotli v1.2.0 // indirect
-	github.com/bogdanfinn/fhttp v0.6.3 // indirect
-	github.com/bogdanfinn/utls v1.7.4-barnius // indirect
 	github.com/cloudflare/circl v1.6.1 // indirect
-	github.com/klauspost/compress v1.18.2 // indirect
 	github.com/miekg/dns v1.1.68 // indirect
 	github.com/quic-go/quic-go v0.57.1 // indirect
 	github.com/vishvananda/netlink v1.3.1 // indirect
 	github.com/vishvananda/netns v0.0.5 // indirect
+	github.com/vistone/logs v1.0.0 // indirect
 	golang.org/x/crypto v0.46.0 // indirect
 	golang.org/x/mod v0.31.0 // indirect
 	golang.org/x/net v0.48.0 // indirect
 	golang.org/x/sync v0.19.0 // indirect
-	golang.org/x/sys v0.39.0 // indirect
 	golang.org/x/text v0.32.0 // indirect
 	golang.org/x/tools v0.40.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
@@ -0,0 +1,151 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Duration 时长配置
+//
+// 配置文件中可以写成整数（按秒解析，兼容旧配置）或时长字符串，
+// 字符串支持 Go 时长格式（"1500ms"、"30s"、"2h"）以及天（"30d"）。
+type Duration time.Duration
+
+// Duration 返回 time.Duration
+func (d Duration) Duration() time.Duration {
+	return time.Duration(d)
+}
+
+// String 返回紧凑的时长文本，如 5m、1h30m、1500ms
+func (d Duration) String() string {
+	return formatDuration(time.Duration(d), false)
+}
+
+// MarshalText 实现 encoding.TextMarshaler
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText 实现 encoding.TextUnmarshaler，整数按秒解析
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := ParseDuration(string(text), time.Second)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// HourDuration 时长配置，整数按小时解析（兼容 renewal_check_interval 等旧配置）
+type HourDuration time.Duration
+
+// Duration 返回 time.Duration
+func (d HourDuration) Duration() time.Duration {
+	return time.Duration(d)
+}
+
+// String 返回紧凑的时长文本
+func (d HourDuration) String() string {
+	return formatDuration(time.Duration(d), false)
+}
+
+// MarshalText 实现 encoding.TextMarshaler
+func (d HourDuration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText 实现 encoding.TextUnmarshaler，整数按小时解析
+func (d *HourDuration) UnmarshalText(text []byte) error {
+	v, err := ParseDuration(string(text), time.Hour)
+	if err != nil {
+		return err
+	}
+	*d = HourDuration(v)
+	return nil
+}
+
+// DayDuration 时长配置，整数按天解析（兼容 renewal_before_days 等旧配置）
+type DayDuration time.Duration
+
+// Duration 返回 time.Duration
+func (d DayDuration) Duration() time.Duration {
+	return time.Duration(d)
+}
+
+// Days 返回整天数（向下取整）
+func (d DayDuration) Days() int {
+	return int(time.Duration(d) / Day)
+}
+
+// String 返回紧凑的时长文本，整天数时使用 d 后缀
+func (d DayDuration) String() string {
+	return formatDuration(time.Duration(d), true)
+}
+
+// MarshalText 实现 encoding.TextMarshaler
+func (d DayDuration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText 实现 encoding.TextUnmarshaler，整数按天解析
+func (d *DayDuration) UnmarshalText(text []byte) error {
+	v, err := ParseDuration(string(text), Day)
+	if err != nil {
+		return err
+	}
+	*d = DayDuration(v)
+	return nil
+}
+
+// Day 一天的时长
+const Day = 24 * time.Hour
+
+// ParseDuration 解析时长：纯整数乘以 unit，否则按 Go 时长格式解析，额外支持 d（天）
+func ParseDuration(s string, unit time.Duration) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Duration(n) * unit, nil
+	}
+
+	// 处理天：如 30d、1d12h
+	var days time.Duration
+	if i := strings.IndexByte(s, 'd'); i > 0 {
+		n, err := strconv.ParseInt(s[:i], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("无法解析为时长: %q", s)
+		}
+		days = time.Duration(n) * Day
+		s = s[i+1:]
+		if s == "" {
+			return days, nil
+		}
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("无法解析为时长: %q", s)
+	}
+	return days + d, nil
+}
+
+// formatDuration 格式化时长，去掉 time.Duration.String 中多余的 0m0s，
+// useDays 为 true 时整天数使用 d 后缀
+func formatDuration(d time.Duration, useDays bool) string {
+	if useDays && d != 0 && d%Day == 0 {
+		return strconv.FormatInt(int64(d/Day), 10) + "d"
+	}
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
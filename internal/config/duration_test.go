@@ -0,0 +1,85 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		in      string
+		unit    time.Duration
+		want    time.Duration
+		wantErr bool
+	}{
+		{"300", time.Second, 300 * time.Second, false},
+		{"12", time.Hour, 12 * time.Hour, false},
+		{"30", Day, 30 * Day, false},
+		{" 0 ", time.Second, 0, false},
+		{"1500ms", time.Second, 1500 * time.Millisecond, false},
+		{"1h30m", time.Hour, 90 * time.Minute, false},
+		{"30d", time.Second, 30 * Day, false},
+		{"1d12h", time.Second, 36 * time.Hour, false},
+		{"5x", time.Second, 0, true},
+		{"d", time.Second, 0, true},
+		{"xd", time.Second, 0, true},
+		{"1d5x", time.Second, 0, true},
+		{"1.5", time.Second, 0, true},
+		{"", time.Second, 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseDuration(tt.in, tt.unit)
+		if tt.wantErr {
+			if err == nil || !strings.Contains(err.Error(), "无法解析为时长") {
+				t.Errorf("ParseDuration(%q) = %v, %v，want 无法解析为时长的错误", tt.in, got, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseDuration(%q, %v) = %v, %v，want %v", tt.in, tt.unit, got, err, tt.want)
+		}
+	}
+}
+
+// durationConfig 各时长配置类型的指针
+type durationConfig interface {
+	UnmarshalText([]byte) error
+	MarshalText() ([]byte, error)
+	Duration() time.Duration
+}
+
+func TestDurationTextRoundTrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		v        durationConfig
+		text     string
+		want     time.Duration
+		wantText string
+	}{
+		{"Duration 整数按秒", new(Duration), "90", 90 * time.Second, "1m30s"},
+		{"Duration 整分钟", new(Duration), "5m", 5 * time.Minute, "5m"},
+		{"Duration 整小时", new(Duration), "2h0m0s", 2 * time.Hour, "2h"},
+		{"HourDuration 整数按小时", new(HourDuration), "12", 12 * time.Hour, "12h"},
+		{"DayDuration 整数按天", new(DayDuration), "30", 30 * Day, "30d"},
+		{"DayDuration 非整天", new(DayDuration), "36h", 36 * time.Hour, "36h"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.v.UnmarshalText([]byte(tt.text)); err != nil {
+				t.Fatalf("UnmarshalText(%q): %v", tt.text, err)
+			}
+			text, err := tt.v.MarshalText()
+			if err != nil {
+				t.Fatalf("MarshalText: %v", err)
+			}
+			if got := tt.v.Duration(); got != tt.want || string(text) != tt.wantText {
+				t.Fatalf("UnmarshalText(%q) = %v（%s），want %v（%s）", tt.text, got, text, tt.want, tt.wantText)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// RedactedText 敏感值的脱敏显示文本
+const RedactedText = "******"
+
+// Secret 敏感配置值（如API Token、邮箱）
+//
+// 配置文件中可以直接写明文，也可以写引用：
+//   - env:NAME  从环境变量 NAME 读取
+//   - file:/path 从文件读取（去除首尾空白）
+//
+// 引用在 LoadConfig 时解析。Secret 通过 fmt 输出或经 JSON、TOML 等编码器序列化时总是脱敏，
+// 需要明文时调用 Value。
+type Secret string
+
+// Value 返回明文
+func (s Secret) Value() string {
+	return string(s)
+}
+
+// IsSet 判断是否配置了值
+func (s Secret) IsSet() bool {
+	return s != ""
+}
+
+// String 返回脱敏后的文本，未配置时返回空字符串
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return RedactedText
+}
+
+// GoString 返回脱敏后的文本，避免 %#v 泄露明文
+func (s Secret) GoString() string {
+	return fmt.Sprintf("config.Secret(%q)", s.String())
+}
+
+// Format 实现 fmt.Formatter，所有格式化动词都输出脱敏文本
+func (s Secret) Format(f fmt.State, verb rune) {
+	switch verb {
+	case 'q':
+		fmt.Fprintf(f, "%q", s.String())
+	case 'v':
+		if f.Flag('#') {
+			fmt.Fprint(f, s.GoString())
+			return
+		}
+		fmt.Fprint(f, s.String())
+	default:
+		fmt.Fprint(f, s.String())
+	}
+}
+
+// MarshalText 实现 encoding.TextMarshaler，序列化为脱敏文本（TOML 编码器使用）
+func (s Secret) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// MarshalJSON 实现 json.Marshaler，序列化为脱敏文本
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
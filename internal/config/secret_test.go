@@ -0,0 +1,83 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+func TestSecretNeverSerializesPlaintext(t *testing.T) {
+	const plaintext = "tok-3f9a2c"
+	type holder struct {
+		Token   Secret            `toml:"token" json:"token"`
+		Ptr     *Secret           `toml:"ptr" json:"ptr"`
+		List    []Secret          `toml:"list" json:"list"`
+		ByName  map[string]Secret `toml:"by_name" json:"by_name"`
+		Section DomainDNSConfig   `toml:"section" json:"section"`
+	}
+	secret := Secret(plaintext)
+	v := holder{
+		Token:   secret,
+		Ptr:     &secret,
+		List:    []Secret{secret},
+		ByName:  map[string]Secret{"a": secret},
+		Section: DomainDNSConfig{IPInfoToken: secret},
+	}
+
+	tests := []struct {
+		name    string
+		marshal func(v interface{}) ([]byte, error)
+	}{
+		{"json.Marshal", json.Marshal},
+		{"toml.Marshal", toml.Marshal},
+		{"fmt %+v", func(v interface{}) ([]byte, error) { return []byte(fmt.Sprintf("%+v", v)), nil }},
+		{"fmt %#v", func(v interface{}) ([]byte, error) { return []byte(fmt.Sprintf("%#v", v)), nil }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := tt.marshal(v)
+			if err != nil {
+				t.Fatalf("序列化失败: %v", err)
+			}
+			if strings.Contains(string(out), plaintext) {
+				t.Fatalf("输出中包含明文:\n%s", out)
+			}
+			if n := strings.Count(string(out), RedactedText); n != 5 {
+				t.Fatalf("输出中有 %d 处脱敏文本，want 5:\n%s", n, out)
+			}
+		})
+	}
+}
+
+func TestSecretUnsetSerializesEmpty(t *testing.T) {
+	v := struct {
+		Token Secret `toml:"token" json:"token"`
+	}{}
+	out, err := json.Marshal(v)
+	if err != nil || string(out) != `{"token":""}` {
+		t.Fatalf("json.Marshal = %s, %v，want 空字符串", out, err)
+	}
+	out, err = toml.Marshal(v)
+	if err != nil || strings.TrimSpace(string(out)) != `token = ''` {
+		t.Fatalf("toml.Marshal = %s, %v，want 空字符串", out, err)
+	}
+}
+
+func TestSecretDecodesPlaintext(t *testing.T) {
+	var v struct {
+		Token Secret `toml:"token" json:"token"`
+	}
+	if err := toml.Unmarshal([]byte(`token = "env:IPINFO_TOKEN"`), &v); err != nil || v.Token.Value() != "env:IPINFO_TOKEN" {
+		t.Fatalf("toml.Unmarshal = %q, %v", v.Token.Value(), err)
+	}
+	if err := json.Unmarshal([]byte(`{"token":"tok"}`), &v); err != nil || v.Token.Value() != "tok" {
+		t.Fatalf("json.Unmarshal = %q, %v", v.Token.Value(), err)
+	}
+}
@@ -4,110 +4,206 @@
 
 package config
 
-// 配置类型定义，避免循环导入
+import "time"
+
+// 各配置段的类型定义（唯一来源）
+//
+// 根包通过类型别名导出这些类型并组成 SystemConfig，模块初始化直接使用，
+// 不再需要转换。字段的行尾注释同时用于生成参考配置和 JSON Schema。
 
 // LogsConfig 日志配置
 type LogsConfig struct {
-	Level       string
-	FileEnabled bool
-	FilePath    string
-	MaxSize     int
-	MaxBackups  int
-	Compress    bool
-	Format      string
-	ShowCaller  bool
+	Level       string            `toml:"level"`        // 日志级别: debug, info, warn, error
+	Levels      map[string]string `toml:"levels"`       // 按模块覆盖日志级别，键为模块名，如 domaindns = "debug"；未列出的模块使用 level
+	OutputPath  string            `toml:"output_path"`  // 日志输出路径（空或 stdout 表示标准输出，stderr 表示标准错误，none 表示不输出，其他值为追加写入的文件）
+	FileEnabled bool              `toml:"file_enabled"` // 是否输出到文件
+	FilePath    string            `toml:"file_path"`    // 日志文件路径
+	MaxSize     int               `toml:"max_size"`     // 日志文件最大大小（MB）
+	MaxBackups  int               `toml:"max_backups"`  // 保留的日志文件数量
+	Compress    bool              `toml:"compress"`     // 是否压缩旧日志
+	Format      string            `toml:"format"`       // 日志格式: json, text
+	ShowCaller  bool              `toml:"show_caller"`  // 是否显示调用位置
 }
 
 // FingerprintConfig 指纹配置
 type FingerprintConfig struct {
-	SelectionStrategy string
-	EnableRotation    bool
-	RotationInterval  int
-	LibraryPath       string
-	Browsers          []string
-	OSRandomization   bool
-	UARandomization   bool
+	SelectionStrategy string   `toml:"selection_strategy"` // 指纹选择策略: random, round_robin, least_used, least_blocked
+	EnableRotation    bool     `toml:"enable_rotation"`    // 是否启用指纹轮换
+	RotationInterval  Duration `toml:"rotation_interval"`  // 指纹轮换间隔（秒）
+	LibraryPath       string   `toml:"library_path"`       // 自定义指纹库文件或目录（.toml/.json），与内置指纹库合并（空表示只使用内置指纹库）
+	Browsers          []string `toml:"browsers"`           // 支持的浏览器列表（空表示使用所有），可选: chrome, firefox, safari, edge, opera
+	OperatingSystems  []string `toml:"operating_systems"`  // 支持的操作系统列表（空表示使用所有），可选: windows, macos, linux, ios
+	OSRandomization   bool     `toml:"os_randomization"`   // 是否启用操作系统随机化
+	UARandomization   bool     `toml:"ua_randomization"`   // 是否随机化 User-Agent 细节（界面语言、Linux 发行版标识），始终与浏览器版本和操作系统一致
+	HealthHalfLife    Duration `toml:"health_half_life"`   // 指纹健康统计（成功、403、人机验证、TLS 重置次数）的衰减半衰期（秒）
 }
 
-// DomainDNSConfig DNS配置
+// DomainDNSConfig DNS解析配置
 type DomainDNSConfig struct {
-	DNSServers         []string
-	CacheEnabled       bool
-	CacheTTL           int
-	Timeout            int
-	MaxRetries         int
-	RetryInterval      int
-	PollutionDetection bool
-	IPv6Enabled        bool
-	IPInfoToken        string
+	DNSServers         []string `toml:"dns_servers"`         // DNS服务器列表
+	CacheEnabled       bool     `toml:"cache_enabled"`       // 是否启用DNS缓存：启用时DNS监控器定期解析目标域名并缓存结果，未启用时每次请求通过 dns_servers 实时解析
+	CacheTTL           Duration `toml:"cache_ttl"`           // DNS缓存TTL（秒），即DNS监控器重新解析的间隔，不小于60
+	Timeout            Duration `toml:"timeout"`             // DNS查询超时（秒）
+	MaxRetries         int      `toml:"max_retries"`         // 最大重试次数
+	RetryInterval      Duration `toml:"retry_interval"`      // 重试间隔（秒）
+	PollutionDetection bool     `toml:"pollution_detection"` // 是否启用DNS污染检测：丢弃解析结果中的回环、私有和保留地址，爬取内网目标时需关闭
+	IPv6Enabled        bool     `toml:"ipv6_enabled"`        // 是否启用IPv6
+	IPInfoToken        Secret   `toml:"ipinfo_token"`        // IPInfo.io API Token（用于获取IP详细信息，支持 env:/file: 引用）
 }
 
 // LocalIPPoolConfig 本地IP池配置
 type LocalIPPoolConfig struct {
-	IPs                   []string
-	SelectionStrategy     string
-	HealthCheckEnabled    bool
-	HealthCheckInterval   int
-	HealthCheckTimeout    int
-	MaxFailures           int
-	RecoveryCheckInterval int
+	IPs                   []string `toml:"ips"`                     // 本地出口IP列表（用于绑定本地连接，与黑白名单无关）
+	SelectionStrategy     string   `toml:"selection_strategy"`      // IP选择策略: random, round_robin, least_used
+	HealthCheckEnabled    bool     `toml:"health_check_enabled"`    // 是否启用IP健康检查：连续 max_failures 次连接失败的出口IP暂停使用 recovery_check_interval
+	HealthCheckInterval   Duration `toml:"health_check_interval"`   // IP健康检查间隔（秒）：暂停中的出口IP每隔这段时间重新连接最后失败的目标，成功则提前恢复使用
+	HealthCheckTimeout    Duration `toml:"health_check_timeout"`    // IP健康检查超时（秒）
+	MaxFailures           int      `toml:"max_failures"`            // IP最大失败次数（超过后标记为不健康）
+	RecoveryCheckInterval Duration `toml:"recovery_check_interval"` // IP恢复检查间隔（秒）
+}
+
+// ConnConfig 连接配置
+type ConnConfig struct {
+	ConnectTimeout      Duration `toml:"connect_timeout"`       // 连接超时（秒）
+	ReadTimeout         Duration `toml:"read_timeout"`          // 读取超时（秒）
+	WriteTimeout        Duration `toml:"write_timeout"`         // 写入超时（秒）
+	KeepAlive           bool     `toml:"keep_alive"`            // 是否启用Keep-Alive
+	KeepAliveTime       Duration `toml:"keep_alive_time"`       // Keep-Alive时间（秒）
+	MaxIdleConns        int      `toml:"max_idle_conns"`        // 每个目标的最大空闲连接数（TCP连接池中保留以便复用，0表示不复用连接）
+	MaxConnsPerHost     int      `toml:"max_conns_per_host"`    // 每个主机的最大连接数
+	TLSHandshakeTimeout Duration `toml:"tls_handshake_timeout"` // TLS握手超时（秒）
+	InsecureSkipVerify  bool     `toml:"insecure_skip_verify"`  // 是否跳过TLS证书验证（仅用于测试）
+}
+
+// NetConnPoolConfig TCP连接池配置
+type NetConnPoolConfig struct {
+	MaxConnections      int      `toml:"max_connections"`       // 每个目标同时使用的最大连接数
+	InitialConnections  int      `toml:"initial_connections"`   // 首次请求目标时预先建立的连接数
+	AcquireTimeout      Duration `toml:"acquire_timeout"`       // 连接数已满时等待可用连接的超时（秒）
+	IdleTimeout         Duration `toml:"idle_timeout"`          // 连接空闲超时（秒），目标的所有连接空闲超过该时间后连接池也被关闭
+	MaxLifetime         Duration `toml:"max_lifetime"`          // 连接最大生存时间（秒）
+	HealthCheckInterval Duration `toml:"health_check_interval"` // 空闲连接健康检查间隔（秒）
+	HealthCheckTimeout  Duration `toml:"health_check_timeout"`  // 连接健康检查超时（秒）
+}
+
+// QUICConfig QUIC连接池配置
+type QUICConfig struct {
+	MaxConnections     int      `toml:"max_connections"`     // 最大连接数
+	InitialConnections int      `toml:"initial_connections"` // 初始连接数
+	IdleTimeout        Duration `toml:"idle_timeout"`        // 连接空闲超时（秒）
+	MaxLifetime        Duration `toml:"max_lifetime"`        // 连接最大生存时间（秒）
 }
 
 // CertificateConfig 证书配置
 type CertificateConfig struct {
-	ServerDomain           string
-	CertStoragePath        string
-	Provider               string
-	AutoRenewal            bool
-	RenewalCheckInterval   int
-	RenewalBeforeDays      int
-	LetsEncryptEmail       string
-	LetsEncryptEnvironment string
-	SelfSignedValidityDays int
-	AutoDetectLocalIP      bool
+	ServerDomain           string       `toml:"server_domain"`             // 服务端域名（VPS域名，用于QUIC服务端）
+	CertStoragePath        string       `toml:"cert_storage_path"`         // 证书存储路径
+	Provider               string       `toml:"provider"`                  // 证书提供商: letsencrypt, self-signed
+	AutoRenewal            bool         `toml:"auto_renewal"`              // 是否自动续期
+	RenewalCheckInterval   HourDuration `toml:"renewal_check_interval"`    // 证书续期检查间隔（小时）
+	RenewalBeforeDays      DayDuration  `toml:"renewal_before_days"`       // 证书提前续期天数（在过期前N天续期）
+	AutoDetectLocalIP      bool         `toml:"auto_detect_local_ip"`      // 是否自动检测本地IP并加入证书
+	SelfSignedValidityDays DayDuration  `toml:"self_signed_validity_days"` // 自签名证书有效期（天）
 }
 
 // IPStatusConfig 黑白名单配置
 type IPStatusConfig struct {
-	MinWhitelistCount           int
-	AllowStartWhenEmpty         bool
-	WhitelistMonitoring         bool
-	WhitelistMonitoringInterval int
+	MinWhitelistCount           int      `toml:"min_whitelist_count"`           // 白名单最小数量（白名单非空但低于此值时健康检查报告异常）
+	AllowStartWhenEmpty         bool     `toml:"allow_start_when_empty"`        // 白名单为空时是否允许启动（允许启动但不参与爬取）
+	WhitelistMonitoring         bool     `toml:"whitelist_monitoring"`          // 是否启用白名单监控
+	WhitelistMonitoringInterval Duration `toml:"whitelist_monitoring_interval"` // 白名单监控间隔（秒）
 }
 
-// ConnConfig 连接配置
-type ConnConfig struct {
-	ConnectTimeout      int
-	ReadTimeout         int
-	WriteTimeout        int
-	KeepAlive           bool
-	KeepAliveTime       int
-	MaxIdleConns        int
-	MaxConnsPerHost     int
-	TLSHandshakeTimeout int
-	InsecureSkipVerify  bool
+// IPPoolTestConfig IP池测试配置
+type IPPoolTestConfig struct {
+	TargetDomains        []string `toml:"target_domains"`         // 目标域名列表（系统会解析这些域名，测试解析出的目标服务器IP）
+	TestURL              string   `toml:"test_url"`               // 测试URL（用于测试IP可用性，{domain}会被替换为实际域名）
+	TestMethod           string   `toml:"test_method"`            // 测试方法: GET, HEAD
+	MaxConcurrent        int      `toml:"max_concurrent"`         // 最大并发测试数
+	TestTimeout          Duration `toml:"test_timeout"`           // 测试超时（秒）
+	RetryCount           int      `toml:"retry_count"`            // 没有收到响应时的重试次数
+	RetryInterval        Duration `toml:"retry_interval"`         // 重试间隔（秒）
+	TestInterval         Duration `toml:"test_interval"`          // 测试间隔（秒）：系统启动时和之后每隔这段时间测试一轮目标域名解析出的、不在黑名单中的IP
+	UseFingerprint       bool     `toml:"use_fingerprint"`        // 是否使用指纹模拟（TLS握手和请求头），否则使用标准HTTP客户端
+	SuccessStatusCodes   []int    `toml:"success_status_codes"`   // 测试成功状态码列表（会被加入白名单，黑名单恢复测试也按此列表判断）
+	ForbiddenStatusCodes []int    `toml:"forbidden_status_codes"` // 测试失败状态码列表（会被加入黑名单）
 }
 
-// NetConnPoolConfig TCP连接池配置
-type NetConnPoolConfig struct {
-	MaxConnections      int
-	InitialConnections  int
-	AcquireTimeout      int
-	IdleTimeout         int
-	MaxLifetime         int
-	HealthCheckInterval int
-	HealthCheckTimeout  int
+// BlacklistRecoveryConfig 黑名单恢复配置
+type BlacklistRecoveryConfig struct {
+	Enabled        bool     `toml:"enabled"`          // 是否启用黑名单恢复：定期重新测试目标域名解析出的、在黑名单中的IP，通过测试的移回白名单
+	CheckInterval  Duration `toml:"check_interval"`   // 检查间隔（秒）
+	IPTestInterval Duration `toml:"ip_test_interval"` // 每个IP的测试间隔（秒，避免频繁测试）
+	MaxConcurrent  int      `toml:"max_concurrent"`   // 最大并发恢复测试数
+	TestTimeout    Duration `toml:"test_timeout"`     // 测试超时（秒）
+	TestURL        string   `toml:"test_url"`         // 测试URL（用于恢复测试，{domain}会被替换为实际域名）
+	TestMethod     string   `toml:"test_method"`      // 测试方法: GET, HEAD
+	UseFingerprint bool     `toml:"use_fingerprint"`  // 是否使用指纹模拟
 }
 
-// QUICConfig QUIC配置
-type QUICConfig struct {
-	MaxConnections      int
-	InitialConnections  int
-	AcquireTimeout      int
-	IdleTimeout         int
-	MaxLifetime         int
-	HealthCheckInterval int
-	HealthCheckTimeout  int
-	HandshakeTimeout    int
-	Enable0RTT          bool
+// StatusReportConfig 状态报告配置
+type StatusReportConfig struct {
+	ReportInterval Duration `toml:"report_interval"`  // 报告间隔（秒）：运行期间每隔这段时间将状态快照写入 Debug 级别日志
+	ReportOnChange bool     `toml:"report_on_change"` // 系统状态变化时是否立即以 Info 级别报告
+	ReportIPList   bool     `toml:"report_ip_list"`   // 是否在状态报告中列出白名单IP
+	MaxReportIPs   int      `toml:"max_report_ips"`   // 最大报告IP数量（超过此数量只报告数量，不报告列表）
+}
+
+// ServerConfig 服务端配置
+type ServerConfig struct {
+	ListenAddress       string   `toml:"listen_address"`         // QUIC服务端监听地址
+	QUICEnabled         bool     `toml:"quic_enabled"`           // 是否启用QUIC服务端
+	MaxClients          int      `toml:"max_clients"`            // 最大客户端连接数
+	ClientTimeout       Duration `toml:"client_timeout"`         // 客户端连接超时（秒）
+	ClientAuthEnabled   bool     `toml:"client_auth_enabled"`    // 是否启用客户端认证
+	ClientCertPath      string   `toml:"client_cert_path"`       // 客户端认证证书路径（如果启用）
+	AccessLogEnabled    bool     `toml:"access_log_enabled"`     // 是否启用访问日志（记录爬取请求和服务端客户端会话）
+	AccessLogPath       string   `toml:"access_log_path"`        // 访问日志路径
+	AccessLogFormat     string   `toml:"access_log_format"`      // 访问日志格式: combined, json
+	AccessLogMaxSize    int      `toml:"access_log_max_size"`    // 访问日志文件最大大小（MB），超过后轮转
+	AccessLogMaxBackups int      `toml:"access_log_max_backups"` // 保留的访问日志轮转文件数量（0表示全部保留）
+	AccessLogCompress   bool     `toml:"access_log_compress"`    // 是否压缩轮转出的访问日志
+}
+
+// CrawlerConfig 爬虫配置
+type CrawlerConfig struct {
+	DefaultTimeout       Duration `toml:"default_timeout"`       // 默认请求超时（秒）
+	MaxRetries           int      `toml:"max_retries"`           // 最大重试次数
+	RetryInterval        Duration `toml:"retry_interval"`        // 重试间隔（秒）
+	Concurrency          int      `toml:"concurrency"`           // 并发请求数
+	RateLimit            int      `toml:"rate_limit"`            // 请求速率限制（每秒请求数，0表示不限制）
+	QueueEnabled         bool     `toml:"queue_enabled"`         // 是否启用请求队列
+	QueueSize            int      `toml:"queue_size"`            // 请求队列大小
+	DeduplicationEnabled bool     `toml:"deduplication_enabled"` // 是否启用请求去重：去重缓存TTL内方法、URL和请求体都相同的请求直接返回错误，失败的请求不计入
+	DeduplicationTTL     Duration `toml:"deduplication_ttl"`     // 去重缓存TTL（秒）
+}
+
+// SystemInfoConfig 系统信息配置
+type SystemInfoConfig struct {
+	Name              string   `toml:"name"`                // 系统名称
+	Version           string   `toml:"version"`             // 系统版本
+	DataDir           string   `toml:"data_dir"`            // 数据目录
+	ShutdownTimeout   Duration `toml:"shutdown_timeout"`    // Run 收到停止信号后等待进行中请求结束的最长时间（秒）
+	ModuleStopTimeout Duration `toml:"module_stop_timeout"` // 停止单个模块的超时（秒），超时的模块记为未能正常停止
+}
+
+// GetConnectTimeout 等辅助函数返回 time.Duration，等价于直接调用对应字段的 Duration()
+func (c *ConnConfig) GetConnectTimeout() time.Duration {
+	return c.ConnectTimeout.Duration()
+}
+
+func (c *ConnConfig) GetReadTimeout() time.Duration {
+	return c.ReadTimeout.Duration()
+}
+
+func (c *ConnConfig) GetWriteTimeout() time.Duration {
+	return c.WriteTimeout.Duration()
+}
+
+func (c *ConnConfig) GetKeepAliveTime() time.Duration {
+	return c.KeepAliveTime.Duration()
+}
+
+func (c *ConnConfig) GetTLSHandshakeTimeout() time.Duration {
+	return c.TLSHandshakeTimeout.Duration()
 }
@@ -0,0 +1,222 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AccessFormat 访问日志格式
+type AccessFormat int
+
+const (
+	AccessFormatCombined AccessFormat = iota // 类似 Apache combined 格式，末尾追加 key=value 字段
+	AccessFormatJSON                         // 每行一个 JSON 对象
+)
+
+// ParseAccessFormat 将配置中的访问日志格式字符串转换为 AccessFormat，未知格式按 combined 处理
+func ParseAccessFormat(format string) AccessFormat {
+	if strings.EqualFold(format, "json") {
+		return AccessFormatJSON
+	}
+	return AccessFormatCombined
+}
+
+// RequestRecord 一次爬取请求的访问记录
+type RequestRecord struct {
+	Time        time.Time     // 请求开始时间
+	Method      string        // 请求方法
+	URL         string        // 目标地址
+	TargetIP    string        // 实际连接的目标IP，连接前失败时为空
+	LocalIP     string        // 绑定的本地出口IP，未绑定时为空
+	Fingerprint string        // 使用的指纹ID
+	Proto       string        // HTTP/1.1 或 HTTP/2.0，未收到响应时为空
+	Status      int           // 响应状态码，未收到响应时为0
+	Bytes       int64         // 响应体字节数（解压后）
+	Duration    time.Duration // 从开始到读完响应体（或失败）的耗时
+	Retries     int           // 重试次数：连接失败后改用下一个目标IP的次数，加上没有收到响应时按 crawler.max_retries 重发的次数
+	Error       string        // 失败原因，成功时为空
+}
+
+// SessionRecord 服务端一次客户端会话（客户端打开的一个QUIC流）的访问记录
+type SessionRecord struct {
+	Time       time.Time     // 会话开始时间
+	ClientID   string        // 客户端连接ID
+	RemoteAddr string        // 客户端地址
+	BytesIn    int64         // 从客户端读取的字节数
+	BytesOut   int64         // 写给客户端的字节数
+	Duration   time.Duration // 会话时长
+}
+
+// AccessLog 访问日志，每条记录一行，可并发使用
+//
+// nil *AccessLog 表示未启用访问日志，所有方法均为空操作。
+type AccessLog struct {
+	format AccessFormat
+
+	mu     sync.Mutex
+	w      io.Writer
+	closer io.Closer
+}
+
+// NewAccessLog 创建写入 w 的访问日志，closer 不为 nil 时由 Close 关闭
+func NewAccessLog(w io.Writer, closer io.Closer, format AccessFormat) *AccessLog {
+	return &AccessLog{format: format, w: w, closer: closer}
+}
+
+// OpenAccessLog 打开按大小轮转的访问日志文件，参数含义同 OpenRotatingFile
+//
+// onError 不为 nil 时接收后台压缩和清理的错误，见 RotatingFile.SetErrorHandler。
+func OpenAccessLog(path string, maxSizeMB, maxBackups int, compress bool, format AccessFormat, onError func(error)) (*AccessLog, error) {
+	file, err := OpenRotatingFile(path, maxSizeMB, maxBackups, compress)
+	if err != nil {
+		return nil, fmt.Errorf("打开访问日志失败: %w", err)
+	}
+	if onError != nil {
+		file.SetErrorHandler(onError)
+	}
+	return NewAccessLog(file, file, format), nil
+}
+
+// LogRequest 记录一次爬取请求
+func (a *AccessLog) LogRequest(r RequestRecord) {
+	if a == nil {
+		return
+	}
+	if a.format == AccessFormatJSON {
+		a.writeJSON(struct {
+			Time        string `json:"time"`
+			Type        string `json:"type"`
+			Method      string `json:"method"`
+			URL         string `json:"url"`
+			TargetIP    string `json:"target_ip,omitempty"`
+			LocalIP     string `json:"local_ip,omitempty"`
+			Fingerprint string `json:"fingerprint,omitempty"`
+			Proto       string `json:"proto,omitempty"`
+			Status      int    `json:"status"`
+			Bytes       int64  `json:"bytes"`
+			DurationMS  int64  `json:"duration_ms"`
+			Retries     int    `json:"retries"`
+			Error       string `json:"error,omitempty"`
+		}{
+			Time:        r.Time.Format(time.RFC3339Nano),
+			Type:        "request",
+			Method:      r.Method,
+			URL:         r.URL,
+			TargetIP:    r.TargetIP,
+			LocalIP:     r.LocalIP,
+			Fingerprint: r.Fingerprint,
+			Proto:       r.Proto,
+			Status:      r.Status,
+			Bytes:       r.Bytes,
+			DurationMS:  r.Duration.Milliseconds(),
+			Retries:     r.Retries,
+			Error:       r.Error,
+		})
+		return
+	}
+
+	// 出口IP - - [时间] "方法 URL 协议" 状态码 字节数 "-" "指纹" target_ip=... duration=... retries=... [error="..."]
+	var b []byte
+	b = append(b, dash(r.LocalIP)...)
+	b = append(b, " - - "...)
+	b = appendCombinedTime(b, r.Time)
+	b = fmt.Appendf(b, " %q %s %d \"-\" %q", r.Method+" "+r.URL+" "+dash(r.Proto), statusText(r.Status), r.Bytes, dash(r.Fingerprint))
+	b = fmt.Appendf(b, " target_ip=%s duration=%s retries=%d", dash(r.TargetIP), r.Duration.Round(time.Millisecond), r.Retries)
+	if r.Error != "" {
+		b = fmt.Appendf(b, " error=%q", r.Error)
+	}
+	a.write(append(b, '\n'))
+}
+
+// LogSession 记录一次服务端客户端会话
+func (a *AccessLog) LogSession(r SessionRecord) {
+	if a == nil {
+		return
+	}
+	if a.format == AccessFormatJSON {
+		a.writeJSON(struct {
+			Time       string `json:"time"`
+			Type       string `json:"type"`
+			ClientID   string `json:"client_id"`
+			RemoteAddr string `json:"remote_addr"`
+			BytesIn    int64  `json:"bytes_in"`
+			BytesOut   int64  `json:"bytes_out"`
+			DurationMS int64  `json:"duration_ms"`
+		}{
+			Time:       r.Time.Format(time.RFC3339Nano),
+			Type:       "session",
+			ClientID:   r.ClientID,
+			RemoteAddr: r.RemoteAddr,
+			BytesIn:    r.BytesIn,
+			BytesOut:   r.BytesOut,
+			DurationMS: r.Duration.Milliseconds(),
+		})
+		return
+	}
+
+	// 客户端地址 - 连接ID [时间] "SESSION quic" - 写出字节数 "-" "-" bytes_in=... duration=...
+	var b []byte
+	b = append(b, dash(r.RemoteAddr)...)
+	b = append(b, " - "...)
+	b = append(b, dash(r.ClientID)...)
+	b = append(b, ' ')
+	b = appendCombinedTime(b, r.Time)
+	b = fmt.Appendf(b, " \"SESSION quic\" - %d \"-\" \"-\" bytes_in=%d duration=%s", r.BytesOut, r.BytesIn, r.Duration.Round(time.Millisecond))
+	a.write(append(b, '\n'))
+}
+
+// Close 关闭底层文件
+func (a *AccessLog) Close() error {
+	if a == nil || a.closer == nil {
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.closer.Close()
+}
+
+func (a *AccessLog) writeJSON(record interface{}) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return
+	}
+	a.write(append(data, '\n'))
+}
+
+// write 写入一行，写入失败时丢弃
+func (a *AccessLog) write(line []byte) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.w.Write(line)
+}
+
+// appendCombinedTime 追加 combined 格式的时间：[02/Jan/2006:15:04:05 -0700]
+func appendCombinedTime(b []byte, t time.Time) []byte {
+	b = append(b, '[')
+	b = t.AppendFormat(b, "02/Jan/2006:15:04:05 -0700")
+	return append(b, ']')
+}
+
+// dash 空值在 combined 格式中写为 -
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func statusText(status int) string {
+	if status == 0 {
+		return "-"
+	}
+	return strconv.Itoa(status)
+}
@@ -0,0 +1,376 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package logging 实现系统日志：文本/JSON 格式、调用位置、同时输出到多个目标，以及按大小轮转的日志文件
+package logging
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Level 日志级别
+type Level int32
+
+const (
+	LevelDebug Level = iota + 1
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+var levelNames = map[Level]string{
+	LevelDebug: "DEBUG",
+	LevelInfo:  "INFO",
+	LevelWarn:  "WARN",
+	LevelError: "ERROR",
+}
+
+var levelColors = map[Level]string{
+	LevelDebug: "\033[34m",
+	LevelInfo:  "\033[32m",
+	LevelWarn:  "\033[33m",
+	LevelError: "\033[31m",
+}
+
+const resetColor = "\033[0m"
+
+func (l Level) String() string {
+	if name, ok := levelNames[l]; ok {
+		return name
+	}
+	return "LEVEL(" + strconv.Itoa(int(l)) + ")"
+}
+
+// ParseLevel 将配置中的日志级别字符串转换为 Level，未知级别按 info 处理
+func ParseLevel(level string) Level {
+	switch strings.ToLower(level) {
+	case "debug":
+		return LevelDebug
+	case "warn":
+		return LevelWarn
+	case "error":
+		return LevelError
+	default:
+		return LevelInfo
+	}
+}
+
+// ParseLevels 将按模块名配置的日志级别字符串转换为 Level
+func ParseLevels(levels map[string]string) map[string]Level {
+	parsed := make(map[string]Level, len(levels))
+	for name, level := range levels {
+		parsed[name] = ParseLevel(level)
+	}
+	return parsed
+}
+
+// Format 日志格式
+type Format int
+
+const (
+	FormatText Format = iota // 2006-01-02 15:04:05.000  INFO  [调用位置]  消息
+	FormatJSON               // 每行一个 JSON 对象
+)
+
+// ParseFormat 将配置中的日志格式字符串转换为 Format，未知格式按 text 处理
+func ParseFormat(format string) Format {
+	if strings.EqualFold(format, "json") {
+		return FormatJSON
+	}
+	return FormatText
+}
+
+// Output 日志输出目标
+type Output struct {
+	Name   string    // 显示名称，如 stdout 或文件路径
+	Writer io.Writer // 写入目标，写入失败的日志被丢弃
+	Closer io.Closer // 不为 nil 时由 Logger.Close 关闭
+	Color  bool      // 文本格式下是否为日志级别着色
+}
+
+// Logger 日志器，可并发使用
+//
+// 每条日志按同一格式编码后依次写入所有输出目标，某个目标写入失败不影响其他目标。
+// Named 和 With 派生的子日志器共享输出目标和级别设置，只是附带不同的字段。
+type Logger struct {
+	core   *core
+	name   string  // 模块名，非空时日志附带 module=<name>，并按该名称查找级别覆盖
+	fields []field // With 附加的字段
+}
+
+// field 日志附带的键值字段
+type field struct {
+	key   string
+	value interface{}
+}
+
+// core 同一棵日志器树共享的状态
+type core struct {
+	level        atomic.Int32                     // 默认最低输出级别
+	moduleLevels atomic.Pointer[map[string]Level] // 按模块名覆盖的级别
+	format       Format
+	showCaller   bool
+
+	mu      sync.Mutex // 串行化写入，保证多个目标中的日志顺序一致
+	outputs []Output
+	closed  bool
+}
+
+// New 创建日志器
+func New(level Level, format Format, showCaller bool, outputs ...Output) *Logger {
+	c := &core{format: format, showCaller: showCaller, outputs: outputs}
+	c.level.Store(int32(level))
+	return &Logger{core: c}
+}
+
+// Discard 返回丢弃所有日志的日志器，用于不需要输出日志的场景
+func Discard() *Logger {
+	return New(LevelError, FormatText, false)
+}
+
+// Named 返回指定模块的子日志器
+//
+// 子日志器的日志附带 module=<name>，级别优先使用 SetModuleLevels 中该模块的设置。
+func (l *Logger) Named(name string) *Logger {
+	return &Logger{core: l.core, name: name, fields: l.fields}
+}
+
+// With 返回附加了一个字段的子日志器
+func (l *Logger) With(key string, value interface{}) *Logger {
+	fields := make([]field, len(l.fields), len(l.fields)+1)
+	copy(fields, l.fields)
+	return &Logger{core: l.core, name: l.name, fields: append(fields, field{key: key, value: value})}
+}
+
+// Name 返回模块名，根日志器返回空字符串
+func (l *Logger) Name() string {
+	return l.name
+}
+
+// SetLevel 设置默认最低输出级别，对整棵日志器树中没有模块级别覆盖的日志器生效
+func (l *Logger) SetLevel(level Level) {
+	l.core.level.Store(int32(level))
+}
+
+// SetModuleLevels 整体替换按模块名覆盖的级别，未列出的模块使用默认级别
+func (l *Logger) SetModuleLevels(levels map[string]Level) {
+	copied := make(map[string]Level, len(levels))
+	for name, level := range levels {
+		copied[name] = level
+	}
+	l.core.moduleLevels.Store(&copied)
+}
+
+// Level 返回该日志器当前生效的最低输出级别
+func (l *Logger) Level() Level {
+	if l.name != "" {
+		if levels := l.core.moduleLevels.Load(); levels != nil {
+			if level, ok := (*levels)[l.name]; ok {
+				return level
+			}
+		}
+	}
+	return Level(l.core.level.Load())
+}
+
+// Outputs 返回输出目标的名称
+func (l *Logger) Outputs() []string {
+	c := l.core
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	names := make([]string, len(c.outputs))
+	for i, o := range c.outputs {
+		names[i] = o.Name
+	}
+	return names
+}
+
+// Debug 输出调试级别的日志
+func (l *Logger) Debug(format string, args ...interface{}) {
+	l.log(LevelDebug, format, args...)
+}
+
+// Info 输出信息级别的日志
+func (l *Logger) Info(format string, args ...interface{}) {
+	l.log(LevelInfo, format, args...)
+}
+
+// Warn 输出警告级别的日志
+func (l *Logger) Warn(format string, args ...interface{}) {
+	l.log(LevelWarn, format, args...)
+}
+
+// Error 输出错误级别的日志
+func (l *Logger) Error(format string, args ...interface{}) {
+	l.log(LevelError, format, args...)
+}
+
+// Close 关闭设置了 Closer 的输出目标（如日志文件），之后只写入其余目标
+//
+// 整棵日志器树共享输出目标，通过任意一个日志器关闭效果相同。
+//
+// 输出目标在锁外关闭：关闭过程中（如日志文件等待后台压缩）写出的日志进入其余目标，不会死锁。
+func (l *Logger) Close() error {
+	c := l.core
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	var closing []Output
+	kept := c.outputs[:0:0]
+	for _, o := range c.outputs {
+		if o.Closer == nil {
+			kept = append(kept, o)
+		} else {
+			closing = append(closing, o)
+		}
+	}
+	c.outputs = kept
+	c.mu.Unlock()
+
+	var errs []error
+	for _, o := range closing {
+		if err := o.Closer.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("关闭日志输出 %s 失败: %w", o.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// log 编码并写入一条日志；调用位置取调用 Debug/Info/Warn/Error 的代码
+func (l *Logger) log(level Level, format string, args ...interface{}) {
+	if level < l.Level() {
+		return
+	}
+	c := l.core
+	e := entry{time: time.Now(), level: level, module: l.name, fields: l.fields, msg: fmt.Sprintf(format, args...)}
+	if c.showCaller {
+		if _, file, line, ok := runtime.Caller(2); ok {
+			e.caller = filepath.Base(filepath.Dir(file)) + "/" + filepath.Base(file) + ":" + strconv.Itoa(line)
+		}
+	}
+
+	var plain, colored []byte
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, o := range c.outputs {
+		var line []byte
+		if o.Color && c.format == FormatText {
+			if colored == nil {
+				colored = e.appendText(nil, true)
+			}
+			line = colored
+		} else {
+			if plain == nil {
+				plain = c.encode(e)
+			}
+			line = plain
+		}
+		o.Writer.Write(line) // 写入失败时丢弃，不影响其他目标
+	}
+}
+
+func (c *core) encode(e entry) []byte {
+	if c.format == FormatJSON {
+		return e.appendJSON(nil)
+	}
+	return e.appendText(nil, false)
+}
+
+// entry 一条日志
+type entry struct {
+	time   time.Time
+	level  Level
+	caller string
+	module string
+	fields []field
+	msg    string
+}
+
+const textTimeFormat = "2006-01-02 15:04:05.000"
+
+// appendText 编码为 "时间  级别  [调用位置]  [module=模块 键=值]  消息"
+func (e entry) appendText(b []byte, color bool) []byte {
+	b = e.time.AppendFormat(b, textTimeFormat)
+	b = append(b, "  "...)
+	if color {
+		b = append(b, levelColors[e.level]...)
+		b = append(b, e.level.String()...)
+		b = append(b, resetColor...)
+	} else {
+		b = append(b, e.level.String()...)
+	}
+	b = append(b, "  "...)
+	if e.caller != "" {
+		b = append(b, e.caller...)
+		b = append(b, "  "...)
+	}
+	if e.module != "" || len(e.fields) > 0 {
+		sep := false
+		if e.module != "" {
+			b = append(b, "module="...)
+			b = append(b, e.module...)
+			sep = true
+		}
+		for _, f := range e.fields {
+			if sep {
+				b = append(b, ' ')
+			}
+			b = append(b, f.key...)
+			b = append(b, '=')
+			b = fmt.Append(b, f.value)
+			sep = true
+		}
+		b = append(b, "  "...)
+	}
+	b = append(b, e.msg...)
+	return append(b, '\n')
+}
+
+// appendJSON 编码为一行 JSON，键依次为 time、level、caller、module、附加字段和 msg
+func (e entry) appendJSON(b []byte) []byte {
+	b = append(b, '{')
+	b = appendJSONMember(b, "time", e.time.Format(time.RFC3339Nano))
+	b = append(b, ',')
+	b = appendJSONMember(b, "level", strings.ToLower(e.level.String()))
+	if e.caller != "" {
+		b = append(b, ',')
+		b = appendJSONMember(b, "caller", e.caller)
+	}
+	if e.module != "" {
+		b = append(b, ',')
+		b = appendJSONMember(b, "module", e.module)
+	}
+	for _, f := range e.fields {
+		b = append(b, ',')
+		b = appendJSONMember(b, f.key, f.value)
+	}
+	b = append(b, ',')
+	b = appendJSONMember(b, "msg", e.msg)
+	return append(b, '}', '\n')
+}
+
+// appendJSONMember 追加 "key":value，值无法编码为 JSON 时按 fmt.Sprint 的结果编码为字符串
+func appendJSONMember(b []byte, key string, value interface{}) []byte {
+	k, _ := json.Marshal(key)
+	b = append(b, k...)
+	b = append(b, ':')
+	v, err := json.Marshal(value)
+	if err != nil {
+		v, _ = json.Marshal(fmt.Sprint(value))
+	}
+	return append(b, v...)
+}
@@ -0,0 +1,36 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package logging
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// OpenOutput 打开日志输出路径：空或 stdout 为标准输出，stderr 为标准错误，其他值为追加写入的文件
+//
+// 标准输出和标准错误是终端时，文本格式的日志级别着色。
+func OpenOutput(path string) (Output, error) {
+	switch path {
+	case "", "stdout":
+		return Output{Name: "stdout", Writer: os.Stdout, Color: isTerminal(os.Stdout)}, nil
+	case "stderr":
+		return Output{Name: "stderr", Writer: os.Stderr, Color: isTerminal(os.Stderr)}, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return Output{}, fmt.Errorf("创建日志目录失败: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return Output{}, fmt.Errorf("打开日志输出 %s 失败: %w", path, err)
+	}
+	return Output{Name: path, Writer: file, Closer: file}, nil
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
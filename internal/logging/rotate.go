@@ -0,0 +1,318 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package logging
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeFormat 轮转文件名中的时间格式，按字典序即按时间排序
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// RotatingFile 按大小轮转的日志文件，实现 io.WriteCloser
+//
+// 当前文件写满 maxSize 后重命名为 <名称>-<时间><扩展名>（同一时间已有轮转文件时为 <名称>-<时间>-<序号><扩展名>），
+// 再创建新文件；新文件打开失败时下次写入重试。
+// 开启压缩时轮转出的文件在后台压缩为 .gz。只保留最新的 maxBackups 个轮转文件（0 表示全部保留）。
+// 后台压缩和清理的错误交给 SetErrorHandler 设置的函数，未设置时由 Close 返回。
+type RotatingFile struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+	compress   bool
+
+	mu       sync.Mutex
+	file     *os.File // 轮转后重新打开失败时为 nil，下次写入重试
+	size     int64
+	closed   bool
+	onError  func(error)
+	millErrs []error // 未设置 onError 时后台处理的错误，由 Close 返回
+
+	millMu sync.Mutex     // 串行化压缩和清理
+	mills  sync.WaitGroup // 后台压缩和清理
+}
+
+// OpenRotatingFile 打开（必要时创建）日志文件，maxSizeMB 为单个文件的最大大小（MB）
+func OpenRotatingFile(path string, maxSizeMB, maxBackups int, compress bool) (*RotatingFile, error) {
+	if maxSizeMB <= 0 {
+		return nil, fmt.Errorf("日志文件最大大小必须大于0: %d", maxSizeMB)
+	}
+	f := &RotatingFile{
+		path:       path,
+		maxSize:    int64(maxSizeMB) << 20,
+		maxBackups: maxBackups,
+		compress:   compress,
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// SetErrorHandler 设置后台压缩和清理出错时调用的函数，fn 在后台 goroutine 中调用
+func (f *RotatingFile) SetErrorHandler(fn func(error)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.onError = fn
+}
+
+// open 以追加方式打开当前文件
+func (f *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(f.path), 0755); err != nil {
+		return fmt.Errorf("创建日志目录失败: %w", err)
+	}
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("打开日志文件失败: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("读取日志文件信息失败: %w", err)
+	}
+	f.file, f.size = file, info.Size()
+	return nil
+}
+
+// Write 写入日志，写入后超过最大大小时先轮转
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.ensureOpen(); err != nil {
+		return 0, err
+	}
+	if f.size > 0 && f.size+int64(len(p)) > f.maxSize {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Rotate 立即轮转当前文件
+func (f *RotatingFile) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.ensureOpen(); err != nil {
+		return err
+	}
+	return f.rotate()
+}
+
+// ensureOpen 已关闭时返回 os.ErrClosed；上次轮转后未能重新打开当前文件时重试
+func (f *RotatingFile) ensureOpen() error {
+	if f.closed {
+		return os.ErrClosed
+	}
+	if f.file == nil {
+		return f.open()
+	}
+	return nil
+}
+
+// rotate 将当前文件重命名为轮转文件并打开新文件；任何一步失败时 file 为 nil，下次写入时重新打开
+func (f *RotatingFile) rotate() error {
+	err := f.file.Close()
+	f.file = nil
+	if err != nil {
+		return fmt.Errorf("关闭日志文件失败: %w", err)
+	}
+
+	backup := f.backupName(time.Now())
+	if err := os.Rename(f.path, backup); err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("轮转日志文件失败: %w", err)
+		}
+	} else {
+		f.mills.Add(1)
+		go func() {
+			defer f.mills.Done()
+			f.mill(backup)
+		}()
+	}
+	return f.open()
+}
+
+// Close 关闭当前文件，并等待后台压缩和清理结束
+//
+// 未设置错误处理函数时，返回值包含后台压缩和清理的错误。
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	var err error
+	if f.file != nil {
+		err = f.file.Close()
+		f.file = nil
+	}
+	f.closed = true
+	f.mu.Unlock()
+	f.mills.Wait()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return errors.Join(append([]error{err}, f.millErrs...)...)
+}
+
+// backupName 返回不与已有轮转文件（含已压缩的）重名的轮转文件名：
+// <名称>-<时间><扩展名>，已存在时为 <名称>-<时间>-<序号><扩展名>
+func (f *RotatingFile) backupName(t time.Time) string {
+	dir, base := filepath.Split(f.path)
+	ext := filepath.Ext(base)
+	stamp := strings.TrimSuffix(base, ext) + "-" + t.Format(backupTimeFormat)
+	name := filepath.Join(dir, stamp+ext)
+	for seq := 1; exists(name) || exists(name+".gz"); seq++ {
+		name = filepath.Join(dir, stamp+"-"+strconv.Itoa(seq)+ext)
+	}
+	return name
+}
+
+func exists(name string) bool {
+	_, err := os.Lstat(name)
+	return err == nil
+}
+
+// mill 压缩刚轮转出的文件并删除多余的轮转文件；失败时保留文件，不影响日志写入，错误交给 reportError
+func (f *RotatingFile) mill(backup string) {
+	f.millMu.Lock()
+	defer f.millMu.Unlock()
+	var errs []error
+	if f.compress {
+		if err := compressFile(backup); err != nil {
+			errs = append(errs, fmt.Errorf("压缩日志文件 %s 失败: %w", backup, err))
+		}
+	}
+	if f.maxBackups > 0 {
+		backups, err := f.backups()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("列出轮转日志文件失败: %w", err))
+		}
+		for _, name := range backups[min(f.maxBackups, len(backups)):] {
+			if err := os.Remove(name); err != nil {
+				errs = append(errs, fmt.Errorf("删除轮转日志文件失败: %w", err))
+			}
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		f.reportError(err)
+	}
+}
+
+// reportError 将后台处理的错误交给错误处理函数，未设置时留给 Close 返回
+func (f *RotatingFile) reportError(err error) {
+	f.mu.Lock()
+	fn := f.onError
+	if fn == nil {
+		f.millErrs = append(f.millErrs, err)
+	}
+	f.mu.Unlock()
+	if fn != nil {
+		fn(err)
+	}
+}
+
+// backupTime 解析轮转文件名（不含目录、.gz 和扩展名）中的时间和序号，不是轮转文件时 ok 为 false
+func backupTime(stamp string) (t time.Time, seq int, ok bool) {
+	if t, err := time.Parse(backupTimeFormat, stamp); err == nil {
+		return t, 0, true
+	}
+	i := strings.LastIndexByte(stamp, '-')
+	if i < 0 {
+		return time.Time{}, 0, false
+	}
+	seq, err := strconv.Atoi(stamp[i+1:])
+	if err != nil || seq <= 0 {
+		return time.Time{}, 0, false
+	}
+	t, err = time.Parse(backupTimeFormat, stamp[:i])
+	if err != nil {
+		return time.Time{}, 0, false
+	}
+	return t, seq, true
+}
+
+// backups 返回所有轮转文件（含已压缩的），最新的在前
+func (f *RotatingFile) backups() ([]string, error) {
+	dir, base := filepath.Split(f.path)
+	if dir == "" {
+		dir = "."
+	}
+	ext := filepath.Ext(base)
+	prefix := strings.TrimSuffix(base, ext) + "-"
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	type backup struct {
+		name string
+		time time.Time
+		seq  int
+	}
+	var list []backup
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		stamp := strings.TrimSuffix(strings.TrimSuffix(name, ".gz"), ext)
+		t, seq, ok := backupTime(strings.TrimPrefix(stamp, prefix))
+		if !ok {
+			continue
+		}
+		list = append(list, backup{filepath.Join(dir, name), t, seq})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].time.Equal(list[j].time) {
+			return list[i].time.After(list[j].time)
+		}
+		return list[i].seq > list[j].seq
+	})
+	names := make([]string, len(list))
+	for i, b := range list {
+		names[i] = b.name
+	}
+	return names, nil
+}
+
+// compressFile 将文件压缩为 <文件>.gz 并删除原文件
+func compressFile(name string) error {
+	src, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(name+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(dst)
+	if _, err := io.Copy(gz, src); err != nil {
+		dst.Close()
+		os.Remove(name + ".gz")
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		dst.Close()
+		os.Remove(name + ".gz")
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(name + ".gz")
+		return err
+	}
+	src.Close()
+	return os.Remove(name)
+}
@@ -0,0 +1,117 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package logging
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRotatingFileBackupNamesAreUnique(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	f, err := OpenRotatingFile(path, 1, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	// 连续轮转通常落在同一毫秒内
+	const rotations = 5
+	for i := range rotations {
+		if _, err := f.Write([]byte{byte('a' + i)}); err != nil {
+			t.Fatal(err)
+		}
+		if err := f.Rotate(); err != nil {
+			t.Fatalf("Rotate: %v", err)
+		}
+	}
+	f.mills.Wait()
+
+	backups, err := f.backups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != rotations {
+		t.Fatalf("%d 个轮转文件，want %d: %v", len(backups), rotations, backups)
+	}
+	// 最新的在前：第 i 个轮转文件的内容是第 rotations-1-i 次写入
+	for i, name := range backups {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := string(rune('a' + rotations - 1 - i)); string(data) != want {
+			t.Errorf("%s 内容 %q，want %q", filepath.Base(name), data, want)
+		}
+	}
+}
+
+func TestRotatingFileRetriesOpenAfterFailure(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "logs")
+	path := filepath.Join(dir, "app.log")
+	f, err := OpenRotatingFile(path, 1, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	// 模拟轮转后重新打开失败：当前文件已关闭，日志目录被同名文件占用
+	f.mu.Lock()
+	f.file.Close()
+	f.file = nil
+	f.mu.Unlock()
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dir, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte("lost\n")); err == nil {
+		t.Fatal("日志目录不可用时 Write 应返回错误")
+	}
+
+	if err := os.Remove(dir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte("ok\n")); err != nil {
+		t.Fatalf("日志目录恢复后 Write 仍失败: %v", err)
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != "ok\n" {
+		t.Fatalf("日志文件内容 %q (%v)，want %q", data, err, "ok\n")
+	}
+}
+
+func TestRotatingFileMillErrors(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "app-missing.log")
+
+	t.Run("handler", func(t *testing.T) {
+		f, err := OpenRotatingFile(filepath.Join(t.TempDir(), "app.log"), 1, 0, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []error
+		f.SetErrorHandler(func(err error) { got = append(got, err) })
+		f.mill(missing)
+		if len(got) != 1 || !errors.Is(got[0], os.ErrNotExist) {
+			t.Fatalf("错误处理函数收到 %v，want 一个压缩失败的错误", got)
+		}
+		if err := f.Close(); err != nil {
+			t.Fatalf("已交给错误处理函数的错误不应再由 Close 返回: %v", err)
+		}
+	})
+
+	t.Run("close", func(t *testing.T) {
+		f, err := OpenRotatingFile(filepath.Join(t.TempDir(), "app.log"), 1, 0, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.mill(missing)
+		if err := f.Close(); !errors.Is(err, os.ErrNotExist) {
+			t.Fatalf("Close 返回 %v，want 包含压缩失败的错误", err)
+		}
+	})
+}
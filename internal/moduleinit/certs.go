@@ -6,60 +6,145 @@ package moduleinit
 
 import (
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/vistone/certs"
 	"github.com/vistone/crawler-system/internal/config"
 )
 
+// CertManager 证书管理器，auto_renewal 时定期续期即将过期的证书
+type CertManager struct {
+	*certs.Manager
+
+	autoRenewal   bool
+	checkInterval time.Duration
+	renewBefore   time.Duration
+	logger        Logger
+
+	mu   sync.Mutex
+	stop chan struct{}
+	done chan struct{}
+}
+
 // InitCerts 初始化证书模块（模块5）
-func InitCerts(cfg *config.CertificateConfig, logger interface{ Info(string, ...interface{}) }) (*certs.Manager, error) {
-	// 输出初始化详细信息
-	fmt.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
-	fmt.Printf("🔒 [模块5] 证书模块初始化\n")
-	fmt.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
-	fmt.Printf("  ✓ 服务端域名: %s\n", cfg.ServerDomain)
-	fmt.Printf("  ✓ 证书存储路径: %s\n", cfg.CertStoragePath)
-	fmt.Printf("  ✓ 证书提供商: %s\n", cfg.Provider)
-	fmt.Printf("  ✓ 自动续期: %v\n", cfg.AutoRenewal)
+func InitCerts(cfg *config.CertificateConfig, logger Logger) (*CertManager, *StartupReport, error) {
+	report := NewStartupReport("certs", "证书模块")
+	report.Set("server_domain", "服务端域名", cfg.ServerDomain)
+	report.Set("cert_storage_path", "证书存储路径", cfg.CertStoragePath)
+	report.Set("provider", "证书提供商", cfg.Provider)
+	report.Set("auto_renewal", "自动续期", cfg.AutoRenewal)
 	if cfg.AutoRenewal {
-		fmt.Printf("  ✓ 续期检查间隔: %d 小时\n", cfg.RenewalCheckInterval)
-		fmt.Printf("  ✓ 提前续期天数: %d 天\n", cfg.RenewalBeforeDays)
-	}
-	if cfg.Provider == "letsencrypt" {
-		fmt.Printf("  ✓ Let's Encrypt邮箱: %s\n", cfg.LetsEncryptEmail)
-		fmt.Printf("  ✓ Let's Encrypt环境: %s\n", cfg.LetsEncryptEnvironment)
-	} else if cfg.Provider == "self-signed" {
-		fmt.Printf("  ✓ 自签名证书有效期: %d 天\n", cfg.SelfSignedValidityDays)
+		report.Set("renewal_check_interval", "续期检查间隔", cfg.RenewalCheckInterval)
+		report.Set("renewal_before_days", "提前续期", cfg.RenewalBeforeDays)
 	}
-	fmt.Printf("  ✓ 自动检测本地IP: %v\n", cfg.AutoDetectLocalIP)
-	fmt.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
+	report.Set("self_signed_validity_days", "自签名证书有效期", cfg.SelfSignedValidityDays)
+	report.Set("auto_detect_local_ip", "自动检测本地IP", cfg.AutoDetectLocalIP)
 
-	// 创建证书配置（使用默认配置，后续根据实际API调整）
 	certConfig := certs.DefaultConfig()
-	// 注意：certs.Config的实际字段可能不同，这里先使用默认配置
-	// 后续需要根据实际API调整配置方式
-	_ = cfg // 暂时忽略配置，使用默认值
+	certConfig.StorageDir = cfg.CertStoragePath
+	certConfig.DefaultProvider = certProvider(cfg.Provider, report)
+	certConfig.AutoDetectIP = cfg.AutoDetectLocalIP
+	certConfig.DefaultValidityDays = cfg.SelfSignedValidityDays.Days()
 
 	manager, err := certs.NewManager(certConfig)
 	if err != nil {
-		return nil, fmt.Errorf("创建证书管理器失败: %w", err)
+		return nil, nil, fmt.Errorf("创建证书管理器失败: %w", err)
 	}
 
 	// 尝试获取证书信息（如果已存在）
 	cert, err := manager.GetOrRequestCertificate(cfg.ServerDomain)
 	if err == nil && cert != nil {
-		fmt.Printf("  📄 证书信息:\n")
-		fmt.Printf("    - 域名: %s\n", cfg.ServerDomain)
-		fmt.Printf("    - 证书已获取\n")
+		report.Detect("certificate", "服务端证书", "已获取")
+		report.Detect("expires_at", "证书过期时间", cert.ExpiresAt.Format(time.DateTime))
 	} else {
-		fmt.Printf("  ⚠️  证书尚未申请，将在首次使用时自动申请\n")
+		report.Detect("certificate", "服务端证书", "未申请")
+		report.Note("证书尚未申请，将在首次使用时自动申请")
+	}
+
+	cm := &CertManager{
+		Manager:       manager,
+		autoRenewal:   cfg.AutoRenewal,
+		checkInterval: cfg.RenewalCheckInterval.Duration(),
+		renewBefore:   cfg.RenewalBeforeDays.Duration(),
+		logger:        logger,
+	}
+	return cm, report, nil
+}
+
+// certProvider 返回证书库中 provider 对应的 CA 提供商名称
+//
+// 证书库尚未实现 Let's Encrypt，选择 letsencrypt 时使用自签名证书并在启动报告中警告。
+func certProvider(provider string, report *StartupReport) string {
+	if provider == "letsencrypt" {
+		report.Warn("证书库暂不支持 Let's Encrypt，使用自签名证书")
+	}
+	return "selfsigned"
+}
+
+// Start 启动自动续期，未启用 auto_renewal 时不做任何事
+func (cm *CertManager) Start() {
+	if !cm.autoRenewal {
+		return
+	}
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	if cm.stop != nil {
+		return
 	}
-	fmt.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
+	cm.stop = make(chan struct{})
+	cm.done = make(chan struct{})
+	go cm.renewLoop(cm.stop, cm.done)
+}
 
-	if logger != nil {
-		logger.Info("证书模块初始化完成，provider=%s, domain=%s, auto_renewal=%v",
-			cfg.Provider, cfg.ServerDomain, cfg.AutoRenewal)
+// Stop 停止自动续期并等待正在进行的续期结束
+func (cm *CertManager) Stop() {
+	cm.mu.Lock()
+	stop, done := cm.stop, cm.done
+	cm.stop, cm.done = nil, nil
+	cm.mu.Unlock()
+	if stop == nil {
+		return
 	}
-	return manager, nil
+	close(stop)
+	<-done
 }
 
+// renewLoop 启动时及每隔 renewal_check_interval 检查一次证书
+func (cm *CertManager) renewLoop(stop, done chan struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(cm.checkInterval)
+	defer ticker.Stop()
+	for {
+		cm.RenewExpiring()
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RenewExpiring 续期已过期或将在 renewal_before_days 内过期的证书，返回续期成功的数量
+func (cm *CertManager) RenewExpiring() int {
+	list, err := cm.ListCertificates()
+	if err != nil {
+		cm.logger.Error("列出证书失败: %v", err)
+		return 0
+	}
+	deadline := time.Now().Add(cm.renewBefore)
+	renewed := 0
+	for _, cert := range list {
+		if cert.ExpiresAt.After(deadline) {
+			continue
+		}
+		newCert, err := cm.RenewCertificate(cert)
+		if err != nil {
+			cm.logger.Error("续期证书 %s 失败: %v", cert.Domain, err)
+			continue
+		}
+		renewed++
+		cm.logger.Info("证书 %s 已续期，新的过期时间 %s", newCert.Domain, newCert.ExpiresAt.Format(time.DateTime))
+	}
+	return renewed
+}
@@ -5,44 +5,137 @@
 package moduleinit
 
 import (
-	"fmt"
+	"context"
+	"net"
+	"sync"
+	"time"
 
 	"github.com/vistone/crawler-system/internal/config"
 )
 
-// ConnManager 连接管理器
+// ConnManager 连接管理器，限制每个目标主机的并发连接数并为连接设置读写超时
 type ConnManager struct {
 	Config *config.ConnConfig
+	logger Logger
+
+	mu    sync.Mutex
+	hosts map[string]*hostSlots
+}
+
+// hostSlots 一个目标主机的连接名额，refs 为占用或等待名额的请求数，为0时删除
+type hostSlots struct {
+	slots chan struct{}
+	refs  int
 }
 
 // InitConn 初始化连接模块（模块7）
-func InitConn(cfg *config.ConnConfig, logger interface{ Info(string, ...interface{}) }) (*ConnManager, error) {
-	// 输出初始化详细信息
-	fmt.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
-	fmt.Printf("🔌 [模块7] 连接模块初始化\n")
-	fmt.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
-	fmt.Printf("  ✓ 连接超时: %d 秒\n", cfg.ConnectTimeout)
-	fmt.Printf("  ✓ 读取超时: %d 秒\n", cfg.ReadTimeout)
-	fmt.Printf("  ✓ 写入超时: %d 秒\n", cfg.WriteTimeout)
-	fmt.Printf("  ✓ Keep-Alive: %v\n", cfg.KeepAlive)
+func InitConn(cfg *config.ConnConfig, logger Logger) (*ConnManager, *StartupReport, error) {
+	report := NewStartupReport("conn", "连接模块")
+	report.Set("connect_timeout", "连接超时", cfg.ConnectTimeout)
+	report.Set("read_timeout", "读取超时", cfg.ReadTimeout)
+	report.Set("write_timeout", "写入超时", cfg.WriteTimeout)
+	report.Set("keep_alive", "Keep-Alive", cfg.KeepAlive)
 	if cfg.KeepAlive {
-		fmt.Printf("  ✓ Keep-Alive时间: %d 秒\n", cfg.KeepAliveTime)
+		report.Set("keep_alive_time", "Keep-Alive时间", cfg.KeepAliveTime)
 	}
-	fmt.Printf("  ✓ 最大空闲连接数: %d\n", cfg.MaxIdleConns)
-	fmt.Printf("  ✓ 每个主机最大连接数: %d\n", cfg.MaxConnsPerHost)
-	fmt.Printf("  ✓ TLS握手超时: %d 秒\n", cfg.TLSHandshakeTimeout)
-	fmt.Printf("  ✓ 跳过TLS验证: %v\n", cfg.InsecureSkipVerify)
+	report.Set("max_idle_conns", "最大空闲连接数", cfg.MaxIdleConns)
+	report.Set("max_conns_per_host", "每个主机最大连接数", cfg.MaxConnsPerHost)
+	report.Set("tls_handshake_timeout", "TLS握手超时", cfg.TLSHandshakeTimeout)
+	report.Set("insecure_skip_verify", "跳过TLS验证", cfg.InsecureSkipVerify)
 	if cfg.InsecureSkipVerify {
-		fmt.Printf("    ⚠️  警告: TLS证书验证已禁用（仅用于测试）\n")
+		report.Warn("TLS证书验证已禁用（仅用于测试）")
 	}
-	fmt.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
 
 	cm := &ConnManager{
 		Config: cfg,
+		logger: logger,
+		hosts:  make(map[string]*hostSlots),
+	}
+	return cm, report, nil
+}
+
+// Acquire 占用目标主机的一个连接名额，已有 max_conns_per_host 个连接时等待，返回释放名额的函数
+func (cm *ConnManager) Acquire(ctx context.Context, host string) (release func(), err error) {
+	cm.mu.Lock()
+	h := cm.hosts[host]
+	if h == nil {
+		h = &hostSlots{slots: make(chan struct{}, cm.Config.MaxConnsPerHost)}
+		cm.hosts[host] = h
+	}
+	h.refs++
+	cm.mu.Unlock()
+
+	done := func() {
+		cm.mu.Lock()
+		if h.refs--; h.refs == 0 {
+			delete(cm.hosts, host)
+		}
+		cm.mu.Unlock()
+	}
+	release = func() {
+		<-h.slots
+		done()
+	}
+	select {
+	case h.slots <- struct{}{}:
+		return release, nil
+	default:
+	}
+	cm.logger.Debug("目标主机 %s 的连接数已达 max_conns_per_host (%d)，等待空闲名额", host, cap(h.slots))
+	select {
+	case h.slots <- struct{}{}:
+		return release, nil
+	case <-ctx.Done():
+		done()
+		return nil, ctx.Err()
+	}
+}
+
+// WrapConn 为连接的每次读写分别设置 read_timeout 和 write_timeout，deadline 不为零时不晚于 deadline
+func (cm *ConnManager) WrapConn(conn net.Conn, deadline time.Time) *TimeoutConn {
+	return &TimeoutConn{
+		Conn:         conn,
+		readTimeout:  cm.Config.ReadTimeout.Duration(),
+		writeTimeout: cm.Config.WriteTimeout.Duration(),
+		deadline:     deadline,
 	}
+}
+
+// TimeoutConn 每次读写前设置超时的连接
+type TimeoutConn struct {
+	net.Conn
+	readTimeout  time.Duration
+	writeTimeout time.Duration
+	deadline     time.Time
+}
+
+func (c *TimeoutConn) Read(p []byte) (int, error) {
+	if err := c.Conn.SetReadDeadline(c.opDeadline(c.readTimeout)); err != nil {
+		return 0, err
+	}
+	return c.Conn.Read(p)
+}
 
-	if logger != nil {
-		logger.Info("连接模块初始化完成，connect_timeout=%d, read_timeout=%d", cfg.ConnectTimeout, cfg.ReadTimeout)
+func (c *TimeoutConn) Write(p []byte) (int, error) {
+	if err := c.Conn.SetWriteDeadline(c.opDeadline(c.writeTimeout)); err != nil {
+		return 0, err
+	}
+	return c.Conn.Write(p)
+}
+
+// SetRequestDeadline 修改读写截止时间的上限，连接被下一个请求复用时调用，零值表示没有上限
+func (c *TimeoutConn) SetRequestDeadline(deadline time.Time) {
+	c.deadline = deadline
+}
+
+// opDeadline 返回一次读写的截止时间
+func (c *TimeoutConn) opDeadline(timeout time.Duration) time.Time {
+	if timeout <= 0 {
+		return c.deadline
+	}
+	t := time.Now().Add(timeout)
+	if !c.deadline.IsZero() && c.deadline.Before(t) {
+		return c.deadline
 	}
-	return cm, nil
+	return t
 }
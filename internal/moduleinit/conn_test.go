@@ -0,0 +1,96 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package moduleinit
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vistone/crawler-system/internal/config"
+)
+
+// recordingLogger 记录写入的日志
+type recordingLogger struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (l *recordingLogger) record(level, format string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = append(l.lines, level+" "+fmt.Sprintf(format, args...))
+}
+
+func (l *recordingLogger) Debug(format string, args ...interface{}) {
+	l.record("DEBUG", format, args...)
+}
+func (l *recordingLogger) Info(format string, args ...interface{}) { l.record("INFO", format, args...) }
+func (l *recordingLogger) Warn(format string, args ...interface{}) { l.record("WARN", format, args...) }
+func (l *recordingLogger) Error(format string, args ...interface{}) {
+	l.record("ERROR", format, args...)
+}
+
+func (l *recordingLogger) contains(substr string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, line := range l.lines {
+		if strings.Contains(line, substr) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestConnManagerLimitsPerHostAndLogsWaits(t *testing.T) {
+	logger := &recordingLogger{}
+	cm, _, err := InitConn(&config.ConnConfig{MaxConnsPerHost: 1}, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	release, err := cm.Acquire(context.Background(), "example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := cm.Acquire(ctx, "example.com"); err == nil {
+		t.Fatal("已达 max_conns_per_host 时第二个连接应等待到超时")
+	}
+	if !logger.contains("example.com") {
+		t.Errorf("等待连接名额没有写入模块日志: %v", logger.lines)
+	}
+
+	release()
+	release2, err := cm.Acquire(context.Background(), "example.com")
+	if err != nil {
+		t.Fatalf("释放后仍无法获取连接名额: %v", err)
+	}
+	release2()
+	if len(cm.hosts) != 0 {
+		t.Errorf("名额全部释放后仍保留 %d 个主机", len(cm.hosts))
+	}
+}
+
+func TestDNSResolverLogsFailedServers(t *testing.T) {
+	logger := &recordingLogger{}
+	r := NewDNSResolver(&config.DomainDNSConfig{
+		DNSServers:    []string{"127.0.0.1:1"},
+		Timeout:       config.Duration(200 * time.Millisecond),
+		MaxRetries:    1,
+		RetryInterval: config.Duration(time.Millisecond),
+	}, logger)
+
+	if _, err := r.LookupIP(context.Background(), "example.com"); err == nil {
+		t.Fatal("DNS服务器不可用时应返回错误")
+	}
+	if !logger.contains("127.0.0.1:1") || !logger.contains("重试") {
+		t.Errorf("解析失败和重试没有写入模块日志: %v", logger.lines)
+	}
+}
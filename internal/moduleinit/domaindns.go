@@ -5,79 +5,216 @@
 package moduleinit
 
 import (
+	"context"
 	"fmt"
+	"net"
+	"time"
 
-	"github.com/vistone/domaindns"
 	"github.com/vistone/crawler-system/internal/config"
+	"github.com/vistone/domaindns"
 )
 
 // InitDomainDNS 初始化DNS解析模块（模块3）
-func InitDomainDNS(cfg *config.DomainDNSConfig, targetDomains []string, logger interface{ Info(string, ...interface{}); Warn(string, ...interface{}) }) (domaindns.DomainMonitor, error) {
+//
+// 监控器按 cache_ttl 定期解析目标域名并缓存结果；未启用缓存或未配置目标域名时不创建监控器，返回的监控器为 nil。
+func InitDomainDNS(cfg *config.DomainDNSConfig, targetDomains []string, logger Logger) (domaindns.DomainMonitor, *StartupReport, error) {
+	report := NewStartupReport("domaindns", "DNS解析模块")
+	report.Set("dns_servers", "DNS服务器数量", len(cfg.DNSServers))
+	report.Set("cache_enabled", "DNS缓存", cfg.CacheEnabled)
+	if cfg.CacheEnabled {
+		report.Set("cache_ttl", "缓存TTL", cfg.CacheTTL)
+	}
+	report.Set("timeout", "查询超时", cfg.Timeout)
+	report.Set("max_retries", "最大重试次数", cfg.MaxRetries)
+	report.Set("retry_interval", "重试间隔", cfg.RetryInterval)
+	report.Set("pollution_detection", "DNS污染检测", cfg.PollutionDetection)
+	report.Set("ipv6_enabled", "IPv6支持", cfg.IPv6Enabled)
+	report.Set("ipinfo_token", "IPInfo Token", getSecretDisplay(cfg.IPInfoToken.Value(), "未配置"))
+	report.Set("target_domains", "目标域名", targetDomains)
+
+	if !cfg.CacheEnabled {
+		report.Note("未启用DNS缓存，每次请求通过 dns_servers 实时解析")
+		return nil, report, nil
+	}
 	if len(targetDomains) == 0 {
-		if logger != nil {
-			logger.Warn("未配置目标域名，跳过DNS监控器创建")
-		}
-		return nil, nil
-	}
-
-	// 输出初始化详细信息
-	fmt.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
-	fmt.Printf("🌐 [模块3] DNS解析模块初始化\n")
-	fmt.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
-	fmt.Printf("  ✓ DNS服务器数量: %d\n", len(cfg.DNSServers))
-	if len(cfg.DNSServers) > 0 {
-		fmt.Printf("  ✓ DNS服务器列表:\n")
-		for i, server := range cfg.DNSServers {
-			if i < 10 {
-				fmt.Printf("    - %s\n", server)
-			} else if i == 10 {
-				fmt.Printf("    ... 还有 %d 个服务器\n", len(cfg.DNSServers)-10)
-				break
-			}
-		}
+		report.Warn("未配置目标域名（ip_pool_test.target_domains），跳过DNS监控器创建")
+		return nil, report, nil
 	}
-	fmt.Printf("  ✓ DNS缓存: %v\n", cfg.CacheEnabled)
-	if cfg.CacheEnabled {
-		fmt.Printf("  ✓ 缓存TTL: %d 秒\n", cfg.CacheTTL)
-	}
-	fmt.Printf("  ✓ 查询超时: %d 秒\n", cfg.Timeout)
-	fmt.Printf("  ✓ 最大重试: %d 次\n", cfg.MaxRetries)
-	fmt.Printf("  ✓ 重试间隔: %d 秒\n", cfg.RetryInterval)
-	fmt.Printf("  ✓ DNS污染检测: %v\n", cfg.PollutionDetection)
-	fmt.Printf("  ✓ IPv6支持: %v\n", cfg.IPv6Enabled)
-	fmt.Printf("  ✓ IPInfo Token: %s\n", getDisplayValue(cfg.IPInfoToken, "未配置"))
-	fmt.Printf("  ✓ 目标域名数量: %d\n", len(targetDomains))
-	fmt.Printf("  ✓ 目标域名列表:\n")
-	for _, domain := range targetDomains {
-		fmt.Printf("    - %s\n", domain)
-	}
-	fmt.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
-
-	// 使用NewMonitorWithGlobalDNSServers创建监控器
-	ipInfoToken := cfg.IPInfoToken
-	if ipInfoToken == "" && logger != nil {
-		logger.Warn("未配置IPInfo Token，IP详细信息获取功能将不可用")
-	}
-
-	monitor, err := domaindns.NewMonitorWithGlobalDNSServers(
-		targetDomains,
-		ipInfoToken,
-		"", // dnsServerFile，空表示使用默认的dnsservernames.json
-		0,  // maxServers，0表示使用全部DNS服务器
-	)
+	if cfg.IPInfoToken.Value() == "" {
+		report.Warn("未配置IPInfo Token，IP详细信息获取功能将不可用")
+	}
+
+	monitor, err := domaindns.NewMonitorWithConfig(DNSMonitorConfig(cfg, targetDomains))
 	if err != nil {
-		return nil, fmt.Errorf("创建DNS监控器失败: %w", err)
+		return nil, nil, fmt.Errorf("创建DNS监控器失败: %w", err)
 	}
+	logger.Debug("DNS监控器已创建，domains=%d, update_interval=%v", len(targetDomains), cfg.CacheTTL)
 
-	// 启动DomainMonitor
-	monitor.Start()
+	// DomainMonitor 由 System.Start 启动
+	return monitor, report, nil
+}
 
-	if logger != nil {
-		logger.Info("DNS解析模块初始化完成，dns_servers=%d, target_domains=%d, domains=%v",
-			len(cfg.DNSServers), len(targetDomains), targetDomains)
+// DNSMonitorConfig 根据 [domaindns] 配置生成 domaindns 监控器的配置
+//
+// 启用缓存时监控器每隔 cache_ttl 重新解析一次，否则使用 domaindns 的默认间隔。
+func DNSMonitorConfig(cfg *config.DomainDNSConfig, domains []string) *domaindns.Config {
+	dnsCfg := domaindns.DefaultConfig()
+	dnsCfg.Domains = domains
+	dnsCfg.DNSServers = cfg.DNSServers
+	dnsCfg.IPInfoToken = cfg.IPInfoToken.Value()
+	dnsCfg.DNSTimeout = cfg.Timeout.Duration()
+	dnsCfg.MaxRetries = cfg.MaxRetries
+	dnsCfg.RetryDelay = cfg.RetryInterval.Duration()
+	dnsCfg.EnableIPv6 = cfg.IPv6Enabled
+	if cfg.CacheEnabled {
+		dnsCfg.UpdateInterval = cfg.CacheTTL.Duration()
 	}
+	return dnsCfg
+}
+
+// DNSResolver 使用 dns_servers 实时解析域名
+//
+// 启用 pollution_detection 时丢弃解析结果中不可能是公网目标的地址（回环、私有、保留地址等），
+// 一个DNS服务器只返回这类地址时视为被污染，改用下一个服务器。
+type DNSResolver struct {
+	servers            []string
+	timeout            time.Duration
+	maxRetries         int
+	retryInterval      time.Duration
+	ipv6               bool
+	pollutionDetection bool
+	logger             Logger
+}
+
+// reservedNets 不会出现在公网解析结果中的地址段，污染的DNS应答常用这些地址
+var reservedNets = mustParseCIDRs(
+	"0.0.0.0/8",       // 本网络
+	"100.64.0.0/10",   // 运营商级NAT
+	"192.0.0.0/24",    // IETF协议分配
+	"192.0.2.0/24",    // TEST-NET-1
+	"198.18.0.0/15",   // 基准测试
+	"198.51.100.0/24", // TEST-NET-2
+	"203.0.113.0/24",  // TEST-NET-3
+	"240.0.0.0/4",     // 保留
+	"2001:db8::/32",   // 文档地址
+)
 
-	return monitor, nil
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, c := range cidrs {
+		_, n, err := net.ParseCIDR(c)
+		if err != nil {
+			panic(err)
+		}
+		nets = append(nets, n)
+	}
+	return nets
 }
 
+// isPollutedIP 判断解析得到的地址是否不可能是公网目标
+func isPollutedIP(ip net.IP) bool {
+	if ip.IsUnspecified() || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() ||
+		ip.IsMulticast() || ip.Equal(net.IPv4bcast) {
+		return true
+	}
+	for _, n := range reservedNets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
 
+// NewDNSResolver 根据 [domaindns] 配置创建实时解析器
+func NewDNSResolver(cfg *config.DomainDNSConfig, logger Logger) *DNSResolver {
+	return &DNSResolver{
+		servers:            cfg.DNSServers,
+		timeout:            cfg.Timeout.Duration(),
+		maxRetries:         cfg.MaxRetries,
+		retryInterval:      cfg.RetryInterval.Duration(),
+		ipv6:               cfg.IPv6Enabled,
+		pollutionDetection: cfg.PollutionDetection,
+		logger:             logger,
+	}
+}
+
+// LookupIP 依次向各DNS服务器查询域名，全部失败时间隔 retry_interval 重试，最多重试 max_retries 次
+//
+// 未启用 IPv6 时只查询 A 记录；启用污染检测时只返回通过检测的地址。
+func (r *DNSResolver) LookupIP(ctx context.Context, host string) ([]net.IP, error) {
+	network := "ip4"
+	if r.ipv6 {
+		network = "ip"
+	}
+	var lastErr error
+	for attempt := 0; attempt <= r.maxRetries; attempt++ {
+		if attempt > 0 {
+			r.logger.Debug("解析域名 %s 失败，%v 后第 %d 次重试，error=%v", host, r.retryInterval, attempt, lastErr)
+			select {
+			case <-ctx.Done():
+				return nil, fmt.Errorf("解析域名 %s 失败: %w", host, ctx.Err())
+			case <-time.After(r.retryInterval):
+			}
+		}
+		for _, server := range r.servers {
+			ips, err := r.lookup(ctx, server, network, host)
+			if err == nil {
+				if ips = r.dropPolluted(host, ips); len(ips) > 0 {
+					return ips, nil
+				}
+				err = fmt.Errorf("DNS服务器 %s 返回的地址均未通过污染检测", server)
+			}
+			r.logger.Debug("DNS服务器 %s 解析 %s 失败，error=%v", server, host, err)
+			lastErr = err
+		}
+	}
+	return nil, fmt.Errorf("解析域名 %s 失败: %w", host, lastErr)
+}
+
+// lookup 向一个DNS服务器查询，超时取 timeout
+func (r *DNSResolver) lookup(ctx context.Context, server, network, host string) ([]net.IP, error) {
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "53")
+	}
+	resolver := &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, server)
+		},
+	}
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	return resolver.LookupIP(ctx, network, host)
+}
+
+// Filter 去掉未启用 IPv6 时的 IPv6 地址，启用污染检测时同时去掉未通过检测的地址
+func (r *DNSResolver) Filter(ips []net.IP) []net.IP {
+	var kept []net.IP
+	for _, ip := range ips {
+		if !r.ipv6 && ip.To4() == nil {
+			continue
+		}
+		if r.pollutionDetection && isPollutedIP(ip) {
+			continue
+		}
+		kept = append(kept, ip)
+	}
+	return kept
+}
+
+// dropPolluted 启用污染检测时去掉未通过检测的地址
+func (r *DNSResolver) dropPolluted(host string, ips []net.IP) []net.IP {
+	if !r.pollutionDetection {
+		return ips
+	}
+	kept := make([]net.IP, 0, len(ips))
+	for _, ip := range ips {
+		if isPollutedIP(ip) {
+			r.logger.Warn("域名 %s 的解析结果 %s 未通过污染检测，已丢弃", host, ip)
+			continue
+		}
+		kept = append(kept, ip)
+	}
+	return kept
+}
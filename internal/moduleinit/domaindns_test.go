@@ -0,0 +1,71 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package moduleinit
+
+import (
+	"net"
+	"testing"
+)
+
+func TestIsPollutedIP(t *testing.T) {
+	tests := []struct {
+		ip       string
+		polluted bool
+	}{
+		{"93.184.216.34", false},
+		{"8.8.8.8", false},
+		{"2606:2800:220:1:248:1893:25c8:1946", false},
+		{"127.0.0.1", true},
+		{"0.0.0.0", true},
+		{"10.1.2.3", true},
+		{"192.168.1.1", true},
+		{"169.254.1.1", true},
+		{"198.18.0.1", true},
+		{"240.0.0.1", true},
+		{"255.255.255.255", true},
+		{"::1", true},
+		{"fe80::1", true},
+		{"2001:db8::1", true},
+	}
+	for _, tt := range tests {
+		if got := isPollutedIP(net.ParseIP(tt.ip)); got != tt.polluted {
+			t.Errorf("isPollutedIP(%s) = %v, want %v", tt.ip, got, tt.polluted)
+		}
+	}
+}
+
+func TestDNSResolverFilter(t *testing.T) {
+	ips := []net.IP{
+		net.ParseIP("93.184.216.34"),
+		net.ParseIP("127.0.0.1"),
+		net.ParseIP("2606:2800:220:1:248:1893:25c8:1946"),
+		net.ParseIP("::1"),
+	}
+	tests := []struct {
+		name               string
+		ipv6               bool
+		pollutionDetection bool
+		want               []string
+	}{
+		{"全部保留", true, false, []string{"93.184.216.34", "127.0.0.1", "2606:2800:220:1:248:1893:25c8:1946", "::1"}},
+		{"只去掉IPv6", false, false, []string{"93.184.216.34", "127.0.0.1"}},
+		{"只去掉污染地址", true, true, []string{"93.184.216.34", "2606:2800:220:1:248:1893:25c8:1946"}},
+		{"两者都去掉", false, true, []string{"93.184.216.34"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &DNSResolver{ipv6: tt.ipv6, pollutionDetection: tt.pollutionDetection, logger: &recordingLogger{}}
+			got := r.Filter(ips)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Filter() = %v, want %v", got, tt.want)
+			}
+			for i, ip := range got {
+				if ip.String() != tt.want[i] {
+					t.Fatalf("Filter() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
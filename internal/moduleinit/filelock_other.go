@@ -0,0 +1,12 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !unix && !windows
+
+package moduleinit
+
+// lockFile 其他系统不支持文件锁，不做任何事；同一进程内的修改仍由管理器的互斥锁保护
+func lockFile(string) (unlock func(), err error) {
+	return func() {}, nil
+}
@@ -5,48 +5,430 @@
 package moduleinit
 
 import (
+	"context"
 	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/vistone/crawler-system/internal/config"
+	"github.com/vistone/fingerprint"
 )
 
+// Fingerprint 一次请求使用的浏览器指纹
+type Fingerprint struct {
+	ID        string                    // 指纹ID，如 chrome_133
+	Browser   string                    // 浏览器：chrome, firefox, safari, edge, opera
+	Version   string                    // 浏览器版本，如 133、17.0
+	OS        string                    // 搭配的操作系统：windows, macos, linux, ios
+	Mobile    bool                      // 是否为移动端指纹
+	Locale    string                    // 界面语言，如 en-US，决定 Accept-Language
+	Profile   fingerprint.ClientProfile // TLS ClientHello 和 HTTP/2 参数
+	UserAgent string                    // 与浏览器版本和操作系统匹配的 User-Agent
+	Headers   []HeaderField             // 浏览器导航请求的默认请求头（含 User-Agent 和 Sec-Ch-Ua），按该浏览器的发送顺序排列
+}
+
+// HeaderField 一个请求头
+type HeaderField struct {
+	Name  string // 规范格式的名称，如 User-Agent
+	Value string
+}
+
 // FingerprintManager 指纹管理器
+//
+// 指纹库由内置指纹和 library_path 中的自定义指纹合并而成，按 browsers 和 operating_systems 过滤后
+// 按 selection_strategy 选择指纹并统计每个指纹的使用次数，以及每个指纹在各目标上被拒绝的情况。
+// 每个目标的指纹在 rotation_interval 内保持不变，使目标站点看到的始终是同一个客户端。
 type FingerprintManager struct {
 	Config *config.FingerprintConfig
+
+	loadMu sync.Mutex  // 串行化配置热更新和指纹库重新加载
+	stamps []fileStamp // 当前自定义指纹库文件的修改时间和大小
+	failed []fileStamp // 最近一次加载失败的指纹库文件，未变化时不重复加载
+	missed string      // 最近一次读取指纹库路径失败的原因，原因不变时不重复报告
+
+	mu        sync.RWMutex
+	library   []fingerprintProfile            // 内置指纹与自定义指纹合并后的指纹库，按指纹ID排序
+	profiles  []fingerprintProfile            // 过滤后的指纹，按指纹ID排序
+	usage     map[string]int64                // 指纹ID -> 被选中的次数
+	cursor    int                             // round_robin 下一个选中的位置
+	sticky    map[string]*stickyFingerprint   // 目标 -> 当前指纹
+	lastSweep time.Time                       // 上次清理闲置粘滞指纹的时间
+	health    map[healthKey]FingerprintHealth // 指纹在各目标上的请求统计
+	lastPrune time.Time                       // 上次清理健康统计的时间
+	rng       *rand.Rand                      // 选择指纹和随机化客户端身份使用的随机源
+	logger    Logger
+}
+
+// 粘滞指纹的清理，与是否启用轮换无关
+const (
+	stickyIdleTTL       = time.Hour   // 目标闲置超过此时间后删除其粘滞指纹，再次请求时重新选择
+	maxStickyTargets    = 10000       // 最多保留粘滞指纹的目标数，超过时删除最久未使用的目标
+	stickySweepInterval = time.Minute // 清理闲置粘滞指纹的最小间隔
+)
+
+// stickyFingerprint 分配给某个目标的当前指纹
+type stickyFingerprint struct {
+	fp       *Fingerprint
+	assigned time.Time // 分配时间，超过 rotation_interval 后轮换
+	lastUsed time.Time // 最近一次被 Next 返回的时间
+	rotate   bool      // 已被强制轮换，下次请求时更换
 }
 
 // InitFingerprint 初始化指纹模块（模块2）
-func InitFingerprint(cfg *config.FingerprintConfig, logger interface{ Info(string, ...interface{}) }) (*FingerprintManager, error) {
-	// 创建指纹管理器
+func InitFingerprint(cfg *config.FingerprintConfig, logger Logger) (*FingerprintManager, *StartupReport, error) {
+	report := NewStartupReport("fingerprint", "指纹模块")
+	report.Set("selection_strategy", "选择策略", cfg.SelectionStrategy)
+	report.Set("enable_rotation", "指纹轮换", cfg.EnableRotation)
+	if cfg.EnableRotation {
+		report.Set("rotation_interval", "轮换间隔", cfg.RotationInterval)
+	}
+	report.Set("library_path", "指纹库路径", getDisplayValue(cfg.LibraryPath, "默认"))
+	report.Set("browsers", "浏览器列表", getBrowserList(cfg.Browsers))
+	report.Set("operating_systems", "操作系统列表", getOSList(cfg.OperatingSystems))
+	report.Set("os_randomization", "操作系统随机化", cfg.OSRandomization)
+	report.Set("ua_randomization", "User-Agent随机化", cfg.UARandomization)
+	report.Set("health_half_life", "健康统计半衰期", cfg.HealthHalfLife)
+
+	library, stamps, custom, replaced, err := openLibrary(cfg.LibraryPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	if cfg.LibraryPath != "" {
+		report.Detect("library_files", "指纹库文件数量", len(stamps))
+		report.Detect("custom_profiles", "自定义指纹数量", custom)
+		if len(replaced) > 0 {
+			report.Note("自定义指纹替换了内置指纹: %s", strings.Join(replaced, ", "))
+		}
+	}
+	profiles, err := selectProfiles(library, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	report.Detect("profiles", "可用指纹数量", len(profiles))
+	for _, b := range missingBrowsers(cfg.Browsers, profiles) {
+		report.Warn("指纹库中没有符合操作系统列表的 %s 指纹，该浏览器不会被使用", b)
+	}
+
 	fm := &FingerprintManager{
-		Config: cfg,
+		Config:   cfg,
+		stamps:   stamps,
+		library:  library,
+		profiles: profiles,
+		usage:    make(map[string]int64, len(profiles)),
+		sticky:   make(map[string]*stickyFingerprint),
+		health:   make(map[healthKey]FingerprintHealth),
+		rng:      rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64())),
+		logger:   logger,
 	}
+	return fm, report, nil
+}
 
-	// 输出初始化详细信息
-	fmt.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
-	fmt.Printf("🔐 [模块2] 指纹模块初始化\n")
-	fmt.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
-	fmt.Printf("  ✓ 选择策略: %s\n", cfg.SelectionStrategy)
-	fmt.Printf("  ✓ 指纹轮换: %v\n", cfg.EnableRotation)
-	if cfg.EnableRotation {
-		fmt.Printf("  ✓ 轮换间隔: %d 秒\n", cfg.RotationInterval)
+// openLibrary 加载指纹库：path 为空时只有内置指纹，否则合并 path 中的自定义指纹
+//
+// 返回合并后的指纹库、自定义指纹库文件、自定义指纹数量和被替换的内置指纹ID。
+func openLibrary(path string) (library []fingerprintProfile, stamps []fileStamp, custom int, replaced []string, err error) {
+	if path == "" {
+		return builtinProfiles(), nil, 0, nil, nil
+	}
+	if stamps, err = statLibrary(path); err != nil {
+		return nil, nil, 0, nil, err
+	}
+	profiles, err := loadLibrary(stamps)
+	if err != nil {
+		return nil, nil, 0, nil, err
+	}
+	library, replaced = mergeProfiles(builtinProfiles(), profiles)
+	return library, stamps, len(profiles), replaced, nil
+}
+
+// selectProfiles 返回指纹库中配置允许使用的指纹，没有可用指纹时返回错误
+func selectProfiles(library []fingerprintProfile, cfg *config.FingerprintConfig) ([]fingerprintProfile, error) {
+	profiles := filterProfiles(library, cfg.Browsers, cfg.OperatingSystems)
+	if len(profiles) == 0 {
+		return nil, fmt.Errorf("没有符合浏览器列表 %s 和操作系统列表 %s 的指纹", getBrowserList(cfg.Browsers), getOSList(cfg.OperatingSystems))
+	}
+	return profiles, nil
+}
+
+// Next 返回目标当前使用的指纹，没有或需要轮换时按 selection_strategy 选择新指纹，
+// 并生成与其浏览器版本和操作系统匹配的 User-Agent 和请求头
+//
+// target 通常为请求的目标主机，也可以是会话标识；同一 target 在 rotation_interval 内得到同一个指纹
+// （enable_rotation 关闭时一直不变，直到调用 Rotate），轮换时尽量换成另一个指纹。
+// 闲置超过 1 小时的 target 不再保留指纹，最多保留 10000 个 target，超过时删除最久未使用的；
+// 被删除的 target 再次请求时按新 target 选择，可能选中原来的指纹。
+// target 为空时每次都选择新指纹。同一 target 得到的 *Fingerprint 是共享的，调用方不得修改。
+func (fm *FingerprintManager) Next(ctx context.Context, target string) (*Fingerprint, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
-	fmt.Printf("  ✓ 指纹库路径: %s\n", getDisplayValue(cfg.LibraryPath, "默认"))
-	fmt.Printf("  ✓ 浏览器列表: %v\n", getBrowserList(cfg.Browsers))
-	fmt.Printf("  ✓ 操作系统随机化: %v\n", cfg.OSRandomization)
-	fmt.Printf("  ✓ User-Agent随机化: %v\n", cfg.UARandomization)
-	fmt.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
+	now := time.Now()
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
 
-	if logger != nil {
-		logger.Info("指纹模块初始化完成，strategy=%s, rotation=%v", cfg.SelectionStrategy, cfg.EnableRotation)
+	var previous string
+	if target != "" {
+		if s, ok := fm.sticky[target]; ok {
+			if !s.rotate && !fm.expired(s, now) && fm.allowed(s.fp) {
+				s.lastUsed = now
+				return s.fp, nil
+			}
+			previous = s.fp.ID
+		}
 	}
-	return fm, nil
+
+	p := fm.pick(target, previous)
+	fp, err := newFingerprint(p, fm.Config, fm.rng)
+	if err != nil {
+		return nil, err
+	}
+	fm.usage[p.id]++
+	if target != "" {
+		fm.sticky[target] = &stickyFingerprint{fp: fp, assigned: now, lastUsed: now}
+		if previous != "" {
+			fm.logger.Debug("目标 %s 的指纹已轮换: %s -> %s", target, previous, fp.ID)
+		}
+		fm.sweep(now)
+	}
+	return fp, nil
 }
 
-// GetRandomFingerprint 获取随机指纹
-func (fm *FingerprintManager) GetRandomFingerprint() (interface{}, error) {
-	// TODO: 实现指纹获取逻辑
-	return nil, fmt.Errorf("未实现")
+// Rotate 强制轮换目标的指纹（如目标开始返回 403），下次 Next 时更换为另一个指纹
+//
+// 返回目标此前是否已分配指纹。
+func (fm *FingerprintManager) Rotate(target string) bool {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	s, ok := fm.sticky[target]
+	if !ok {
+		return false
+	}
+	if !s.rotate {
+		s.rotate = true
+		fm.logger.Info("强制轮换目标 %s 的指纹 %s", target, s.fp.ID)
+	}
+	return true
 }
 
+// expired 判断粘滞指纹是否超过轮换间隔，调用方持有 fm.mu
+func (fm *FingerprintManager) expired(s *stickyFingerprint, now time.Time) bool {
+	return fm.Config.EnableRotation && now.Sub(s.assigned) >= fm.Config.RotationInterval.Duration()
+}
+
+// allowed 判断指纹在当前配置下是否仍可使用，调用方持有 fm.mu
+func (fm *FingerprintManager) allowed(fp *Fingerprint) bool {
+	i, ok := slices.BinarySearchFunc(fm.profiles, fp.ID, func(p fingerprintProfile, id string) int {
+		return strings.Compare(p.id, id)
+	})
+	return ok && slices.Contains(fm.profiles[i].oses, fp.OS)
+}
 
+// sweep 删除闲置超过 stickyIdleTTL 的粘滞指纹（每 stickySweepInterval 最多一次），
+// 目标数超过 maxStickyTargets 时删除最久未使用的，调用方持有 fm.mu
+//
+// 已过期或被强制轮换但未闲置的粘滞指纹保留到下次 Next，使轮换时能排除原指纹；
+// 被删除的目标不再记得原指纹，再次请求时按新目标选择。
+func (fm *FingerprintManager) sweep(now time.Time) {
+	if now.Sub(fm.lastSweep) >= stickySweepInterval {
+		fm.lastSweep = now
+		for target, s := range fm.sticky {
+			if now.Sub(s.lastUsed) >= stickyIdleTTL {
+				delete(fm.sticky, target)
+			}
+		}
+	}
+	if len(fm.sticky) <= maxStickyTargets {
+		return
+	}
+
+	// 一次删到上限的 90%，避免每个新目标都排序一次
+	type entry struct {
+		target   string
+		lastUsed time.Time
+	}
+	entries := make([]entry, 0, len(fm.sticky))
+	for target, s := range fm.sticky {
+		entries = append(entries, entry{target, s.lastUsed})
+	}
+	slices.SortFunc(entries, func(a, b entry) int { return a.lastUsed.Compare(b.lastUsed) })
+	for _, e := range entries[:len(entries)-maxStickyTargets*9/10] {
+		delete(fm.sticky, e.target)
+	}
+}
+
+// pick 按选择策略为目标选出一个指纹，有其他指纹可选时不选 exclude，调用方持有 fm.mu
+func (fm *FingerprintManager) pick(target, exclude string) fingerprintProfile {
+	candidates := fm.profiles
+	if exclude != "" && len(candidates) > 1 {
+		candidates = slices.DeleteFunc(slices.Clone(candidates), func(p fingerprintProfile) bool { return p.id == exclude })
+	}
+	switch fm.Config.SelectionStrategy {
+	case "round_robin":
+		for {
+			p := fm.profiles[fm.cursor]
+			fm.cursor = (fm.cursor + 1) % len(fm.profiles)
+			if p.id != exclude || len(fm.profiles) == 1 {
+				return p
+			}
+		}
+	case "least_used":
+		// 使用次数最少的指纹中随机选一个，避免并发请求总是落在同一个指纹上
+		var least []fingerprintProfile
+		var fewest int64
+		for _, p := range candidates {
+			n := fm.usage[p.id]
+			if len(least) == 0 || n < fewest {
+				least, fewest = least[:0], n
+			}
+			if n == fewest {
+				least = append(least, p)
+			}
+		}
+		return least[fm.rng.IntN(len(least))]
+	case "least_blocked":
+		return fm.pickLeastBlocked(candidates, target)
+	default:
+		return candidates[fm.rng.IntN(len(candidates))]
+	}
+}
+
+// newFingerprint 为指纹搭配操作系统，生成一致的 User-Agent、客户端提示和请求头
+//
+// os_randomization 开启时从可搭配的操作系统中随机选择，否则使用第一个；ua_randomization 见 newIdentity。
+func newFingerprint(p fingerprintProfile, cfg *config.FingerprintConfig, rng *rand.Rand) (*Fingerprint, error) {
+	os := p.oses[0]
+	if cfg.OSRandomization {
+		os = p.oses[rng.IntN(len(p.oses))]
+	}
+	id, err := newIdentity(p, os, cfg.UARandomization, rng)
+	if err != nil {
+		return nil, err
+	}
+	return &Fingerprint{
+		ID:        p.id,
+		Browser:   p.browser,
+		Version:   p.version,
+		OS:        os,
+		Mobile:    p.mobile,
+		Locale:    id.locale,
+		Profile:   p.profile,
+		UserAgent: id.userAgent,
+		Headers:   id.headers,
+	}, nil
+}
+
+// Profiles 返回当前可用的指纹ID，按ID排序
+func (fm *FingerprintManager) Profiles() []string {
+	fm.mu.RLock()
+	defer fm.mu.RUnlock()
+	ids := make([]string, len(fm.profiles))
+	for i, p := range fm.profiles {
+		ids[i] = p.id
+	}
+	return ids
+}
+
+// Usage 返回每个指纹被选中的次数（指纹ID -> 次数），包含热更新后已不可用的指纹
+//
+// 粘滞期内复用同一个指纹不计入次数。
+func (fm *FingerprintManager) Usage() map[string]int64 {
+	fm.mu.RLock()
+	defer fm.mu.RUnlock()
+	usage := make(map[string]int64, len(fm.usage))
+	for id, n := range fm.usage {
+		usage[id] = n
+	}
+	return usage
+}
+
+// GetConfig 返回当前生效的指纹配置
+func (fm *FingerprintManager) GetConfig() *config.FingerprintConfig {
+	fm.mu.RLock()
+	defer fm.mu.RUnlock()
+	return fm.Config
+}
+
+// UpdateConfig 热更新指纹配置，重新过滤指纹集合；library_path 变化时加载新的指纹库
+//
+// 指纹库无效或没有可用指纹时保留原配置并返回错误。使用次数统计跨热更新保留；
+// 目标的当前指纹不再可用时在下次请求时更换，新的轮换间隔立即生效。
+func (fm *FingerprintManager) UpdateConfig(cfg *config.FingerprintConfig) error {
+	fm.loadMu.Lock()
+	defer fm.loadMu.Unlock()
+
+	library, stamps := fm.currentLibrary()
+	if cfg.LibraryPath != fm.GetConfig().LibraryPath {
+		var err error
+		if library, stamps, _, _, err = openLibrary(cfg.LibraryPath); err != nil {
+			return err
+		}
+		fm.failed, fm.missed = nil, ""
+	}
+	profiles, err := selectProfiles(library, cfg)
+	if err != nil {
+		return err
+	}
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	fm.Config = cfg
+	fm.stamps = stamps
+	fm.library = library
+	fm.profiles = profiles
+	fm.cursor = 0
+	fm.logger.Debug("指纹配置已更新，selection_strategy=%s, browsers=%s, operating_systems=%s, 可用指纹%d个",
+		cfg.SelectionStrategy, getBrowserList(cfg.Browsers), getOSList(cfg.OperatingSystems), len(profiles))
+	return nil
+}
+
+// ReloadLibrary 自定义指纹库文件有变化（修改、新增或删除）时重新加载
+//
+// 返回是否重新加载了指纹库；新指纹库无效或没有可用指纹时保留当前指纹库并返回错误，
+// 同一份无效文件只报告一次。
+func (fm *FingerprintManager) ReloadLibrary() (bool, error) {
+	fm.loadMu.Lock()
+	defer fm.loadMu.Unlock()
+
+	cfg := fm.GetConfig()
+	if cfg.LibraryPath == "" {
+		return false, nil
+	}
+	stamps, err := statLibrary(cfg.LibraryPath)
+	if err != nil {
+		if err.Error() == fm.missed {
+			return false, nil
+		}
+		fm.missed = err.Error()
+		return false, err
+	}
+	fm.missed = ""
+	if _, current := fm.currentLibrary(); slices.Equal(stamps, current) || slices.Equal(stamps, fm.failed) {
+		return false, nil
+	}
+	library, loaded, custom, _, err := openLibrary(cfg.LibraryPath)
+	if err == nil {
+		var profiles []fingerprintProfile
+		if profiles, err = selectProfiles(library, cfg); err == nil {
+			fm.failed = nil
+			fm.mu.Lock()
+			fm.stamps = loaded
+			fm.library = library
+			fm.profiles = profiles
+			fm.cursor = 0
+			fm.mu.Unlock()
+			fm.logger.Info("指纹库已重新加载，path=%s, 自定义指纹%d个, 可用指纹%d个", cfg.LibraryPath, custom, len(profiles))
+			return true, nil
+		}
+	}
+	fm.failed = stamps
+	return false, err
+}
+
+// currentLibrary 返回当前的指纹库及其文件
+func (fm *FingerprintManager) currentLibrary() ([]fingerprintProfile, []fileStamp) {
+	fm.mu.RLock()
+	defer fm.mu.RUnlock()
+	return fm.library, fm.stamps
+}
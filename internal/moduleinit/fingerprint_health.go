@@ -0,0 +1,197 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package moduleinit
+
+import (
+	"math"
+	"slices"
+	"time"
+)
+
+// maxHealthEntries 最多保留的健康统计条数（指纹与目标的组合），超过时删除最久没有更新的
+const maxHealthEntries = 50000
+
+// FingerprintOutcome 一次请求的结果，用于统计指纹在目标上的健康度
+type FingerprintOutcome int
+
+const (
+	OutcomeSuccess   FingerprintOutcome = iota // 目标正常响应
+	OutcomeForbidden                           // 目标返回 403
+	OutcomeChallenge                           // 目标返回人机验证页面
+	OutcomeReset                               // TLS 握手时连接被目标重置或关闭
+)
+
+// String 返回结果名称
+func (o FingerprintOutcome) String() string {
+	switch o {
+	case OutcomeSuccess:
+		return "success"
+	case OutcomeForbidden:
+		return "forbidden"
+	case OutcomeChallenge:
+		return "challenge"
+	case OutcomeReset:
+		return "reset"
+	}
+	return "unknown"
+}
+
+// Blocked 判断结果是否表示目标拒绝了该指纹
+func (o FingerprintOutcome) Blocked() bool {
+	return o != OutcomeSuccess
+}
+
+// FingerprintHealth 指纹在一个目标上的请求统计，各计数按 health_half_life 指数衰减
+type FingerprintHealth struct {
+	Success   float64 // 正常响应次数
+	Forbidden float64 // 403 次数
+	Challenge float64 // 人机验证页面次数
+	Reset     float64 // TLS 握手被重置次数
+
+	updated time.Time
+}
+
+// Blocked 返回被拒绝的次数（403、人机验证和 TLS 重置之和）
+func (h FingerprintHealth) Blocked() float64 {
+	return h.Forbidden + h.Challenge + h.Reset
+}
+
+// BlockRate 返回被拒绝的比例，没有统计时为0
+func (h FingerprintHealth) BlockRate() float64 {
+	total := h.Success + h.Blocked()
+	if total == 0 {
+		return 0
+	}
+	return h.Blocked() / total
+}
+
+// weight least_blocked 策略的选择权重：平滑后成功率的平方
+//
+// 没有统计的指纹权重为 1/4，被拒绝后持续成功的指纹趋近1，持续被拒绝的指纹趋近0；
+// 随着统计衰减，被拒绝过的指纹会逐渐恢复到没有统计时的权重。
+func (h FingerprintHealth) weight() float64 {
+	rate := (h.Success + 1) / (h.Success + h.Blocked() + 2)
+	return rate * rate
+}
+
+// decayed 返回衰减到 now 的统计
+func (h FingerprintHealth) decayed(now time.Time, halfLife time.Duration) FingerprintHealth {
+	if elapsed := now.Sub(h.updated); elapsed > 0 && halfLife > 0 {
+		f := math.Exp2(-float64(elapsed) / float64(halfLife))
+		h.Success *= f
+		h.Forbidden *= f
+		h.Challenge *= f
+		h.Reset *= f
+	}
+	h.updated = now
+	return h
+}
+
+// healthKey 健康统计的键：指纹ID和目标
+type healthKey struct {
+	id     string
+	target string
+}
+
+// RecordOutcome 记录指纹在目标上的一次请求结果，供 least_blocked 策略和 Health 使用
+//
+// target 与 Next 的 target 相同（通常为目标主机），为空时不记录。
+// 只有被目标拒绝过的指纹才有统计：没有统计时的成功不记录，避免正常请求的每个目标都占用一条统计。
+func (fm *FingerprintManager) RecordOutcome(id, target string, outcome FingerprintOutcome) {
+	if target == "" {
+		return
+	}
+	now := time.Now()
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+
+	halfLife := fm.Config.HealthHalfLife.Duration()
+	key := healthKey{id: id, target: target}
+	h, ok := fm.health[key]
+	if !ok && !outcome.Blocked() {
+		return
+	}
+	h = h.decayed(now, halfLife)
+	switch outcome {
+	case OutcomeSuccess:
+		h.Success++
+	case OutcomeForbidden:
+		h.Forbidden++
+	case OutcomeChallenge:
+		h.Challenge++
+	case OutcomeReset:
+		h.Reset++
+	}
+	fm.health[key] = h
+	if outcome.Blocked() {
+		fm.logger.Debug("指纹 %s 被目标 %s 拒绝（%s），拒绝率 %.2f", id, target, outcome, h.BlockRate())
+	}
+	fm.pruneHealth(now, halfLife)
+}
+
+// Health 返回各指纹在目标上衰减到当前时刻的请求统计，没有被目标拒绝过的指纹不在其中
+func (fm *FingerprintManager) Health(target string) map[string]FingerprintHealth {
+	now := time.Now()
+	fm.mu.RLock()
+	defer fm.mu.RUnlock()
+	halfLife := fm.Config.HealthHalfLife.Duration()
+	health := make(map[string]FingerprintHealth)
+	for key, h := range fm.health {
+		if key.target == target {
+			health[key.id] = h.decayed(now, halfLife)
+		}
+	}
+	return health
+}
+
+// pickLeastBlocked 按目标上的健康统计加权随机选择指纹，调用方持有 fm.mu
+func (fm *FingerprintManager) pickLeastBlocked(candidates []fingerprintProfile, target string) fingerprintProfile {
+	now := time.Now()
+	halfLife := fm.Config.HealthHalfLife.Duration()
+	weights := make([]float64, len(candidates))
+	var total float64
+	for i, p := range candidates {
+		weights[i] = fm.health[healthKey{id: p.id, target: target}].decayed(now, halfLife).weight()
+		total += weights[i]
+	}
+	r := fm.rng.Float64() * total
+	for i, w := range weights {
+		if r < w {
+			return candidates[i]
+		}
+		r -= w
+	}
+	return candidates[len(candidates)-1]
+}
+
+// pruneHealth 删除长时间没有更新、已衰减到可以忽略的统计（每个半衰期最多一次），
+// 条数超过 maxHealthEntries 时删除最久没有更新的，调用方持有 fm.mu
+func (fm *FingerprintManager) pruneHealth(now time.Time, halfLife time.Duration) {
+	if now.Sub(fm.lastPrune) >= halfLife {
+		fm.lastPrune = now
+		for key, h := range fm.health {
+			if now.Sub(h.updated) >= 10*halfLife {
+				delete(fm.health, key)
+			}
+		}
+	}
+	if len(fm.health) <= maxHealthEntries {
+		return
+	}
+
+	// 一次删到上限的 90%，避免之后每次记录都要排序
+	type entry struct {
+		key     healthKey
+		updated time.Time
+	}
+	entries := make([]entry, 0, len(fm.health))
+	for key, h := range fm.health {
+		entries = append(entries, entry{key, h.updated})
+	}
+	slices.SortFunc(entries, func(a, b entry) int { return a.updated.Compare(b.updated) })
+	for _, e := range entries[:len(entries)-maxHealthEntries*9/10] {
+		delete(fm.health, e.key)
+	}
+}
@@ -0,0 +1,281 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package moduleinit
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// identity 与指纹的浏览器、版本和操作系统一致的客户端身份
+type identity struct {
+	userAgent string
+	locale    string        // 界面语言，如 en-US
+	headers   []HeaderField // 导航请求的默认请求头，按该浏览器的发送顺序排列
+}
+
+// locales ua_randomization 开启时随机选择的界面语言，第一个为关闭时使用的语言
+var locales = []string{"en-US", "en-GB", "de-DE", "fr-FR", "es-ES", "it-IT", "nl-NL", "pt-BR", "ja-JP", "ko-KR"}
+
+// operaChromium Opera 版本对应的 Chromium 版本，未列出的版本按相差 14 推算
+var operaChromium = map[int]int{89: 103, 90: 104, 91: 105}
+
+// chromeVersionPattern 匹配 User-Agent 中的 Chromium 主版本号
+var chromeVersionPattern = regexp.MustCompile(`Chrome/(\d+)`)
+
+// newIdentity 生成指纹搭配操作系统 os 时的客户端身份
+//
+// randomize 对应 ua_randomization：开启时随机选择界面语言和 Linux 发行版标识等同一浏览器版本的用户之间本就不同的细节，
+// 关闭时使用固定值。User-Agent、sec-ch-ua、Accept 系列请求头和请求头顺序始终由浏览器、版本和操作系统决定。
+func newIdentity(p fingerprintProfile, os string, randomize bool, rng *rand.Rand) (identity, error) {
+	id := identity{locale: locales[0]}
+	if randomize {
+		id.locale = locales[rng.IntN(len(locales))]
+	}
+
+	platform := platformToken(p.browser, os, randomize, rng)
+	if p.userAgent != "" {
+		id.userAgent = strings.ReplaceAll(p.userAgent, "{os}", platform)
+	} else {
+		ua, err := generateUserAgent(p, platform)
+		if err != nil {
+			return identity{}, err
+		}
+		id.userAgent = ua
+	}
+
+	var names []string
+	values := map[string]string{"User-Agent": id.userAgent}
+	switch p.browser {
+	case "chrome", "edge", "opera":
+		chromium := chromiumMajor(id.userAgent)
+		names = []string{"Sec-Ch-Ua", "Sec-Ch-Ua-Mobile", "Sec-Ch-Ua-Platform", "Upgrade-Insecure-Requests", "User-Agent", "Accept",
+			"Sec-Fetch-Site", "Sec-Fetch-Mode", "Sec-Fetch-User", "Sec-Fetch-Dest", "Accept-Encoding", "Accept-Language", "Priority"}
+		values["Sec-Ch-Ua"] = secCHUA(p.browser, majorVersion(p.version), chromium)
+		values["Sec-Ch-Ua-Mobile"] = "?0"
+		values["Sec-Ch-Ua-Platform"] = strconv.Quote(chPlatforms[os])
+		values["Accept"] = "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,image/apng,*/*;q=0.8,application/signed-exchange;v=b3;q=0.7"
+		values["Accept-Encoding"] = "gzip, deflate, br"
+		if chromium >= 123 {
+			values["Accept-Encoding"] = "gzip, deflate, br, zstd"
+		}
+		values["Accept-Language"] = chromiumAcceptLanguage(id.locale)
+		if chromium >= 124 {
+			values["Priority"] = "u=0, i"
+		}
+	case "firefox":
+		version := majorVersion(p.version)
+		names = []string{"User-Agent", "Accept", "Accept-Language", "Accept-Encoding", "Upgrade-Insecure-Requests",
+			"Sec-Fetch-Dest", "Sec-Fetch-Mode", "Sec-Fetch-Site", "Sec-Fetch-User", "Priority", "Te"}
+		values["Accept"] = "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,*/*;q=0.8"
+		values["Accept-Encoding"] = "gzip, deflate, br"
+		if version >= 126 {
+			values["Accept-Encoding"] = "gzip, deflate, br, zstd"
+		}
+		if version >= 128 {
+			values["Accept"] = "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"
+			values["Priority"] = "u=0, i"
+		}
+		values["Accept-Language"] = firefoxAcceptLanguage(id.locale)
+		values["Te"] = "trailers"
+	case "safari":
+		names = []string{"Accept", "User-Agent", "Accept-Language", "Accept-Encoding"}
+		if versionAtLeast(p.version, 16, 4) {
+			names = []string{"Accept", "Sec-Fetch-Site", "Sec-Fetch-Dest", "Accept-Language", "Sec-Fetch-Mode", "User-Agent", "Accept-Encoding", "Priority"}
+		}
+		values["Accept"] = "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"
+		values["Accept-Encoding"] = "gzip, deflate, br"
+		values["Accept-Language"] = safariAcceptLanguage(id.locale)
+		if versionAtLeast(p.version, 18, 0) {
+			values["Priority"] = "u=0, i"
+		}
+	}
+	values["Upgrade-Insecure-Requests"] = "1"
+	values["Sec-Fetch-Site"] = "none"
+	values["Sec-Fetch-Mode"] = "navigate"
+	values["Sec-Fetch-User"] = "?1"
+	values["Sec-Fetch-Dest"] = "document"
+
+	id.headers = buildHeaders(names, values, p.headers, p.headerOrder)
+	return id, nil
+}
+
+// chPlatforms 操作系统在 Sec-Ch-Ua-Platform 中的名称
+var chPlatforms = map[string]string{
+	osWindows: "Windows",
+	osMacOS:   "macOS",
+	osLinux:   "Linux",
+}
+
+// platformToken 返回浏览器在 User-Agent 中使用的操作系统平台标识，iOS 的标识由 User-Agent 模板决定
+//
+// 各浏览器已冻结平台标识中的系统版本（如 macOS 始终为 10_15_7），与真实浏览器保持一致。
+func platformToken(browser, os string, randomize bool, rng *rand.Rand) string {
+	switch os {
+	case osWindows:
+		return "Windows NT 10.0; Win64; x64"
+	case osMacOS:
+		if browser == "firefox" {
+			return "Macintosh; Intel Mac OS X 10.15"
+		}
+		return "Macintosh; Intel Mac OS X 10_15_7"
+	case osLinux:
+		if browser == "firefox" && randomize && rng.IntN(2) == 0 {
+			return "X11; Ubuntu; Linux x86_64"
+		}
+		return "X11; Linux x86_64"
+	}
+	return ""
+}
+
+// generateUserAgent 按浏览器、版本和平台标识生成 User-Agent
+func generateUserAgent(p fingerprintProfile, platform string) (string, error) {
+	major := majorVersion(p.version)
+	switch {
+	case p.browser == "chrome" && major > 0:
+		return fmt.Sprintf("Mozilla/5.0 (%s) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/%d.0.0.0 Safari/537.36", platform, major), nil
+	case p.browser == "edge" && major > 0:
+		return fmt.Sprintf("Mozilla/5.0 (%s) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/%d.0.0.0 Safari/537.36 Edg/%d.0.0.0", platform, major, major), nil
+	case p.browser == "opera" && major > 0:
+		chromium, ok := operaChromium[major]
+		if !ok {
+			chromium = major + 14
+		}
+		return fmt.Sprintf("Mozilla/5.0 (%s) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/%d.0.0.0 Safari/537.36 OPR/%d.0.0.0", platform, chromium, major), nil
+	case p.browser == "firefox" && major > 0:
+		return fmt.Sprintf("Mozilla/5.0 (%s; rv:%d.0) Gecko/20100101 Firefox/%d.0", platform, major, major), nil
+	case p.browser == "safari" && p.tablet:
+		return fmt.Sprintf("Mozilla/5.0 (iPad; CPU OS %s like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/%s Mobile/15E148 Safari/604.1",
+			strings.ReplaceAll(p.version, ".", "_"), p.version), nil
+	case p.browser == "safari" && p.mobile:
+		return fmt.Sprintf("Mozilla/5.0 (iPhone; CPU iPhone OS %s like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/%s Mobile/15E148 Safari/604.1",
+			strings.ReplaceAll(p.version, ".", "_"), p.version), nil
+	case p.browser == "safari":
+		return fmt.Sprintf("Mozilla/5.0 (%s) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/%s Safari/605.1.15", platform, p.version), nil
+	}
+	return "", fmt.Errorf("无法为指纹 %s 生成User-Agent：版本 %q 无效", p.id, p.version)
+}
+
+// secCHUA 按 Chromium 的算法生成 Sec-Ch-Ua：品牌列表由 GREASE 品牌、Chromium 和浏览器品牌组成，
+// GREASE 品牌的名称、版本和三者的顺序都由 Chromium 主版本号决定
+func secCHUA(browser string, version, chromium int) string {
+	brand := map[string]string{"chrome": "Google Chrome", "edge": "Microsoft Edge", "opera": "Opera"}[browser]
+	if browser == "chrome" {
+		version = chromium
+	}
+
+	// Chromium 105 之前使用固定的 GREASE 品牌
+	grease, greaseVersion := " Not A;Brand", "99"
+	order := []int{0, 1, 2}
+	switch {
+	case chromium == 103:
+		grease, order = ".Not/A)Brand", []int{0, 2, 1}
+	case chromium == 104:
+		order = []int{1, 0, 2}
+	case chromium >= 105:
+		chars := []string{" ", "(", ":", "-", ".", "/", ")", ";", "=", "?", "_"}
+		grease = "Not" + chars[chromium%len(chars)] + "A" + chars[(chromium+1)%len(chars)] + "Brand"
+		greaseVersion = []string{"8", "99", "24"}[chromium%3]
+		order = [][]int{{0, 1, 2}, {0, 2, 1}, {1, 0, 2}, {1, 2, 0}, {2, 0, 1}, {2, 1, 0}}[chromium%6]
+	}
+
+	brands := make([]string, 3)
+	brands[order[0]] = fmt.Sprintf("%q;v=%q", grease, greaseVersion)
+	brands[order[1]] = fmt.Sprintf("%q;v=\"%d\"", "Chromium", chromium)
+	brands[order[2]] = fmt.Sprintf("%q;v=\"%d\"", brand, version)
+	return strings.Join(brands, ", ")
+}
+
+// chromiumAcceptLanguage Chromium 系浏览器的 Accept-Language，如 de-DE,de;q=0.9,en-US;q=0.8,en;q=0.7
+func chromiumAcceptLanguage(locale string) string {
+	lang, _, _ := strings.Cut(locale, "-")
+	switch {
+	case locale == "en-US":
+		return "en-US,en;q=0.9"
+	case lang == "en":
+		return locale + ",en-US;q=0.9,en;q=0.8"
+	}
+	return locale + "," + lang + ";q=0.9,en-US;q=0.8,en;q=0.7"
+}
+
+// firefoxAcceptLanguage Firefox 的 Accept-Language，如 de,en-US;q=0.7,en;q=0.3
+func firefoxAcceptLanguage(locale string) string {
+	lang, _, _ := strings.Cut(locale, "-")
+	if lang == "en" {
+		return locale + ",en;q=0.5"
+	}
+	return lang + ",en-US;q=0.7,en;q=0.3"
+}
+
+// safariAcceptLanguage Safari 的 Accept-Language，如 de-DE,de;q=0.9
+func safariAcceptLanguage(locale string) string {
+	lang, _, _ := strings.Cut(locale, "-")
+	return locale + "," + lang + ";q=0.9"
+}
+
+// buildHeaders 按浏览器的发送顺序 names 生成请求头，合并指纹库中的自定义请求头，跳过空值
+//
+// 自定义请求头覆盖同名请求头，不在 names 中的按名称排序排在后面；order 中列出的请求头排在最前面。
+func buildHeaders(names []string, values, custom map[string]string, order []string) []HeaderField {
+	names = slices.Clone(names)
+	var extra []string
+	for name, value := range custom {
+		if !slices.Contains(names, name) {
+			extra = append(extra, name)
+		}
+		values[name] = value
+	}
+	sort.Strings(extra)
+	names = append(names, extra...)
+	if len(order) > 0 {
+		rank := func(name string) int {
+			if i := slices.Index(order, strings.ToLower(name)); i >= 0 {
+				return i
+			}
+			return len(order)
+		}
+		sort.SliceStable(names, func(i, j int) bool { return rank(names[i]) < rank(names[j]) })
+	}
+
+	fields := make([]HeaderField, 0, len(names))
+	for _, name := range names {
+		if values[name] != "" {
+			fields = append(fields, HeaderField{Name: name, Value: values[name]})
+		}
+	}
+	return fields
+}
+
+// chromiumMajor 返回 User-Agent 中的 Chromium 主版本号，没有时返回0
+func chromiumMajor(ua string) int {
+	m := chromeVersionPattern.FindStringSubmatch(ua)
+	if m == nil {
+		return 0
+	}
+	n, _ := strconv.Atoi(m[1])
+	return n
+}
+
+// majorVersion 返回版本号的主版本，无效时返回0
+func majorVersion(version string) int {
+	major, _, _ := strings.Cut(version, ".")
+	n, _ := strconv.Atoi(major)
+	return n
+}
+
+// versionAtLeast 判断版本号是否不低于 major.minor
+func versionAtLeast(version string, major, minor int) bool {
+	parts := strings.Split(version, ".")
+	v := make([]int, 2)
+	for i := 0; i < len(parts) && i < 2; i++ {
+		v[i], _ = strconv.Atoi(parts[i])
+	}
+	return v[0] > major || (v[0] == major && v[1] >= minor)
+}
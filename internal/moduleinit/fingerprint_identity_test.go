@@ -0,0 +1,97 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package moduleinit
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// headerValue 返回请求头的值
+func headerValue(headers []HeaderField, name string) (string, bool) {
+	for _, h := range headers {
+		if h.Name == name {
+			return h.Value, true
+		}
+	}
+	return "", false
+}
+
+func TestSecCHUA(t *testing.T) {
+	tests := []struct {
+		browser  string
+		version  int
+		chromium int
+		want     string
+	}{
+		{"chrome", 102, 102, `" Not A;Brand";v="99", "Chromium";v="102", "Google Chrome";v="102"`},
+		{"chrome", 103, 103, `".Not/A)Brand";v="99", "Google Chrome";v="103", "Chromium";v="103"`},
+		{"chrome", 104, 104, `"Chromium";v="104", " Not A;Brand";v="99", "Google Chrome";v="104"`},
+		{"chrome", 124, 124, `"Chromium";v="124", "Google Chrome";v="124", "Not-A.Brand";v="99"`},
+		{"chrome", 133, 133, `"Not(A:Brand";v="99", "Google Chrome";v="133", "Chromium";v="133"`},
+		{"edge", 131, 131, `"Microsoft Edge";v="131", "Chromium";v="131", "Not_A Brand";v="24"`},
+		{"opera", 91, 105, `"Opera";v="91", "Not)A;Brand";v="8", "Chromium";v="105"`},
+	}
+	for _, tt := range tests {
+		if got := secCHUA(tt.browser, tt.version, tt.chromium); got != tt.want {
+			t.Errorf("secCHUA(%s, %d, %d) = %s，want %s", tt.browser, tt.version, tt.chromium, got, tt.want)
+		}
+	}
+}
+
+// Sec-Ch-Ua 中的 Chromium 和浏览器品牌版本必须与 User-Agent 一致
+func TestIdentitySecCHUAMatchesUserAgent(t *testing.T) {
+	brands := map[string]string{"chrome": "Google Chrome", "edge": "Microsoft Edge", "opera": "Opera"}
+	uaTokens := map[string]string{"chrome": "Chrome/", "edge": "Edg/", "opera": "OPR/"}
+	rng := rand.New(rand.NewPCG(1, 1))
+
+	profiles := builtinProfiles()
+	custom, err := newCustomProfile(libraryFingerprint{
+		ID: "chrome_custom_ua", Base: "chrome_124",
+		UserAgent: "Mozilla/5.0 ({os}) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/125.0.6422.60 Safari/537.36",
+	})
+	if err != nil {
+		t.Fatalf("newCustomProfile: %v", err)
+	}
+	profiles = append(profiles, custom)
+
+	for _, p := range profiles {
+		brand, ok := brands[p.browser]
+		if !ok {
+			continue
+		}
+		for _, os := range p.oses {
+			for _, randomize := range []bool{false, true} {
+				id, err := newIdentity(p, os, randomize, rng)
+				if err != nil {
+					t.Fatalf("newIdentity(%s, %s): %v", p.id, os, err)
+				}
+				chua, ok := headerValue(id.headers, "Sec-Ch-Ua")
+				if !ok {
+					t.Fatalf("%s/%s 没有 Sec-Ch-Ua", p.id, os)
+				}
+
+				chromium := chromiumMajor(id.userAgent)
+				if want := fmt.Sprintf(`"Chromium";v="%d"`, chromium); chromium == 0 || !strings.Contains(chua, want) {
+					t.Errorf("%s/%s: Sec-Ch-Ua %s 与 User-Agent %s 的 Chromium 版本不一致", p.id, os, chua, id.userAgent)
+				}
+				_, after, _ := strings.Cut(id.userAgent, uaTokens[p.browser])
+				major, _, _ := strings.Cut(after, ".")
+				if want := fmt.Sprintf(`%q;v="%s"`, brand, major); major == "" || !strings.Contains(chua, want) {
+					t.Errorf("%s/%s: Sec-Ch-Ua %s 中没有 User-Agent %s 的品牌版本 %s", p.id, os, chua, id.userAgent, want)
+				}
+				if platform, _ := headerValue(id.headers, "Sec-Ch-Ua-Platform"); platform != strconv.Quote(chPlatforms[os]) {
+					t.Errorf("%s/%s: Sec-Ch-Ua-Platform %s，want %q", p.id, os, platform, chPlatforms[os])
+				}
+				if ua, _ := headerValue(id.headers, "User-Agent"); ua != id.userAgent {
+					t.Errorf("%s/%s: User-Agent 请求头 %s 与生成的 %s 不一致", p.id, os, ua, id.userAgent)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,366 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package moduleinit
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/vistone/fingerprint"
+)
+
+// 指纹可搭配的操作系统
+const (
+	osWindows = "windows"
+	osMacOS   = "macos"
+	osLinux   = "linux"
+	osIOS     = "ios"
+)
+
+// desktopOSes 桌面浏览器指纹可搭配的操作系统
+var desktopOSes = []string{osWindows, osMacOS, osLinux}
+
+// compatibleOSes 返回浏览器可搭配的操作系统：移动端只有 Safari（iOS），桌面 Safari 只有 macOS
+func compatibleOSes(browser string, mobile bool) []string {
+	switch {
+	case mobile:
+		return []string{osIOS}
+	case browser == "safari":
+		return []string{osMacOS}
+	}
+	return desktopOSes
+}
+
+// fingerprintProfile 指纹库中的一个指纹
+type fingerprintProfile struct {
+	id      string   // 指纹ID，如 chrome_133、safari_ios_17_0
+	browser string   // 浏览器：chrome, firefox, safari, edge, opera
+	version string   // 浏览器版本，如 133、17.0
+	mobile  bool     // 是否为移动端指纹
+	tablet  bool     // 是否为平板（iPad）指纹
+	oses    []string // 可搭配的操作系统
+	profile fingerprint.ClientProfile
+
+	source      string            // 定义该指纹的自定义指纹库文件，内置指纹为空
+	userAgent   string            // 自定义 User-Agent，{os} 替换为操作系统的平台标识，为空时按浏览器、版本和操作系统生成
+	headers     map[string]string // 自定义默认请求头，覆盖生成的同名请求头，值为空表示不发送
+	headerOrder []string          // 自定义请求头顺序（小写），未列出的请求头按默认顺序排在后面
+}
+
+// builtinProfiles 内置指纹库，按指纹ID排序
+var builtinProfiles = sync.OnceValue(func() []fingerprintProfile {
+	profiles := make([]fingerprintProfile, 0, len(fingerprint.MappedTLSClients))
+	for id, profile := range fingerprint.MappedTLSClients {
+		p, ok := parseProfileID(id)
+		if !ok {
+			continue
+		}
+		p.profile = profile
+		profiles = append(profiles, p)
+	}
+	sort.Slice(profiles, func(i, j int) bool { return profiles[i].id < profiles[j].id })
+	return profiles
+})
+
+// parseProfileID 从指纹ID解析浏览器、版本和可搭配的操作系统
+//
+// 指纹ID形如 <浏览器>_<版本>[_变体]，Safari 的移动端指纹为 safari_ios_<版本> 和 safari_ipad_<版本>。
+func parseProfileID(id string) (fingerprintProfile, bool) {
+	browser, rest, ok := strings.Cut(strings.ToLower(id), "_")
+	if !ok || rest == "" {
+		return fingerprintProfile{}, false
+	}
+	p := fingerprintProfile{id: id, browser: browser}
+	switch browser {
+	case "chrome", "firefox", "opera":
+		// 版本号之后是 PSK、PQ 等变体标记
+		p.version, _, _ = strings.Cut(rest, "_")
+		p.oses = desktopOSes
+	case "safari":
+		if device, version, ok := strings.Cut(rest, "_"); ok && (device == "ios" || device == "ipad") {
+			p.mobile = true
+			p.tablet = device == "ipad"
+			p.oses = []string{osIOS}
+			rest = version
+		} else {
+			p.oses = []string{osMacOS}
+		}
+		p.version = strings.ReplaceAll(rest, "_", ".")
+	default:
+		return fingerprintProfile{}, false
+	}
+	return p, true
+}
+
+// filterProfiles 按 browsers 和 operating_systems 过滤指纹，空列表表示不限制
+//
+// 保留的指纹只搭配允许的操作系统；没有可搭配操作系统的指纹被排除。
+func filterProfiles(profiles []fingerprintProfile, browsers, oses []string) []fingerprintProfile {
+	var filtered []fingerprintProfile
+	for _, p := range profiles {
+		if len(browsers) > 0 && !slices.Contains(browsers, p.browser) {
+			continue
+		}
+		if len(oses) > 0 {
+			var allowed []string
+			for _, name := range p.oses {
+				if slices.Contains(oses, name) {
+					allowed = append(allowed, name)
+				}
+			}
+			if len(allowed) == 0 {
+				continue
+			}
+			p.oses = allowed
+		}
+		filtered = append(filtered, p)
+	}
+	return filtered
+}
+
+// missingBrowsers 返回配置了但过滤后没有任何指纹的浏览器
+func missingBrowsers(browsers []string, profiles []fingerprintProfile) []string {
+	var missing []string
+	for _, b := range browsers {
+		if !slices.ContainsFunc(profiles, func(p fingerprintProfile) bool { return p.browser == b }) {
+			missing = append(missing, b)
+		}
+	}
+	return missing
+}
+
+// libraryFile 自定义指纹库文件（TOML 或 JSON）
+type libraryFile struct {
+	Fingerprints []libraryFingerprint `toml:"fingerprints" json:"fingerprints"`
+}
+
+// libraryFingerprint 自定义指纹库中的一个指纹
+//
+// 设置 base 时以该内置指纹为基础，只覆盖给出的部分；不设置 base 时 tls 和 http2.settings 必须给出。
+type libraryFingerprint struct {
+	ID               string            `toml:"id" json:"id"`                               // 指纹ID，与内置指纹相同时替换内置指纹
+	Base             string            `toml:"base" json:"base"`                           // 作为基础的内置指纹ID
+	Browser          string            `toml:"browser" json:"browser"`                     // 浏览器：chrome, firefox, safari, edge, opera
+	Version          string            `toml:"version" json:"version"`                     // 浏览器版本
+	Mobile           *bool             `toml:"mobile" json:"mobile"`                       // 是否为移动端指纹
+	OperatingSystems []string          `toml:"operating_systems" json:"operating_systems"` // 可搭配的操作系统：windows, macos, linux, ios
+	UserAgent        string            `toml:"user_agent" json:"user_agent"`               // User-Agent，{os} 替换为操作系统的平台标识，为空时自动生成
+	Headers          map[string]string `toml:"headers" json:"headers"`                     // 默认请求头，覆盖生成的同名请求头，值为空表示不发送
+	HeaderOrder      []string          `toml:"header_order" json:"header_order"`           // 请求头发送顺序
+	TLS              *libraryTLS       `toml:"tls" json:"tls"`                             // ClientHello 参数，给出时整体替换 base 的 ClientHello
+	HTTP2            *libraryHTTP2     `toml:"http2" json:"http2"`                         // HTTP/2 参数，给出的字段覆盖 base 的对应参数
+}
+
+// libraryBrowsers 自定义指纹可用的浏览器
+var libraryBrowsers = []string{"chrome", "firefox", "safari", "edge", "opera"}
+
+// fileStamp 指纹库文件的修改时间和大小，用于发现文件变化
+type fileStamp struct {
+	path    string
+	modTime time.Time
+	size    int64
+}
+
+// statLibrary 返回指纹库包含的文件：path 为文件时即该文件，为目录时是其中的 .toml 和 .json 文件（按文件名排序）
+func statLibrary(path string) ([]fileStamp, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取指纹库失败: %w", err)
+	}
+	if !info.IsDir() {
+		return []fileStamp{{path: path, modTime: info.ModTime(), size: info.Size()}}, nil
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取指纹库目录失败: %w", err)
+	}
+	var stamps []fileStamp
+	for _, e := range entries {
+		ext := strings.ToLower(filepath.Ext(e.Name()))
+		if e.IsDir() || (ext != ".toml" && ext != ".json") {
+			continue
+		}
+		fi, err := e.Info()
+		if err != nil {
+			return nil, fmt.Errorf("读取指纹库文件失败: %w", err)
+		}
+		stamps = append(stamps, fileStamp{path: filepath.Join(path, e.Name()), modTime: fi.ModTime(), size: fi.Size()})
+	}
+	return stamps, nil
+}
+
+// loadLibrary 读取并校验 stamps 中的指纹库文件，指纹ID在所有文件中必须唯一
+func loadLibrary(stamps []fileStamp) ([]fingerprintProfile, error) {
+	var profiles []fingerprintProfile
+	defined := make(map[string]string) // 指纹ID -> 文件
+	for _, st := range stamps {
+		file, err := readLibraryFile(st.path)
+		if err != nil {
+			return nil, err
+		}
+		for i, e := range file.Fingerprints {
+			p, err := newCustomProfile(e)
+			if err != nil {
+				return nil, fmt.Errorf("指纹库文件 %s 中的第 %d 个指纹 %q 无效: %w", st.path, i+1, e.ID, err)
+			}
+			if prev, ok := defined[p.id]; ok {
+				return nil, fmt.Errorf("指纹库文件 %s 中的指纹 %q 与 %s 中的重复", st.path, p.id, prev)
+			}
+			defined[p.id] = st.path
+			p.source = st.path
+			profiles = append(profiles, p)
+		}
+	}
+	return profiles, nil
+}
+
+// readLibraryFile 按扩展名解析 TOML 或 JSON 指纹库文件，拒绝未知字段
+func readLibraryFile(path string) (*libraryFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取指纹库文件失败: %w", err)
+	}
+	var file libraryFile
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&file)
+	} else {
+		decoder := toml.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&file)
+		var missing *toml.StrictMissingError
+		if errors.As(err, &missing) {
+			err = fmt.Errorf("存在未知字段:\n%s", missing.String())
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("解析指纹库文件 %s 失败: %w", path, err)
+	}
+	return &file, nil
+}
+
+// newCustomProfile 校验自定义指纹并生成指纹库条目
+func newCustomProfile(e libraryFingerprint) (fingerprintProfile, error) {
+	if e.ID == "" {
+		return fingerprintProfile{}, fmt.Errorf("id 不能为空")
+	}
+	p := fingerprintProfile{id: e.ID}
+	var base fingerprint.ClientProfile
+	if e.Base != "" {
+		i := slices.IndexFunc(builtinProfiles(), func(b fingerprintProfile) bool { return b.id == e.Base })
+		if i < 0 {
+			return fingerprintProfile{}, fmt.Errorf("base: 内置指纹库中没有 %q", e.Base)
+		}
+		b := builtinProfiles()[i]
+		p.browser, p.version, p.mobile, p.tablet, p.oses = b.browser, b.version, b.mobile, b.tablet, b.oses
+		base = b.profile
+	}
+
+	if e.Browser != "" {
+		p.browser = e.Browser
+	}
+	if !slices.Contains(libraryBrowsers, p.browser) {
+		return fingerprintProfile{}, fmt.Errorf("browser 必须是 %s 之一（当前值: %q）", strings.Join(libraryBrowsers, ", "), p.browser)
+	}
+	if e.Version != "" {
+		p.version = e.Version
+	}
+	if p.version == "" {
+		return fingerprintProfile{}, fmt.Errorf("version 不能为空")
+	}
+	if e.Mobile != nil && *e.Mobile != p.mobile {
+		p.mobile, p.tablet = *e.Mobile, false
+	}
+	if p.mobile && p.browser != "safari" {
+		return fingerprintProfile{}, fmt.Errorf("移动端指纹只支持 safari（当前: %s）", p.browser)
+	}
+	compatible := compatibleOSes(p.browser, p.mobile)
+	switch {
+	case len(e.OperatingSystems) > 0:
+		p.oses = e.OperatingSystems
+	case e.Base == "" || e.Browser != "" || e.Mobile != nil:
+		p.oses = compatible
+	}
+	for i, name := range p.oses {
+		if !slices.Contains(compatible, name) {
+			return fingerprintProfile{}, fmt.Errorf("operating_systems[%d] 必须是 %s 之一（当前值: %q）", i, strings.Join(compatible, ", "), name)
+		}
+	}
+
+	// User-Agent 必须与浏览器版本和操作系统一致，不给出时按浏览器、版本和操作系统生成
+	p.userAgent = e.UserAgent
+	switch {
+	case p.userAgent == "":
+		if _, err := generateUserAgent(p, ""); err != nil {
+			return fingerprintProfile{}, err
+		}
+	case p.browser != "firefox" && p.browser != "safari" && chromiumMajor(p.userAgent) == 0:
+		return fingerprintProfile{}, fmt.Errorf("user_agent 中没有 Chrome/<版本>，无法生成 %s 的 Sec-Ch-Ua", p.browser)
+	case strings.Contains(p.userAgent, "{os}") && slices.Contains(p.oses, osIOS):
+		return fingerprintProfile{}, fmt.Errorf("user_agent 含 {os} 时不能搭配 ios，iOS 的 User-Agent 需要完整写出")
+	case p.userAgent != "" && !strings.Contains(p.userAgent, "{os}") && len(p.oses) > 1:
+		return fingerprintProfile{}, fmt.Errorf("user_agent 不含 {os} 时只能搭配一个操作系统（当前: %v）", p.oses)
+	}
+
+	if len(e.Headers) > 0 {
+		p.headers = make(map[string]string, len(e.Headers))
+		for name, value := range e.Headers {
+			p.headers[textproto.CanonicalMIMEHeaderKey(name)] = value
+		}
+	}
+	for _, name := range e.HeaderOrder {
+		p.headerOrder = append(p.headerOrder, strings.ToLower(name))
+	}
+
+	helloID := base.GetClientHelloId()
+	if e.TLS != nil {
+		id, err := buildClientHelloID(e.ID, e.TLS)
+		if err != nil {
+			return fingerprintProfile{}, err
+		}
+		helloID = id
+	} else if e.Base == "" {
+		return fingerprintProfile{}, fmt.Errorf("没有 base 时 tls 不能为空")
+	}
+	profile, err := buildClientProfile(helloID, base, e.HTTP2)
+	if err != nil {
+		return fingerprintProfile{}, err
+	}
+	p.profile = profile
+	return p, nil
+}
+
+// mergeProfiles 合并内置指纹和自定义指纹，ID 相同时自定义指纹替换内置指纹，结果按指纹ID排序
+func mergeProfiles(builtin, custom []fingerprintProfile) (merged []fingerprintProfile, replaced []string) {
+	byID := make(map[string]fingerprintProfile, len(builtin)+len(custom))
+	for _, p := range builtin {
+		byID[p.id] = p
+	}
+	for _, p := range custom {
+		if _, ok := byID[p.id]; ok {
+			replaced = append(replaced, p.id)
+		}
+		byID[p.id] = p
+	}
+	merged = make([]fingerprintProfile, 0, len(byID))
+	for _, p := range byID {
+		merged = append(merged, p)
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i].id < merged[j].id })
+	sort.Strings(replaced)
+	return merged, replaced
+}
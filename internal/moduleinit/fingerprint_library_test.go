@@ -0,0 +1,161 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package moduleinit
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestNewCustomProfile(t *testing.T) {
+	mobile, desktop := true, false
+	minimalTLS := func() *libraryTLS {
+		return &libraryTLS{CipherSuites: []string{"TLS_AES_128_GCM_SHA256"}, Extensions: []string{"server_name"}}
+	}
+	minimalHTTP2 := &libraryHTTP2{Settings: map[string]uint32{"INITIAL_WINDOW_SIZE": 6291456}}
+
+	tests := []struct {
+		name    string
+		entry   libraryFingerprint
+		wantErr string
+		check   func(t *testing.T, p fingerprintProfile)
+	}{
+		{
+			name:  "以内置指纹为基础覆盖版本和操作系统",
+			entry: libraryFingerprint{ID: "chrome_custom", Base: "chrome_133", Version: "134", OperatingSystems: []string{"linux"}},
+			check: func(t *testing.T, p fingerprintProfile) {
+				if p.browser != "chrome" || p.version != "134" || !slices.Equal(p.oses, []string{"linux"}) {
+					t.Errorf("指纹 %s %s %v，want chrome 134 [linux]", p.browser, p.version, p.oses)
+				}
+			},
+		},
+		{
+			name:  "没有 base 时完整给出 tls 和 http2",
+			entry: libraryFingerprint{ID: "firefox_custom", Browser: "firefox", Version: "136", TLS: minimalTLS(), HTTP2: minimalHTTP2},
+			check: func(t *testing.T, p fingerprintProfile) {
+				if !slices.Equal(p.oses, desktopOSes) {
+					t.Errorf("可搭配的操作系统 %v，want %v", p.oses, desktopOSes)
+				}
+			},
+		},
+		{
+			name:    "缺少 id",
+			entry:   libraryFingerprint{Base: "chrome_133"},
+			wantErr: "id 不能为空",
+		},
+		{
+			name:    "base 不存在",
+			entry:   libraryFingerprint{ID: "x", Base: "chrome_1"},
+			wantErr: `base: 内置指纹库中没有 "chrome_1"`,
+		},
+		{
+			name:    "不支持的浏览器",
+			entry:   libraryFingerprint{ID: "x", Browser: "ie", Version: "11"},
+			wantErr: "browser 必须是 chrome, firefox, safari, edge, opera 之一",
+		},
+		{
+			name:    "缺少版本",
+			entry:   libraryFingerprint{ID: "x", Browser: "chrome"},
+			wantErr: "version 不能为空",
+		},
+		{
+			name:    "版本无法生成 User-Agent",
+			entry:   libraryFingerprint{ID: "x", Browser: "chrome", Version: "latest", TLS: minimalTLS()},
+			wantErr: "无法为指纹 x 生成User-Agent",
+		},
+		{
+			name:    "移动端指纹不是 safari",
+			entry:   libraryFingerprint{ID: "x", Base: "chrome_133", Mobile: &mobile},
+			wantErr: "移动端指纹只支持 safari",
+		},
+		{
+			name:    "操作系统与浏览器不匹配",
+			entry:   libraryFingerprint{ID: "x", Base: "safari_16_0", OperatingSystems: []string{"macos", "windows"}},
+			wantErr: `operating_systems[1] 必须是 macos 之一（当前值: "windows"）`,
+		},
+		{
+			name:    "把移动端 base 改为桌面端时重新推导操作系统",
+			entry:   libraryFingerprint{ID: "x", Base: "safari_ios_17_0", Mobile: &desktop, OperatingSystems: []string{"ios"}},
+			wantErr: `operating_systems[0] 必须是 macos 之一（当前值: "ios"）`,
+		},
+		{
+			name:    "Chromium 系 User-Agent 中没有版本",
+			entry:   libraryFingerprint{ID: "x", Base: "chrome_133", UserAgent: "Mozilla/5.0 ({os}) Safari/537.36"},
+			wantErr: "user_agent 中没有 Chrome/<版本>",
+		},
+		{
+			name:    "iOS User-Agent 使用 {os}",
+			entry:   libraryFingerprint{ID: "x", Base: "safari_ios_17_0", UserAgent: "Mozilla/5.0 ({os}) Version/17.0 Mobile/15E148 Safari/604.1"},
+			wantErr: "user_agent 含 {os} 时不能搭配 ios",
+		},
+		{
+			name:    "固定 User-Agent 搭配多个操作系统",
+			entry:   libraryFingerprint{ID: "x", Base: "firefox_135", UserAgent: "Mozilla/5.0 (X11; Linux x86_64; rv:135.0) Gecko/20100101 Firefox/135.0"},
+			wantErr: "user_agent 不含 {os} 时只能搭配一个操作系统",
+		},
+		{
+			name:    "没有 base 也没有 tls",
+			entry:   libraryFingerprint{ID: "x", Browser: "firefox", Version: "136", HTTP2: minimalHTTP2},
+			wantErr: "没有 base 时 tls 不能为空",
+		},
+		{
+			name:    "没有 base 也没有 http2.settings",
+			entry:   libraryFingerprint{ID: "x", Browser: "firefox", Version: "136", TLS: minimalTLS()},
+			wantErr: "http2.settings 不能为空",
+		},
+		{
+			name:    "tls 缺少密码套件",
+			entry:   libraryFingerprint{ID: "x", Base: "chrome_133", TLS: &libraryTLS{Extensions: []string{"server_name"}}},
+			wantErr: "tls.cipher_suites 不能为空",
+		},
+		{
+			name: "tls 扩展不支持",
+			entry: libraryFingerprint{ID: "x", Base: "chrome_133", TLS: &libraryTLS{
+				CipherSuites: []string{"TLS_AES_128_GCM_SHA256"}, Extensions: []string{"server_name", "heartbeat"}}},
+			wantErr: `tls.extensions[1]: 不支持的扩展 "heartbeat"`,
+		},
+		{
+			name: "tls 扩展重复",
+			entry: libraryFingerprint{ID: "x", Base: "chrome_133", TLS: &libraryTLS{
+				CipherSuites: []string{"TLS_AES_128_GCM_SHA256"}, Extensions: []string{"GREASE", "server_name", "GREASE", "server_name"}}},
+			wantErr: `tls.extensions[3]: 扩展 "server_name" 重复`,
+		},
+		{
+			name: "使用 supported_groups 但没有曲线",
+			entry: libraryFingerprint{ID: "x", Base: "chrome_133", TLS: &libraryTLS{
+				CipherSuites: []string{"TLS_AES_128_GCM_SHA256"}, Extensions: []string{"supported_groups"}}},
+			wantErr: "使用 supported_groups 或 key_share 扩展时 tls.curves 不能为空",
+		},
+		{
+			name:    "未知的 HTTP/2 参数",
+			entry:   libraryFingerprint{ID: "x", Base: "chrome_133", HTTP2: &libraryHTTP2{Settings: map[string]uint32{"WINDOW": 1}}},
+			wantErr: `http2.settings: 未知的参数 "WINDOW"`,
+		},
+		{
+			name:    "伪头顺序不完整",
+			entry:   libraryFingerprint{ID: "x", Base: "chrome_133", HTTP2: &libraryHTTP2{PseudoHeaderOrder: []string{":method", ":path"}}},
+			wantErr: "http2.pseudo_header_order 必须是",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := newCustomProfile(tt.entry)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("newCustomProfile error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("newCustomProfile: %v", err)
+			}
+			if p.id != tt.entry.ID {
+				t.Errorf("指纹ID %s，want %s", p.id, tt.entry.ID)
+			}
+			tt.check(t, p)
+		})
+	}
+}
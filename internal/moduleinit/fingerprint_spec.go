@@ -0,0 +1,375 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package moduleinit
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+
+	"github.com/bogdanfinn/fhttp/http2"
+	tls "github.com/bogdanfinn/utls"
+	"github.com/bogdanfinn/utls/dicttls"
+	"github.com/vistone/fingerprint"
+)
+
+// libraryTLS 自定义指纹的 ClientHello 参数
+//
+// 名称使用 IANA 注册名（如 TLS_AES_128_GCM_SHA256、x25519、ecdsa_secp256r1_sha256），
+// 也可以写成十进制或 0x 开头的十六进制数值；GREASE 表示随机 GREASE 值。
+type libraryTLS struct {
+	CipherSuites         []string `toml:"cipher_suites" json:"cipher_suites"`                   // 密码套件，按发送顺序
+	Extensions           []string `toml:"extensions" json:"extensions"`                         // 扩展，按发送顺序，可选值见 tlsExtensionNames
+	Curves               []string `toml:"curves" json:"curves"`                                 // supported_groups 扩展中的曲线
+	KeyShares            []string `toml:"key_shares" json:"key_shares"`                         // key_share 扩展中的曲线，默认为第一个非 GREASE 曲线
+	SignatureAlgorithms  []string `toml:"signature_algorithms" json:"signature_algorithms"`     // signature_algorithms 和 delegated_credentials 扩展中的签名算法
+	ALPN                 []string `toml:"alpn" json:"alpn"`                                     // ALPN 协议，默认 h2、http/1.1
+	ALPS                 []string `toml:"alps" json:"alps"`                                     // application_settings 扩展中的协议，默认 h2
+	Versions             []string `toml:"versions" json:"versions"`                             // supported_versions 扩展中的版本，可选 1.0-1.3、GREASE，默认 1.3、1.2
+	CertCompression      []string `toml:"cert_compression" json:"cert_compression"`             // compress_certificate 扩展中的算法：zlib, brotli, zstd，默认 brotli
+	RandomExtensionOrder bool     `toml:"random_extension_order" json:"random_extension_order"` // 每次连接随机打乱扩展顺序（Chrome 106+ 的行为）
+}
+
+// libraryHTTP2 自定义指纹的 HTTP/2 参数
+type libraryHTTP2 struct {
+	Settings          map[string]uint32 `toml:"settings" json:"settings"`                       // SETTINGS 帧参数，键为 HEADER_TABLE_SIZE 等名称
+	SettingsOrder     []string          `toml:"settings_order" json:"settings_order"`           // SETTINGS 参数的发送顺序，默认按参数ID排序
+	PseudoHeaderOrder []string          `toml:"pseudo_header_order" json:"pseudo_header_order"` // 伪头顺序，如 [":method", ":authority", ":scheme", ":path"]
+	ConnectionFlow    uint32            `toml:"connection_flow" json:"connection_flow"`         // 连接级 WINDOW_UPDATE 增量
+}
+
+// tlsExtensionNames 自定义指纹支持的 TLS 扩展名称
+var tlsExtensionNames = []string{
+	"GREASE",
+	"server_name",
+	"extended_master_secret",
+	"renegotiation_info",
+	"supported_groups",
+	"ec_point_formats",
+	"session_ticket",
+	"application_layer_protocol_negotiation",
+	"status_request",
+	"signature_algorithms",
+	"signed_certificate_timestamp",
+	"key_share",
+	"psk_key_exchange_modes",
+	"supported_versions",
+	"compress_certificate",
+	"application_settings",
+	"application_settings_new",
+	"delegated_credentials",
+	"record_size_limit",
+	"encrypted_client_hello",
+	"padding",
+	"pre_shared_key",
+}
+
+// extraCurveNames IANA 注册表之外的曲线（后量子混合密钥交换）
+var extraCurveNames = map[string]uint16{
+	"X25519MLKEM768":        uint16(tls.X25519MLKEM768),
+	"X25519Kyber768Draft00": uint16(tls.X25519Kyber768Draft00),
+}
+
+var tlsVersionNames = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+var http2SettingNames = map[string]http2.SettingID{
+	"HEADER_TABLE_SIZE":       http2.SettingHeaderTableSize,
+	"ENABLE_PUSH":             http2.SettingEnablePush,
+	"MAX_CONCURRENT_STREAMS":  http2.SettingMaxConcurrentStreams,
+	"INITIAL_WINDOW_SIZE":     http2.SettingInitialWindowSize,
+	"MAX_FRAME_SIZE":          http2.SettingMaxFrameSize,
+	"MAX_HEADER_LIST_SIZE":    http2.SettingMaxHeaderListSize,
+	"ENABLE_CONNECT_PROTOCOL": http2.SettingEnableConnectProtocol,
+	"NO_RFC7540_PRIORITIES":   http2.SettingNoRFC7540Priorities,
+}
+
+var pseudoHeaders = []string{":method", ":authority", ":scheme", ":path"}
+
+// clientHelloParams 校验后的 ClientHello 参数，每次握手由它生成新的 ClientHelloSpec
+type clientHelloParams struct {
+	cipherSuites        []uint16
+	extensions          []string
+	curves              []tls.CurveID
+	keyShares           []tls.CurveID
+	signatureAlgorithms []tls.SignatureScheme
+	alpn                []string
+	alps                []string
+	versions            []uint16
+	certCompression     []tls.CertCompressionAlgo
+}
+
+// buildClientHelloID 校验 ClientHello 参数并生成 ClientHelloID
+func buildClientHelloID(id string, c *libraryTLS) (tls.ClientHelloID, error) {
+	var p clientHelloParams
+	var err error
+	if len(c.CipherSuites) == 0 {
+		return tls.ClientHelloID{}, fmt.Errorf("tls.cipher_suites 不能为空")
+	}
+	if p.cipherSuites, err = parseCodes("tls.cipher_suites", c.CipherSuites, func(name string) (uint16, bool) {
+		v, ok := dicttls.DictCipherSuiteNameIndexed[name]
+		return v, ok
+	}); err != nil {
+		return tls.ClientHelloID{}, err
+	}
+
+	if len(c.Extensions) == 0 {
+		return tls.ClientHelloID{}, fmt.Errorf("tls.extensions 不能为空")
+	}
+	for i, name := range c.Extensions {
+		if !slices.Contains(tlsExtensionNames, name) {
+			return tls.ClientHelloID{}, fmt.Errorf("tls.extensions[%d]: 不支持的扩展 %q", i, name)
+		}
+		if name != "GREASE" && slices.Index(c.Extensions, name) != i {
+			return tls.ClientHelloID{}, fmt.Errorf("tls.extensions[%d]: 扩展 %q 重复", i, name)
+		}
+	}
+	p.extensions = c.Extensions
+	uses := func(name string) bool { return slices.Contains(c.Extensions, name) }
+
+	curves, err := parseCodes("tls.curves", c.Curves, lookupCurve)
+	if err != nil {
+		return tls.ClientHelloID{}, err
+	}
+	if len(curves) == 0 && (uses("supported_groups") || uses("key_share")) {
+		return tls.ClientHelloID{}, fmt.Errorf("使用 supported_groups 或 key_share 扩展时 tls.curves 不能为空")
+	}
+	p.curves = toCurveIDs(curves)
+
+	keyShares, err := parseCodes("tls.key_shares", c.KeyShares, lookupCurve)
+	if err != nil {
+		return tls.ClientHelloID{}, err
+	}
+	p.keyShares = toCurveIDs(keyShares)
+	if len(p.keyShares) == 0 {
+		for _, curve := range p.curves {
+			if curve != tls.GREASE_PLACEHOLDER {
+				p.keyShares = []tls.CurveID{curve}
+				break
+			}
+		}
+	}
+
+	sigs, err := parseCodes("tls.signature_algorithms", c.SignatureAlgorithms, func(name string) (uint16, bool) {
+		v, ok := dicttls.DictSignatureSchemeNameIndexed[name]
+		return v, ok
+	})
+	if err != nil {
+		return tls.ClientHelloID{}, err
+	}
+	if len(sigs) == 0 && (uses("signature_algorithms") || uses("delegated_credentials")) {
+		return tls.ClientHelloID{}, fmt.Errorf("使用 signature_algorithms 或 delegated_credentials 扩展时 tls.signature_algorithms 不能为空")
+	}
+	for _, s := range sigs {
+		p.signatureAlgorithms = append(p.signatureAlgorithms, tls.SignatureScheme(s))
+	}
+
+	p.alpn = valueOrDefault(c.ALPN, []string{"h2", "http/1.1"})
+	p.alps = valueOrDefault(c.ALPS, []string{"h2"})
+
+	if p.versions, err = parseCodes("tls.versions", valueOrDefault(c.Versions, []string{"1.3", "1.2"}), func(name string) (uint16, bool) {
+		v, ok := tlsVersionNames[name]
+		return v, ok
+	}); err != nil {
+		return tls.ClientHelloID{}, err
+	}
+
+	algos, err := parseCodes("tls.cert_compression", valueOrDefault(c.CertCompression, []string{"brotli"}), func(name string) (uint16, bool) {
+		v, ok := dicttls.DictCertificateCompressionAlgorithmNameIndexed[name]
+		return v, ok
+	})
+	if err != nil {
+		return tls.ClientHelloID{}, err
+	}
+	for _, a := range algos {
+		p.certCompression = append(p.certCompression, tls.CertCompressionAlgo(a))
+	}
+
+	return tls.ClientHelloID{
+		Client:               "Custom",
+		Version:              id,
+		RandomExtensionOrder: c.RandomExtensionOrder,
+		SpecFactory:          p.spec,
+	}, nil
+}
+
+// spec 生成 ClientHelloSpec；扩展对象在握手中会被修改，每次都新建
+func (p *clientHelloParams) spec() (tls.ClientHelloSpec, error) {
+	extensions := make([]tls.TLSExtension, 0, len(p.extensions))
+	for _, name := range p.extensions {
+		extensions = append(extensions, p.extension(name))
+	}
+	return tls.ClientHelloSpec{
+		CipherSuites:       slices.Clone(p.cipherSuites),
+		CompressionMethods: []byte{tls.CompressionNone},
+		Extensions:         extensions,
+	}, nil
+}
+
+func (p *clientHelloParams) extension(name string) tls.TLSExtension {
+	switch name {
+	case "server_name":
+		return &tls.SNIExtension{}
+	case "extended_master_secret":
+		return &tls.ExtendedMasterSecretExtension{}
+	case "renegotiation_info":
+		return &tls.RenegotiationInfoExtension{Renegotiation: tls.RenegotiateOnceAsClient}
+	case "supported_groups":
+		return &tls.SupportedCurvesExtension{Curves: slices.Clone(p.curves)}
+	case "ec_point_formats":
+		return &tls.SupportedPointsExtension{SupportedPoints: []byte{tls.PointFormatUncompressed}}
+	case "session_ticket":
+		return &tls.SessionTicketExtension{}
+	case "application_layer_protocol_negotiation":
+		return &tls.ALPNExtension{AlpnProtocols: slices.Clone(p.alpn)}
+	case "status_request":
+		return &tls.StatusRequestExtension{}
+	case "signature_algorithms":
+		return &tls.SignatureAlgorithmsExtension{SupportedSignatureAlgorithms: slices.Clone(p.signatureAlgorithms)}
+	case "signed_certificate_timestamp":
+		return &tls.SCTExtension{}
+	case "key_share":
+		shares := make([]tls.KeyShare, len(p.keyShares))
+		for i, curve := range p.keyShares {
+			shares[i] = tls.KeyShare{Group: curve}
+			if curve == tls.GREASE_PLACEHOLDER {
+				shares[i].Data = []byte{0}
+			}
+		}
+		return &tls.KeyShareExtension{KeyShares: shares}
+	case "psk_key_exchange_modes":
+		return &tls.PSKKeyExchangeModesExtension{Modes: []uint8{tls.PskModeDHE}}
+	case "supported_versions":
+		return &tls.SupportedVersionsExtension{Versions: slices.Clone(p.versions)}
+	case "compress_certificate":
+		return &tls.UtlsCompressCertExtension{Algorithms: slices.Clone(p.certCompression)}
+	case "application_settings":
+		return &tls.ApplicationSettingsExtension{SupportedProtocols: slices.Clone(p.alps)}
+	case "application_settings_new":
+		return &tls.ApplicationSettingsExtensionNew{SupportedProtocols: slices.Clone(p.alps)}
+	case "delegated_credentials":
+		return &tls.DelegatedCredentialsExtension{SupportedSignatureAlgorithms: slices.Clone(p.signatureAlgorithms)}
+	case "record_size_limit":
+		return &tls.FakeRecordSizeLimitExtension{Limit: 0x4001}
+	case "encrypted_client_hello":
+		return tls.BoringGREASEECH()
+	case "padding":
+		return &tls.UtlsPaddingExtension{GetPaddingLen: tls.BoringPaddingStyle}
+	case "pre_shared_key":
+		return &tls.UtlsPreSharedKeyExtension{}
+	default: // GREASE
+		return &tls.UtlsGREASEExtension{}
+	}
+}
+
+// buildClientProfile 用 HTTP/2 参数覆盖 base 的对应部分，生成指纹参数
+func buildClientProfile(helloID tls.ClientHelloID, base fingerprint.ClientProfile, h *libraryHTTP2) (fingerprint.ClientProfile, error) {
+	settings := base.GetSettings()
+	settingsOrder := base.GetSettingsOrder()
+	pseudoHeaderOrder := base.GetPseudoHeaderOrder()
+	connectionFlow := base.GetConnectionFlow()
+	if h != nil {
+		if len(h.Settings) > 0 {
+			settings = make(map[http2.SettingID]uint32, len(h.Settings))
+			for name, value := range h.Settings {
+				id, ok := http2SettingNames[name]
+				if !ok {
+					return fingerprint.ClientProfile{}, fmt.Errorf("http2.settings: 未知的参数 %q", name)
+				}
+				settings[id] = value
+			}
+			settingsOrder = nil
+			if len(h.SettingsOrder) == 0 {
+				for id := range settings {
+					settingsOrder = append(settingsOrder, id)
+				}
+				sort.Slice(settingsOrder, func(i, j int) bool { return settingsOrder[i] < settingsOrder[j] })
+			}
+		}
+		if len(h.SettingsOrder) > 0 {
+			settingsOrder = nil
+			for i, name := range h.SettingsOrder {
+				id, ok := http2SettingNames[name]
+				if !ok {
+					return fingerprint.ClientProfile{}, fmt.Errorf("http2.settings_order[%d]: 未知的参数 %q", i, name)
+				}
+				if _, ok := settings[id]; !ok {
+					return fingerprint.ClientProfile{}, fmt.Errorf("http2.settings_order[%d]: 参数 %s 没有在 http2.settings 中设置", i, name)
+				}
+				settingsOrder = append(settingsOrder, id)
+			}
+			if len(settingsOrder) != len(settings) {
+				return fingerprint.ClientProfile{}, fmt.Errorf("http2.settings_order 必须列出 http2.settings 中的所有参数")
+			}
+		}
+		if len(h.PseudoHeaderOrder) > 0 {
+			sorted := slices.Sorted(slices.Values(h.PseudoHeaderOrder))
+			if !slices.Equal(sorted, slices.Sorted(slices.Values(pseudoHeaders))) {
+				return fingerprint.ClientProfile{}, fmt.Errorf("http2.pseudo_header_order 必须是 %v 的一个排列", pseudoHeaders)
+			}
+			pseudoHeaderOrder = h.PseudoHeaderOrder
+		}
+		if h.ConnectionFlow > 0 {
+			connectionFlow = h.ConnectionFlow
+		}
+	}
+	if len(settings) == 0 {
+		return fingerprint.ClientProfile{}, fmt.Errorf("http2.settings 不能为空")
+	}
+	if len(pseudoHeaderOrder) == 0 {
+		pseudoHeaderOrder = pseudoHeaders
+	}
+	return fingerprint.NewClientProfile(helloID, settings, settingsOrder, pseudoHeaderOrder, connectionFlow,
+		base.GetPriorities(), base.GetHeaderPriority()), nil
+}
+
+// parseCodes 将名称列表转换为数值，GREASE 和数值写法对所有列表通用
+func parseCodes(field string, names []string, lookup func(name string) (uint16, bool)) ([]uint16, error) {
+	codes := make([]uint16, 0, len(names))
+	for i, name := range names {
+		if name == "GREASE" {
+			codes = append(codes, tls.GREASE_PLACEHOLDER)
+			continue
+		}
+		if v, ok := lookup(name); ok {
+			codes = append(codes, v)
+			continue
+		}
+		v, err := strconv.ParseUint(name, 0, 16)
+		if err != nil {
+			return nil, fmt.Errorf("%s[%d]: 未知的名称 %q", field, i, name)
+		}
+		codes = append(codes, uint16(v))
+	}
+	return codes, nil
+}
+
+func lookupCurve(name string) (uint16, bool) {
+	if v, ok := extraCurveNames[name]; ok {
+		return v, true
+	}
+	v, ok := dicttls.DictSupportedGroupsNameIndexed[name]
+	return v, ok
+}
+
+func toCurveIDs(codes []uint16) []tls.CurveID {
+	ids := make([]tls.CurveID, len(codes))
+	for i, c := range codes {
+		ids[i] = tls.CurveID(c)
+	}
+	return ids
+}
+
+func valueOrDefault(values, def []string) []string {
+	if len(values) == 0 {
+		return def
+	}
+	return values
+}
@@ -0,0 +1,303 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package moduleinit
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/vistone/crawler-system/internal/config"
+)
+
+func newTestFingerprintManager(t *testing.T) *FingerprintManager {
+	t.Helper()
+	fm, _, err := InitFingerprint(&config.FingerprintConfig{
+		SelectionStrategy: "random",
+		HealthHalfLife:    config.Duration(30 * time.Minute),
+	}, discardLogger{})
+	if err != nil {
+		t.Fatalf("InitFingerprint: %v", err)
+	}
+	return fm
+}
+
+// newSeededFingerprintManager 创建只使用 Opera 指纹（opera_89、opera_90、opera_91）、随机源固定的指纹管理器
+func newSeededFingerprintManager(t *testing.T, strategy string, seed uint64) *FingerprintManager {
+	t.Helper()
+	fm, _, err := InitFingerprint(&config.FingerprintConfig{
+		SelectionStrategy: strategy,
+		Browsers:          []string{"opera"},
+		HealthHalfLife:    config.Duration(30 * time.Minute),
+	}, discardLogger{})
+	if err != nil {
+		t.Fatalf("InitFingerprint: %v", err)
+	}
+	fm.rng = rand.New(rand.NewPCG(seed, seed))
+	return fm
+}
+
+// pickIDs 按选择策略为目标连续选择 n 次指纹，与 Next 一样累计使用次数
+func pickIDs(fm *FingerprintManager, target string, n int) []string {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	ids := make([]string, n)
+	for i := range ids {
+		p := fm.pick(target, "")
+		fm.usage[p.id]++
+		ids[i] = p.id
+	}
+	return ids
+}
+
+func countIDs(ids []string) map[string]int {
+	counts := make(map[string]int)
+	for _, id := range ids {
+		counts[id]++
+	}
+	return counts
+}
+
+func TestFingerprintSelectionStrategies(t *testing.T) {
+	const target = "example.com"
+	tests := []struct {
+		strategy string
+		setup    func(fm *FingerprintManager)
+		check    func(t *testing.T, ids []string)
+	}{
+		{
+			strategy: "random",
+			check: func(t *testing.T, ids []string) {
+				if counts := countIDs(ids); len(counts) != 3 {
+					t.Errorf("90 次随机选择只选中了 %v", counts)
+				}
+			},
+		},
+		{
+			strategy: "round_robin",
+			check: func(t *testing.T, ids []string) {
+				for i, id := range ids {
+					if want := []string{"opera_89", "opera_90", "opera_91"}[i%3]; id != want {
+						t.Fatalf("第 %d 次选中 %s，want %s", i, id, want)
+					}
+				}
+			},
+		},
+		{
+			strategy: "least_used",
+			setup: func(fm *FingerprintManager) {
+				fm.usage["opera_89"] = 15
+			},
+			check: func(t *testing.T, ids []string) {
+				// opera_90 和 opera_91 先各被选中 15 次追平 opera_89，之后三者轮流
+				counts := countIDs(ids)
+				if counts["opera_89"] != 20 || counts["opera_90"] != 35 || counts["opera_91"] != 35 {
+					t.Errorf("各指纹选中次数 %v，want opera_89 20 次，其余各 35 次", counts)
+				}
+				if slices.Contains(ids[:30], "opera_89") {
+					t.Errorf("使用次数最多的 opera_89 在其他指纹追平前被选中: %v", ids[:30])
+				}
+			},
+		},
+		{
+			strategy: "least_blocked",
+			setup: func(fm *FingerprintManager) {
+				for range 20 {
+					fm.RecordOutcome("opera_89", target, OutcomeForbidden)
+					fm.RecordOutcome("opera_90", target, OutcomeChallenge)
+				}
+			},
+			check: func(t *testing.T, ids []string) {
+				if counts := countIDs(ids); counts["opera_91"] < 80 {
+					t.Errorf("各指纹选中次数 %v，want 没有被拒绝过的 opera_91 占绝大多数", counts)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			var runs [2][]string
+			for i := range runs {
+				fm := newSeededFingerprintManager(t, tt.strategy, 1)
+				if tt.setup != nil {
+					tt.setup(fm)
+				}
+				runs[i] = pickIDs(fm, target, 90)
+			}
+			if !slices.Equal(runs[0], runs[1]) {
+				t.Fatalf("随机源相同时两次选择的结果不同:\n%v\n%v", runs[0], runs[1])
+			}
+			tt.check(t, runs[0])
+		})
+	}
+}
+
+func TestNextExcludesPreviousFingerprintOnRotate(t *testing.T) {
+	for _, strategy := range []string{"random", "round_robin", "least_used", "least_blocked"} {
+		t.Run(strategy, func(t *testing.T) {
+			fm := newSeededFingerprintManager(t, strategy, 1)
+			ctx := context.Background()
+			fp, err := fm.Next(ctx, "example.com")
+			if err != nil {
+				t.Fatal(err)
+			}
+			for range 20 {
+				fm.Rotate("example.com")
+				next, err := fm.Next(ctx, "example.com")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if next.ID == fp.ID {
+					t.Fatalf("轮换后仍选中了 %s", fp.ID)
+				}
+				fp = next
+			}
+		})
+	}
+}
+
+// 清理粘滞指纹时保留已过期但仍活跃的目标，轮换时仍排除原指纹
+func TestNextExcludesPreviousFingerprintAfterSweep(t *testing.T) {
+	for _, strategy := range []string{"random", "round_robin", "least_used", "least_blocked"} {
+		t.Run(strategy, func(t *testing.T) {
+			fm := newSeededFingerprintManager(t, strategy, 1)
+			fm.Config.EnableRotation = true
+			fm.Config.RotationInterval = config.Duration(time.Minute)
+			ctx := context.Background()
+			fp, err := fm.Next(ctx, "example.com")
+			if err != nil {
+				t.Fatal(err)
+			}
+			for range 20 {
+				fm.mu.Lock()
+				fm.sticky["example.com"].assigned = time.Now().Add(-2 * time.Minute)
+				fm.lastSweep = time.Time{}
+				fm.mu.Unlock()
+				// 其他目标的请求触发清理
+				if _, err := fm.Next(ctx, "other.example.com"); err != nil {
+					t.Fatal(err)
+				}
+
+				next, err := fm.Next(ctx, "example.com")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if next.ID == fp.ID {
+					t.Fatalf("清理后轮换仍选中了 %s", fp.ID)
+				}
+				fp = next
+			}
+		})
+	}
+}
+
+// 未启用轮换时闲置的目标同样被清理
+func TestStickyFingerprintIdleTargetsEvictedWithoutRotation(t *testing.T) {
+	fm := newTestFingerprintManager(t)
+	ctx := context.Background()
+	if _, err := fm.Next(ctx, "idle.example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fm.Next(ctx, "active.example.com"); err != nil {
+		t.Fatal(err)
+	}
+
+	fm.mu.Lock()
+	fm.sticky["idle.example.com"].lastUsed = time.Now().Add(-stickyIdleTTL)
+	fm.lastSweep = time.Time{}
+	fm.mu.Unlock()
+
+	if _, err := fm.Next(ctx, "new.example.com"); err != nil {
+		t.Fatal(err)
+	}
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	if _, ok := fm.sticky["idle.example.com"]; ok {
+		t.Error("闲置超过 stickyIdleTTL 的目标没有被清理")
+	}
+	if _, ok := fm.sticky["active.example.com"]; !ok {
+		t.Error("活跃的目标被清理")
+	}
+}
+
+func TestStickyFingerprintTargetsCapped(t *testing.T) {
+	fm := newTestFingerprintManager(t)
+	ctx := context.Background()
+	fp, err := fm.Next(ctx, "first.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fm.mu.Lock()
+	base := time.Now().Add(-time.Minute)
+	for i := range maxStickyTargets {
+		used := base.Add(time.Duration(i) * time.Millisecond)
+		fm.sticky[fmt.Sprintf("t%d.example.com", i)] = &stickyFingerprint{fp: fp, assigned: used, lastUsed: used}
+	}
+	fm.mu.Unlock()
+
+	if _, err := fm.Next(ctx, "last.example.com"); err != nil {
+		t.Fatal(err)
+	}
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	if n := len(fm.sticky); n > maxStickyTargets {
+		t.Fatalf("保留了 %d 个目标，超过上限 %d", n, maxStickyTargets)
+	}
+	if _, ok := fm.sticky["t0.example.com"]; ok {
+		t.Error("最久未使用的目标没有被删除")
+	}
+	for _, target := range []string{"first.example.com", "last.example.com", fmt.Sprintf("t%d.example.com", maxStickyTargets-1)} {
+		if _, ok := fm.sticky[target]; !ok {
+			t.Errorf("最近使用的目标 %s 被删除", target)
+		}
+	}
+}
+
+func TestRecordOutcomeSkipsSuccessWithoutPenalty(t *testing.T) {
+	fm := newTestFingerprintManager(t)
+	fm.RecordOutcome("chrome", "ok.example.com", OutcomeSuccess)
+	if h := fm.Health("ok.example.com"); len(h) != 0 {
+		t.Fatalf("没有被拒绝过的指纹不应有统计，got %v", h)
+	}
+
+	fm.RecordOutcome("chrome", "blocked.example.com", OutcomeForbidden)
+	fm.RecordOutcome("chrome", "blocked.example.com", OutcomeSuccess)
+	h := fm.Health("blocked.example.com")["chrome"]
+	if h.Forbidden < 0.99 || h.Success < 0.99 {
+		t.Fatalf("被拒绝后的成功应计入统计，got %+v", h)
+	}
+}
+
+func TestFingerprintHealthCapped(t *testing.T) {
+	fm := newTestFingerprintManager(t)
+	fm.RecordOutcome("chrome", "first.example.com", OutcomeForbidden)
+
+	fm.mu.Lock()
+	base := time.Now().Add(-time.Minute)
+	for i := range maxHealthEntries {
+		key := healthKey{id: "chrome", target: fmt.Sprintf("t%d.example.com", i)}
+		fm.health[key] = FingerprintHealth{Forbidden: 1, updated: base.Add(time.Duration(i) * time.Millisecond)}
+	}
+	fm.mu.Unlock()
+
+	fm.RecordOutcome("chrome", "last.example.com", OutcomeChallenge)
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	if n := len(fm.health); n > maxHealthEntries {
+		t.Fatalf("保留了 %d 条统计，超过上限 %d", n, maxHealthEntries)
+	}
+	if _, ok := fm.health[healthKey{id: "chrome", target: "t0.example.com"}]; ok {
+		t.Error("最久没有更新的统计没有被删除")
+	}
+	for _, target := range []string{"first.example.com", "last.example.com"} {
+		if _, ok := fm.health[healthKey{id: "chrome", target: target}]; !ok {
+			t.Errorf("最近更新的统计 %s 被删除", target)
+		}
+	}
+}
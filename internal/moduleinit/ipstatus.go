@@ -5,7 +5,15 @@
 package moduleinit
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
 
 	"github.com/vistone/crawler-system/internal/config"
 )
@@ -15,85 +23,231 @@ type IPStatusManager interface {
 	AddToWhitelist(ip string) error
 	RemoveFromWhitelist(ip string, reason string) error
 	AddToBlacklist(ip string, reason string) error
+	RemoveFromBlacklist(ip string) error
 	GetStatus(ip string) string
 	GetWhitelistIPs() []string
 	GetWhitelistCount() int
+	GetBlacklist() map[string]string
 	CheckSystemHealth() error
 	SetMinWhitelistCount(count int)
 	SetAllowStartWhenEmpty(allow bool)
 	SetWhitelistMonitoring(enabled bool)
-	SetWhitelistMonitoringInterval(interval int)
+	SetWhitelistMonitoringInterval(interval time.Duration)
 }
 
 // PlaceholderIPStatusManager 占位符黑白名单管理器实现
 type PlaceholderIPStatusManager struct {
-	whitelist                    map[string]bool
-	blacklist                    map[string]string
-	minWhitelistCount            int
-	allowStartWhenEmpty          bool
-	whitelistMonitoring          bool
-	whitelistMonitoringInterval  int
+	mu                          sync.RWMutex
+	whitelist                   map[string]bool
+	blacklist                   map[string]string
+	minWhitelistCount           int
+	allowStartWhenEmpty         bool
+	whitelistMonitoring         bool
+	whitelistMonitoringInterval time.Duration
+	storePath                   string      // 持久化文件路径，为空时只保存在内存中
+	loaded                      os.FileInfo // 最近一次加载或写入的持久化文件，用于发现其他进程的修改
+	checked                     time.Time   // 最近一次检查持久化文件是否被修改的时间
+	logger                      Logger
+}
+
+// ipStatusRefreshInterval 读取黑白名单时检查持久化文件是否被其他进程修改的最小间隔
+const ipStatusRefreshInterval = time.Second
+
+// ipStatusFile 黑白名单持久化文件内容
+type ipStatusFile struct {
+	Whitelist []string          `json:"whitelist"`
+	Blacklist map[string]string `json:"blacklist"` // IP -> 加入黑名单的原因
 }
 
 // InitIPStatusManager 初始化黑白名单模块（模块6）
-func InitIPStatusManager(cfg *config.IPStatusConfig, logger interface{ Info(string, ...interface{}) }) (IPStatusManager, error) {
-	// 输出初始化详细信息
-	fmt.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
-	fmt.Printf("📋 [模块6] 黑白名单模块初始化\n")
-	fmt.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
-	fmt.Printf("  ✓ 白名单最小数量: %d\n", cfg.MinWhitelistCount)
-	fmt.Printf("  ✓ 允许空白名单启动: %v\n", cfg.AllowStartWhenEmpty)
-	if !cfg.AllowStartWhenEmpty {
-		fmt.Printf("    ⚠️  警告: 白名单为空时系统将无法启动\n")
-	} else {
-		fmt.Printf("    ℹ️  说明: 白名单为空时系统进入待机状态，不参与爬取\n")
-	}
-	fmt.Printf("  ✓ 白名单监控: %v\n", cfg.WhitelistMonitoring)
+//
+// storePath 不为空时从该文件加载黑白名单，供多个进程（如命令行工具）共享：
+// 每次修改在文件锁内重新加载文件、应用修改后写回，不会覆盖其他进程的修改；
+// 读取时发现文件已被其他进程修改则重新加载。
+func InitIPStatusManager(cfg *config.IPStatusConfig, storePath string, logger Logger) (IPStatusManager, *StartupReport, error) {
+	report := NewStartupReport("ipstatus", "黑白名单模块")
+	report.Set("min_whitelist_count", "白名单最小数量", cfg.MinWhitelistCount)
+	report.Set("allow_start_when_empty", "允许空白名单启动", cfg.AllowStartWhenEmpty)
+	report.Set("whitelist_monitoring", "白名单监控", cfg.WhitelistMonitoring)
 	if cfg.WhitelistMonitoring {
-		fmt.Printf("  ✓ 监控间隔: %d 秒\n", cfg.WhitelistMonitoringInterval)
+		report.Set("whitelist_monitoring_interval", "监控间隔", cfg.WhitelistMonitoringInterval)
 	}
-	fmt.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
+	report.Set("store_path", "持久化文件", getDisplayValue(storePath, "不持久化"))
 
 	// TODO: 实际实现时使用真实的whitelist-blacklist-manager库
 	manager := &PlaceholderIPStatusManager{
-		whitelist:                    make(map[string]bool),
-		blacklist:                    make(map[string]string),
-		minWhitelistCount:            cfg.MinWhitelistCount,
-		allowStartWhenEmpty:          cfg.AllowStartWhenEmpty,
-		whitelistMonitoring:          cfg.WhitelistMonitoring,
-		whitelistMonitoringInterval:  cfg.WhitelistMonitoringInterval,
+		whitelist:                   make(map[string]bool),
+		blacklist:                   make(map[string]string),
+		minWhitelistCount:           cfg.MinWhitelistCount,
+		allowStartWhenEmpty:         cfg.AllowStartWhenEmpty,
+		whitelistMonitoring:         cfg.WhitelistMonitoring,
+		whitelistMonitoringInterval: cfg.WhitelistMonitoringInterval.Duration(),
+		storePath:                   storePath,
+		logger:                      logger,
+	}
+	if err := manager.load(); err != nil {
+		return nil, nil, err
+	}
+
+	report.Detect("whitelist_count", "白名单IP数量", manager.GetWhitelistCount())
+	report.Detect("blacklist_count", "黑名单IP数量", len(manager.blacklist))
+	if manager.GetWhitelistCount() == 0 {
+		if cfg.AllowStartWhenEmpty {
+			report.Note("白名单为空，系统进入待机状态，不参与爬取")
+		} else {
+			report.Warn("白名单为空，系统将无法启动")
+		}
+	}
+	return manager, report, nil
+}
+
+// load 从持久化文件加载黑白名单，替换内存中的黑白名单；文件不存在时保持不变，调用方需持有写锁
+func (m *PlaceholderIPStatusManager) load() error {
+	if m.storePath == "" {
+		return nil
+	}
+	data, err := os.ReadFile(m.storePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("读取黑白名单文件失败: %w", err)
+	}
+	var f ipStatusFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return fmt.Errorf("解析黑白名单文件 %s 失败: %w", m.storePath, err)
+	}
+	clear(m.whitelist)
+	clear(m.blacklist)
+	for _, ip := range f.Whitelist {
+		m.whitelist[ip] = true
+	}
+	for ip, reason := range f.Blacklist {
+		m.blacklist[ip] = reason
+	}
+	m.loaded, _ = os.Stat(m.storePath)
+	return nil
+}
+
+// refresh 持久化文件被其他进程修改后重新加载，每 ipStatusRefreshInterval 最多检查一次，调用方不能持有锁
+//
+// 文件总是整体替换（写临时文件后重命名），文件变化即表示有新的修改。重新加载失败时保留内存中的黑白名单。
+func (m *PlaceholderIPStatusManager) refresh() {
+	if m.storePath == "" {
+		return
+	}
+	now := time.Now()
+	m.mu.RLock()
+	due := now.Sub(m.checked) >= ipStatusRefreshInterval
+	m.mu.RUnlock()
+	if !due {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if now.Sub(m.checked) < ipStatusRefreshInterval {
+		return
+	}
+	m.checked = now
+	info, err := os.Stat(m.storePath)
+	if err != nil || (m.loaded != nil && os.SameFile(info, m.loaded) && info.ModTime().Equal(m.loaded.ModTime())) {
+		return
+	}
+	if err := m.load(); err != nil {
+		m.logger.Warn("重新加载黑白名单文件失败，继续使用内存中的黑白名单，error=%v", err)
+	}
+}
+
+// update 在持久化文件锁内重新加载文件、应用 change 并写回，其他进程在此之前的修改不会被覆盖
+func (m *PlaceholderIPStatusManager) update(change func()) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.storePath == "" {
+		change()
+		return nil
 	}
 
-	fmt.Printf("  📊 当前状态:\n")
-	fmt.Printf("    - 白名单IP数量: %d\n", manager.GetWhitelistCount())
-	fmt.Printf("    - 黑名单IP数量: %d\n", len(manager.blacklist))
-	fmt.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
+	if err := os.MkdirAll(filepath.Dir(m.storePath), 0755); err != nil {
+		return fmt.Errorf("创建黑白名单目录失败: %w", err)
+	}
+	unlock, err := lockFile(m.storePath + ".lock")
+	if err != nil {
+		return fmt.Errorf("锁定黑白名单文件失败: %w", err)
+	}
+	defer unlock()
 
-	if logger != nil {
-		logger.Info("黑白名单模块初始化完成，min_whitelist_count=%d, allow_start_when_empty=%v",
-			cfg.MinWhitelistCount, cfg.AllowStartWhenEmpty)
+	if err := m.load(); err != nil {
+		return err
 	}
-	return manager, nil
+	change()
+	return m.save()
+}
+
+// save 将黑白名单写回持久化文件，调用方需持有写锁和文件锁
+func (m *PlaceholderIPStatusManager) save() error {
+	if m.storePath == "" {
+		return nil
+	}
+	f := ipStatusFile{Whitelist: make([]string, 0, len(m.whitelist)), Blacklist: m.blacklist}
+	for ip := range m.whitelist {
+		f.Whitelist = append(f.Whitelist, ip)
+	}
+	sort.Strings(f.Whitelist)
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// 先写临时文件再重命名，避免写入中断时损坏原文件
+	if err := os.MkdirAll(filepath.Dir(m.storePath), 0755); err != nil {
+		return fmt.Errorf("创建黑白名单目录失败: %w", err)
+	}
+	tmp := m.storePath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("写入黑白名单文件失败: %w", err)
+	}
+	if err := os.Rename(tmp, m.storePath); err != nil {
+		return fmt.Errorf("写入黑白名单文件失败: %w", err)
+	}
+	m.loaded, _ = os.Stat(m.storePath)
+	return nil
 }
 
 func (m *PlaceholderIPStatusManager) AddToWhitelist(ip string) error {
-	m.whitelist[ip] = true
-	delete(m.blacklist, ip)
+	if err := m.update(func() {
+		m.whitelist[ip] = true
+		delete(m.blacklist, ip)
+	}); err != nil {
+		return err
+	}
+	m.logger.Info("IP已加入白名单，ip=%s", ip)
 	return nil
 }
 
 func (m *PlaceholderIPStatusManager) RemoveFromWhitelist(ip string, reason string) error {
-	delete(m.whitelist, ip)
+	if err := m.update(func() { delete(m.whitelist, ip) }); err != nil {
+		return err
+	}
+	m.logger.Info("IP已移出白名单，ip=%s, reason=%s", ip, reason)
 	return nil
 }
 
 func (m *PlaceholderIPStatusManager) AddToBlacklist(ip string, reason string) error {
-	m.blacklist[ip] = reason
-	delete(m.whitelist, ip)
+	if err := m.update(func() {
+		m.blacklist[ip] = reason
+		delete(m.whitelist, ip)
+	}); err != nil {
+		return err
+	}
+	m.logger.Warn("IP已加入黑名单，ip=%s, reason=%s", ip, reason)
 	return nil
 }
 
 func (m *PlaceholderIPStatusManager) GetStatus(ip string) string {
+	m.refresh()
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 	if m.whitelist[ip] {
 		return "whitelist"
 	}
@@ -104,6 +258,9 @@ func (m *PlaceholderIPStatusManager) GetStatus(ip string) string {
 }
 
 func (m *PlaceholderIPStatusManager) GetWhitelistIPs() []string {
+	m.refresh()
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 	ips := make([]string, 0, len(m.whitelist))
 	for ip := range m.whitelist {
 		ips = append(ips, ip)
@@ -112,29 +269,68 @@ func (m *PlaceholderIPStatusManager) GetWhitelistIPs() []string {
 }
 
 func (m *PlaceholderIPStatusManager) GetWhitelistCount() int {
+	m.refresh()
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 	return len(m.whitelist)
 }
 
+// RemoveFromBlacklist 将IP移出黑名单，IP的状态变为 unknown
+func (m *PlaceholderIPStatusManager) RemoveFromBlacklist(ip string) error {
+	if err := m.update(func() { delete(m.blacklist, ip) }); err != nil {
+		return err
+	}
+	m.logger.Info("IP已移出黑名单，ip=%s", ip)
+	return nil
+}
+
+// GetBlacklist 返回黑名单IP及加入黑名单的原因
+func (m *PlaceholderIPStatusManager) GetBlacklist() map[string]string {
+	m.refresh()
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	blacklist := make(map[string]string, len(m.blacklist))
+	for ip, reason := range m.blacklist {
+		blacklist[ip] = reason
+	}
+	return blacklist
+}
+
 func (m *PlaceholderIPStatusManager) CheckSystemHealth() error {
-	if len(m.whitelist) == 0 && !m.allowStartWhenEmpty {
+	m.refresh()
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	switch n := len(m.whitelist); {
+	case n == 0 && m.allowStartWhenEmpty:
+		return nil
+	case n == 0:
 		return fmt.Errorf("白名单为空且不允许启动")
+	case n < m.minWhitelistCount:
+		return fmt.Errorf("白名单IP数量 %d 少于最小数量 %d", n, m.minWhitelistCount)
 	}
 	return nil
 }
 
 func (m *PlaceholderIPStatusManager) SetMinWhitelistCount(count int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.minWhitelistCount = count
 }
 
 func (m *PlaceholderIPStatusManager) SetAllowStartWhenEmpty(allow bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.allowStartWhenEmpty = allow
 }
 
 func (m *PlaceholderIPStatusManager) SetWhitelistMonitoring(enabled bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.whitelistMonitoring = enabled
 }
 
-func (m *PlaceholderIPStatusManager) SetWhitelistMonitoringInterval(interval int) {
+func (m *PlaceholderIPStatusManager) SetWhitelistMonitoringInterval(interval time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.whitelistMonitoringInterval = interval
 }
-
@@ -0,0 +1,150 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package moduleinit
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vistone/crawler-system/internal/config"
+)
+
+type discardLogger struct{}
+
+func (discardLogger) Debug(string, ...interface{}) {}
+func (discardLogger) Info(string, ...interface{})  {}
+func (discardLogger) Warn(string, ...interface{})  {}
+func (discardLogger) Error(string, ...interface{}) {}
+
+func openIPStatus(t *testing.T, path string) IPStatusManager {
+	t.Helper()
+	m, _, err := InitIPStatusManager(&config.IPStatusConfig{AllowStartWhenEmpty: true}, path, discardLogger{})
+	if err != nil {
+		t.Fatalf("InitIPStatusManager: %v", err)
+	}
+	return m
+}
+
+// expireRefresh 使下次读取时检查持久化文件，模拟距上次检查已超过 ipStatusRefreshInterval
+func expireRefresh(m IPStatusManager) {
+	pm := m.(*PlaceholderIPStatusManager)
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	pm.checked = time.Time{}
+}
+
+// 两个管理器（如运行中的系统和命令行工具）同时修改同一文件，互不覆盖
+func TestIPStatusSharedFileMergesChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ip_status.json")
+	running := openIPStatus(t, path)
+	cli := openIPStatus(t, path)
+
+	if err := running.AddToWhitelist("1.1.1.1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := cli.AddToBlacklist("2.2.2.2", "manual"); err != nil {
+		t.Fatal(err)
+	}
+	if err := running.AddToWhitelist("3.3.3.3"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, m := range []IPStatusManager{running, cli, openIPStatus(t, path)} {
+		expireRefresh(m)
+		if got := m.GetStatus("1.1.1.1"); got != "whitelist" {
+			t.Errorf("1.1.1.1: %s, want whitelist", got)
+		}
+		if got := m.GetStatus("2.2.2.2"); got != "blacklist" {
+			t.Errorf("2.2.2.2: %s, want blacklist（其他进程的修改被覆盖或没有重新加载）", got)
+		}
+		if got := m.GetWhitelistCount(); got != 2 {
+			t.Errorf("whitelist count = %d, want 2", got)
+		}
+	}
+}
+
+func TestIPStatusConcurrentWritersKeepAllChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ip_status.json")
+	managers := []IPStatusManager{openIPStatus(t, path), openIPStatus(t, path), openIPStatus(t, path)}
+
+	const perManager = 20
+	var wg sync.WaitGroup
+	for i, m := range managers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range perManager {
+				if err := m.AddToWhitelist(fmt.Sprintf("10.0.%d.%d", i, j)); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got, want := openIPStatus(t, path).GetWhitelistCount(), len(managers)*perManager; got != want {
+		t.Fatalf("whitelist count = %d, want %d", got, want)
+	}
+}
+
+func TestIPStatusRefreshRateLimited(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ip_status.json")
+	running := openIPStatus(t, path)
+	cli := openIPStatus(t, path)
+	if err := cli.AddToWhitelist("1.1.1.1"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 距上次检查不到 ipStatusRefreshInterval 时不检查文件
+	if got := running.GetStatus("1.1.1.1"); got != "unknown" {
+		t.Fatalf("1.1.1.1: %s, want unknown（检查间隔内不应重新加载）", got)
+	}
+	expireRefresh(running)
+	if got := running.GetStatus("1.1.1.1"); got != "whitelist" {
+		t.Fatalf("1.1.1.1: %s, want whitelist（超过检查间隔后应重新加载）", got)
+	}
+}
+
+func TestIPStatusCheckSystemHealth(t *testing.T) {
+	tests := []struct {
+		name       string
+		whitelist  int
+		minCount   int
+		allowEmpty bool
+		wantErr    string
+	}{
+		{"达到最小数量", 2, 2, false, ""},
+		{"低于最小数量", 1, 2, false, "白名单IP数量 1 少于最小数量 2"},
+		{"允许空白名单时低于最小数量", 1, 2, true, "白名单IP数量 1 少于最小数量 2"},
+		{"空白名单且允许启动", 0, 2, true, ""},
+		{"空白名单且不允许启动", 0, 2, false, "白名单为空且不允许启动"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := openIPStatus(t, "")
+			m.SetMinWhitelistCount(tt.minCount)
+			m.SetAllowStartWhenEmpty(tt.allowEmpty)
+			for i := range tt.whitelist {
+				if err := m.AddToWhitelist(fmt.Sprintf("10.0.0.%d", i+1)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			err := m.CheckSystemHealth()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("CheckSystemHealth: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("CheckSystemHealth error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -6,66 +6,240 @@ package moduleinit
 
 import (
 	"fmt"
+	"math/rand/v2"
+	"net"
+	"sync"
+	"time"
 
 	"github.com/vistone/crawler-system/internal/config"
 	"github.com/vistone/localippool"
 )
 
+// LocalIPPool 本地IP池
+//
+// 没有动态IPv6地址池时按 selection_strategy 从IPv4地址中选择出口地址；
+// 启用 health_check_enabled 时，连续 max_failures 次连接失败的地址暂停使用 recovery_check_interval，
+// 暂停期间每隔 health_check_interval 用该地址连接它最后连接失败的目标，连接成功则提前恢复使用。
+type LocalIPPool struct {
+	localippool.IPPool
+
+	strategy      string
+	healthCheck   bool
+	maxFailures   int
+	recoveryAfter time.Duration
+	checkInterval time.Duration
+	checkTimeout  time.Duration
+	logger        Logger
+
+	mu         sync.Mutex
+	ipv4       []net.IP
+	next       int
+	uses       map[string]int       // IP -> 被选中的次数，least_used 使用
+	failures   map[string]int       // IP -> 连续连接失败次数
+	downUntil  map[string]time.Time // IP -> 暂停使用的截止时间
+	lastTarget map[string]string    // IP -> 最后一次连接失败的目标地址（host:port），健康检查使用
+
+	stop chan struct{} // 关闭时停止健康检查，未启动时为 nil
+	done chan struct{}
+}
+
 // InitLocalIPPool 初始化本地IP池模块（模块4）
-func InitLocalIPPool(cfg *config.LocalIPPoolConfig, logger interface{ Info(string, ...interface{}) }) (localippool.IPPool, error) {
-	// 输出初始化详细信息
-	fmt.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
-	fmt.Printf("🌍 [模块4] 本地IP池模块初始化\n")
-	fmt.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
-	fmt.Printf("  ✓ IPv4地址数量: %d\n", len(cfg.IPs))
+func InitLocalIPPool(cfg *config.LocalIPPoolConfig, logger Logger) (*LocalIPPool, *StartupReport, error) {
+	report := NewStartupReport("localippool", "本地IP池模块")
 	if len(cfg.IPs) > 0 {
-		fmt.Printf("  ✓ IPv4地址列表:\n")
-		for _, ip := range cfg.IPs {
-			fmt.Printf("    - %s\n", ip)
-		}
+		report.Set("ips", "IPv4地址", cfg.IPs)
 	} else {
-		fmt.Printf("  ✓ IPv4地址: 自动检测\n")
+		report.Set("ips", "IPv4地址", "自动检测")
 	}
-	fmt.Printf("  ✓ 选择策略: %s\n", cfg.SelectionStrategy)
-	fmt.Printf("  ✓ 健康检查: %v\n", cfg.HealthCheckEnabled)
+	report.Set("selection_strategy", "选择策略", cfg.SelectionStrategy)
+	report.Set("health_check_enabled", "健康检查", cfg.HealthCheckEnabled)
 	if cfg.HealthCheckEnabled {
-		fmt.Printf("  ✓ 健康检查间隔: %d 秒\n", cfg.HealthCheckInterval)
-		fmt.Printf("  ✓ 健康检查超时: %d 秒\n", cfg.HealthCheckTimeout)
-		fmt.Printf("  ✓ 最大失败次数: %d\n", cfg.MaxFailures)
-		fmt.Printf("  ✓ 恢复检查间隔: %d 秒\n", cfg.RecoveryCheckInterval)
+		report.Set("health_check_interval", "健康检查间隔", cfg.HealthCheckInterval)
+		report.Set("health_check_timeout", "健康检查超时", cfg.HealthCheckTimeout)
+		report.Set("max_failures", "最大失败次数", cfg.MaxFailures)
+		report.Set("recovery_check_interval", "恢复检查间隔", cfg.RecoveryCheckInterval)
 	}
-	fmt.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
 
 	// 创建本地IP池（使用配置的IPv4列表，IPv6为空表示自动检测）
 	pool, err := localippool.NewLocalIPPool(cfg.IPs, "")
 	if err != nil {
-		return nil, fmt.Errorf("创建本地IP池失败: %w", err)
+		return nil, nil, fmt.Errorf("创建本地IP池失败: %w", err)
 	}
 
-	// 获取实际检测到的IP信息
+	// 记录实际检测到的IP信息
 	ipv4s := pool.GetIPv4Addresses()
 	ipv6s := pool.GetActiveIPv6Addresses()
-	supportsDynamic := pool.SupportsDynamicPool()
-
-	fmt.Printf("  📊 实际检测结果:\n")
-	fmt.Printf("    - IPv4地址: %v\n", ipv4s)
-	if supportsDynamic {
-		fmt.Printf("    - IPv6动态池: 已启用\n")
-		if len(ipv6s) > 0 {
-			fmt.Printf("    - 活跃IPv6地址数量: %d\n", len(ipv6s))
-			if len(ipv6s) <= 5 {
-				fmt.Printf("    - IPv6地址列表: %v\n", ipv6s)
-			} else {
-				fmt.Printf("    - IPv6地址列表: %v ... (共%d个)\n", ipv6s[:5], len(ipv6s))
+	report.Detect("ipv4_count", "IPv4地址数量", len(ipv4s))
+	report.Detect("ipv4_addresses", "IPv4地址列表", ipv4s)
+	report.Detect("ipv6_dynamic_pool", "IPv6动态池", pool.SupportsDynamicPool())
+	report.Detect("ipv6_count", "活跃IPv6地址数量", len(ipv6s))
+	if len(ipv4s) == 0 && len(ipv6s) == 0 {
+		report.Warn("未检测到可用的本地IP，出站连接将由系统选择源地址")
+	}
+
+	p := &LocalIPPool{
+		IPPool:        pool,
+		strategy:      cfg.SelectionStrategy,
+		healthCheck:   cfg.HealthCheckEnabled,
+		maxFailures:   cfg.MaxFailures,
+		recoveryAfter: cfg.RecoveryCheckInterval.Duration(),
+		checkInterval: cfg.HealthCheckInterval.Duration(),
+		checkTimeout:  cfg.HealthCheckTimeout.Duration(),
+		logger:        logger,
+		uses:          make(map[string]int),
+		failures:      make(map[string]int),
+		downUntil:     make(map[string]time.Time),
+		lastTarget:    make(map[string]string),
+	}
+	if !pool.SupportsDynamicPool() {
+		for _, s := range ipv4s {
+			if ip := net.ParseIP(s); ip != nil {
+				p.ipv4 = append(p.ipv4, ip)
 			}
 		}
 	} else {
-		fmt.Printf("    - IPv6动态池: 未启用\n")
+		report.Note("使用动态IPv6地址池，出口地址由地址池分配，selection_strategy 只用于IPv4")
+	}
+	return p, report, nil
+}
+
+// GetIP 返回一个出口IP，没有可选的IPv4地址时由 localippool 分配
+func (p *LocalIPPool) GetIP() net.IP {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.ipv4) == 0 {
+		return p.IPPool.GetIP()
+	}
+
+	now := time.Now()
+	candidates := make([]net.IP, 0, len(p.ipv4))
+	for _, ip := range p.ipv4 {
+		if now.Before(p.downUntil[ip.String()]) {
+			continue
+		}
+		candidates = append(candidates, ip)
+	}
+	if len(candidates) == 0 { // 全部暂停时仍然使用，避免请求无法发出
+		candidates = p.ipv4
+	}
+
+	var ip net.IP
+	switch p.strategy {
+	case "random":
+		ip = candidates[rand.IntN(len(candidates))]
+	case "least_used":
+		ip = candidates[0]
+		for _, c := range candidates[1:] {
+			if p.uses[c.String()] < p.uses[ip.String()] {
+				ip = c
+			}
+		}
+	default: // round_robin
+		ip = candidates[p.next%len(candidates)]
+		p.next++
+	}
+	p.uses[ip.String()]++
+	return ip
+}
+
+// ReportResult 记录使用出口IP连接 target（host:port）的结果，未启用健康检查时不做任何事
+func (p *LocalIPPool) ReportResult(ip net.IP, target string, err error) {
+	if !p.healthCheck || ip == nil {
+		return
+	}
+	key := ip.String()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err == nil {
+		delete(p.failures, key)
+		delete(p.lastTarget, key)
+		return
+	}
+	p.lastTarget[key] = target
+	p.failures[key]++
+	if p.failures[key] < p.maxFailures {
+		return
+	}
+	delete(p.failures, key)
+	p.downUntil[key] = time.Now().Add(p.recoveryAfter)
+	p.logger.Warn("出口IP %s 连续 %d 次连接失败，暂停使用 %v", key, p.maxFailures, p.recoveryAfter)
+}
+
+// Start 启动暂停出口IP的健康检查，未启用健康检查或 health_check_interval 为0时不做任何事
+func (p *LocalIPPool) Start() {
+	if !p.healthCheck || p.checkInterval <= 0 || p.stop != nil {
+		return
+	}
+	p.stop = make(chan struct{})
+	p.done = make(chan struct{})
+	go p.checkLoop(p.stop, p.done)
+}
+
+// Close 停止健康检查并关闭本地IP池
+func (p *LocalIPPool) Close() error {
+	if p.stop != nil {
+		close(p.stop)
+		<-p.done
+		p.stop = nil
+	}
+	return p.IPPool.Close()
+}
+
+func (p *LocalIPPool) checkLoop(stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(p.checkInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			p.checkPaused(stop)
+		}
 	}
-	fmt.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
+}
 
-	if logger != nil {
-		logger.Info("本地IP池模块初始化完成，ipv4_count=%d, strategy=%s", len(cfg.IPs), cfg.SelectionStrategy)
+// checkPaused 用每个暂停中的出口IP连接它最后连接失败的目标，连接成功的IP恢复使用
+func (p *LocalIPPool) checkPaused(stop <-chan struct{}) {
+	type probe struct {
+		ip     string
+		target string
+	}
+	now := time.Now()
+	var probes []probe
+	p.mu.Lock()
+	for ip, until := range p.downUntil {
+		if !now.Before(until) {
+			delete(p.downUntil, ip)
+			delete(p.lastTarget, ip)
+			continue
+		}
+		if target := p.lastTarget[ip]; target != "" {
+			probes = append(probes, probe{ip: ip, target: target})
+		}
+	}
+	p.mu.Unlock()
+
+	for _, pr := range probes {
+		select {
+		case <-stop:
+			return
+		default:
+		}
+		dialer := &net.Dialer{
+			Timeout:   p.checkTimeout,
+			LocalAddr: &net.TCPAddr{IP: net.ParseIP(pr.ip)},
+		}
+		conn, err := dialer.Dial("tcp", pr.target)
+		if err != nil {
+			p.logger.Debug("出口IP %s 健康检查失败，target=%s, error=%v", pr.ip, pr.target, err)
+			continue
+		}
+		conn.Close()
+		p.mu.Lock()
+		delete(p.downUntil, pr.ip)
+		delete(p.lastTarget, pr.ip)
+		p.mu.Unlock()
+		p.logger.Info("出口IP %s 健康检查连接 %s 成功，恢复使用", pr.ip, pr.target)
 	}
-	return pool, nil
 }
@@ -0,0 +1,97 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package moduleinit
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func newTestLocalIPPool(ips ...string) *LocalIPPool {
+	p := &LocalIPPool{
+		strategy:      "round_robin",
+		healthCheck:   true,
+		maxFailures:   2,
+		recoveryAfter: time.Hour,
+		checkInterval: time.Second,
+		checkTimeout:  time.Second,
+		logger:        &recordingLogger{},
+		uses:          make(map[string]int),
+		failures:      make(map[string]int),
+		downUntil:     make(map[string]time.Time),
+		lastTarget:    make(map[string]string),
+	}
+	for _, ip := range ips {
+		p.ipv4 = append(p.ipv4, net.ParseIP(ip))
+	}
+	return p
+}
+
+func TestLocalIPPoolHealthCheckRecoversPausedIP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	p := newTestLocalIPPool("127.0.0.1", "127.0.0.2")
+	paused := net.ParseIP("127.0.0.1")
+	dialErr := errors.New("connection refused")
+	p.ReportResult(paused, ln.Addr().String(), dialErr)
+	p.ReportResult(paused, ln.Addr().String(), dialErr)
+
+	for i := 0; i < 4; i++ {
+		if ip := p.GetIP(); ip.Equal(paused) {
+			t.Fatalf("暂停中的出口IP %s 仍被选中", ip)
+		}
+	}
+
+	p.checkPaused(make(chan struct{}))
+
+	var selected bool
+	for i := 0; i < 4; i++ {
+		if p.GetIP().Equal(paused) {
+			selected = true
+		}
+	}
+	if !selected {
+		t.Fatalf("健康检查连接成功后出口IP %s 没有恢复使用", paused)
+	}
+}
+
+func TestLocalIPPoolHealthCheckKeepsUnreachableIPPaused(t *testing.T) {
+	// 监听后立即关闭，得到一个拒绝连接的地址
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := ln.Addr().String()
+	ln.Close()
+
+	p := newTestLocalIPPool("127.0.0.1", "127.0.0.2")
+	paused := net.ParseIP("127.0.0.1")
+	for i := 0; i < p.maxFailures; i++ {
+		p.ReportResult(paused, target, errors.New("connection refused"))
+	}
+
+	p.checkPaused(make(chan struct{}))
+
+	for i := 0; i < 4; i++ {
+		if ip := p.GetIP(); ip.Equal(paused) {
+			t.Fatalf("健康检查失败后出口IP %s 不应恢复使用", ip)
+		}
+	}
+}
@@ -8,46 +8,79 @@ import (
 	"fmt"
 
 	"github.com/vistone/crawler-system/internal/config"
-	"github.com/vistone/logs"
+	"github.com/vistone/crawler-system/internal/logging"
 )
 
+// Logger 模块使用的日志接口，由 *logging.Logger 及其 Named 派生的模块日志器实现
+type Logger interface {
+	Debug(format string, args ...interface{})
+	Info(format string, args ...interface{})
+	Warn(format string, args ...interface{})
+	Error(format string, args ...interface{})
+}
+
 // InitLogs 初始化日志系统（模块1）
-func InitLogs(cfg *config.LogsConfig) (*logs.Logger, error) {
-	// 转换日志级别
-	var logLevel logs.LogLevel
-	switch cfg.Level {
-	case "debug":
-		logLevel = logs.Debug
-	case "info":
-		logLevel = logs.Info
-	case "warn":
-		logLevel = logs.Warn
-	case "error":
-		logLevel = logs.Error
-	default:
-		logLevel = logs.Info
-	}
-
-	// 创建日志器（启用彩色输出）
-	logger := logs.NewLogger(logLevel, true)
-
-	// 输出初始化详细信息
-	fmt.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
-	fmt.Printf("📝 [模块1] 日志系统初始化\n")
-	fmt.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
-	fmt.Printf("  ✓ 日志级别: %s (LogLevel=%d)\n", cfg.Level, logLevel)
-	fmt.Printf("  ✓ 文件输出: %v\n", cfg.FileEnabled)
+//
+// 日志同时输出到 output_path（默认标准输出，none 表示不输出）和 file_enabled 时的轮转日志文件。
+func InitLogs(cfg *config.LogsConfig) (*logging.Logger, *StartupReport, error) {
+	var outputs []logging.Output
+	if cfg.OutputPath != "none" {
+		out, err := logging.OpenOutput(cfg.OutputPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		outputs = append(outputs, out)
+	}
+	var file *logging.RotatingFile
+	if cfg.FileEnabled {
+		var err error
+		file, err = logging.OpenRotatingFile(cfg.FilePath, cfg.MaxSize, cfg.MaxBackups, cfg.Compress)
+		if err != nil {
+			closeOutputs(outputs)
+			return nil, nil, fmt.Errorf("打开日志文件失败: %w", err)
+		}
+		outputs = append(outputs, logging.Output{Name: cfg.FilePath, Writer: file, Closer: file})
+	}
+
+	logger := logging.New(logging.ParseLevel(cfg.Level), logging.ParseFormat(cfg.Format), cfg.ShowCaller, outputs...)
+	logger.SetModuleLevels(logging.ParseLevels(cfg.Levels))
+	if file != nil {
+		file.SetErrorHandler(RotateErrorHandler(logger.Named("logs"), cfg.FilePath))
+	}
+
+	report := NewStartupReport("logs", "日志系统")
+	report.Set("level", "日志级别", cfg.Level)
+	if len(cfg.Levels) > 0 {
+		report.Set("levels", "模块日志级别", getLevelList(cfg.Levels))
+	}
+	report.Set("output_path", "输出路径", getDisplayValue(cfg.OutputPath, "标准输出"))
+	report.Set("file_enabled", "文件输出", cfg.FileEnabled)
 	if cfg.FileEnabled {
-		fmt.Printf("  ✓ 文件路径: %s\n", cfg.FilePath)
-		fmt.Printf("  ✓ 最大大小: %d MB\n", cfg.MaxSize)
-		fmt.Printf("  ✓ 保留数量: %d\n", cfg.MaxBackups)
-		fmt.Printf("  ✓ 压缩: %v\n", cfg.Compress)
-	}
-	fmt.Printf("  ✓ 日志格式: %s\n", cfg.Format)
-	fmt.Printf("  ✓ 显示调用位置: %v\n", cfg.ShowCaller)
-	fmt.Printf("  ✓ 彩色输出: 已启用\n")
-	fmt.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
-
-	logger.Info("日志系统初始化完成，level=%s", cfg.Level)
-	return logger, nil
+		report.Set("file_path", "文件路径", cfg.FilePath)
+		report.Set("max_size", "最大大小（MB）", cfg.MaxSize)
+		report.Set("max_backups", "保留数量", cfg.MaxBackups)
+		report.Set("compress", "压缩", cfg.Compress)
+	}
+	report.Set("format", "日志格式", cfg.Format)
+	report.Set("show_caller", "显示调用位置", cfg.ShowCaller)
+	report.Detect("outputs", "输出目标", logger.Outputs())
+	if len(outputs) == 0 {
+		report.Warn("没有日志输出目标（output_path = \"none\" 且未启用文件输出），日志将被丢弃")
+	}
+	return logger, report, nil
+}
+
+// RotateErrorHandler 返回把轮转日志文件后台压缩和清理的错误写入 logger 的函数
+func RotateErrorHandler(logger Logger, path string) func(error) {
+	return func(err error) {
+		logger.Error("轮转日志文件后台处理失败，file=%s, error=%v", path, err)
+	}
+}
+
+func closeOutputs(outputs []logging.Output) {
+	for _, o := range outputs {
+		if o.Closer != nil {
+			o.Closer.Close()
+		}
+	}
 }
@@ -5,39 +5,308 @@
 package moduleinit
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"sync"
+	"time"
 
-	"github.com/vistone/netconnpool"
 	"github.com/vistone/crawler-system/internal/config"
+	"github.com/vistone/netconnpool"
 )
 
+// maxConnGroups 同时保留的连接分组上限，超过时关闭最久未使用的空闲分组
+const maxConnGroups = 1024
+
+// errConnPoolsClosed 连接池已关闭
+var errConnPoolsClosed = errors.New("TCP连接池已关闭")
+
+// PooledConn 连接池中的连接
+type PooledConn interface {
+	io.Closer
+	// Healthy 检查空闲连接是否仍然可用，由连接池的健康检查定期调用
+	Healthy() bool
+}
+
+// ConnPools TCP连接池，按分组复用已建立的连接
+//
+// 每个分组（目标地址、TLS服务器名和指纹都相同的连接）是一个 netconnpool 连接池：
+// 同时使用的连接最多 max_connections 个，已满时最多等待 acquire_timeout；分组创建时预热 initial_connections 个连接；
+// 空闲超过 idle_timeout 或存在超过 max_lifetime 的连接被关闭，空闲连接每隔 health_check_interval 检查一次。
+// conn.max_idle_conns 限制每个分组的空闲连接数，为0时连接用完即关闭。
+// 分组在第一次使用时创建，连续 idle_timeout 没有使用时关闭。
+type ConnPools struct {
+	cfg            netconnpool.Config // 各分组共用的配置，不含 Dialer
+	maxConnections int
+	acquireTimeout time.Duration
+	idleTimeout    time.Duration
+	reuse          bool // conn.max_idle_conns > 0
+	logger         Logger
+
+	mu     sync.Mutex
+	groups map[string]*connGroup
+	closed bool
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// connGroup 一个连接分组
+//
+// max_connections 由 slots 限制而不交给 netconnpool：netconnpool 在连接数已满时
+// 会把归还的连接同时放入空闲队列和交给等待者，同一连接可能被两个请求取走。
+type connGroup struct {
+	init  sync.Once
+	pool  *netconnpool.Pool
+	err   error
+	slots chan struct{} // 使用中的连接，容量为 max_connections
+
+	active   int       // 已登记未注销的使用者，受 ConnPools.mu 保护
+	lastUsed time.Time // 最近一次登记或注销的时间，受 ConnPools.mu 保护
+}
+
 // InitNetConnPool 初始化TCP连接池模块（模块8）
-func InitNetConnPool(cfg *config.NetConnPoolConfig, logger interface{ Info(string, ...interface{}) }) (*netconnpool.Pool, error) {
-	// 输出初始化详细信息
-	fmt.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
-	fmt.Printf("🌐 [模块8] TCP连接池模块初始化\n")
-	fmt.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
-	fmt.Printf("  ✓ 最大连接数: %d\n", cfg.MaxConnections)
-	fmt.Printf("  ✓ 初始连接数: %d\n", cfg.InitialConnections)
-	fmt.Printf("  ✓ 获取连接超时: %d 秒\n", cfg.AcquireTimeout)
-	fmt.Printf("  ✓ 空闲连接超时: %d 秒\n", cfg.IdleTimeout)
-	fmt.Printf("  ✓ 连接最大生存时间: %d 秒\n", cfg.MaxLifetime)
-	fmt.Printf("  ✓ 健康检查间隔: %d 秒\n", cfg.HealthCheckInterval)
-	fmt.Printf("  ✓ 健康检查超时: %d 秒\n", cfg.HealthCheckTimeout)
-	fmt.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
-
-	// 注意：客户端模式需要Dialer，但Dialer需要目标地址
-	// 由于在初始化时还不知道目标地址，这里暂时不创建连接池
-	// 连接池将在实际使用时按需创建
-	fmt.Printf("  ℹ️  说明: 连接池将在需要时按需创建（需要Dialer时）\n")
-	fmt.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
-
-	if logger != nil {
-		logger.Info("TCP连接池配置已准备，max_connections=%d, initial_connections=%d, note=连接池将在需要时按需创建",
-			cfg.MaxConnections, cfg.InitialConnections)
-	}
-
-	// 暂时返回nil，连接池延迟初始化
-	return nil, nil
+//
+// 创建连接的超时取 conn.connect_timeout 与 conn.tls_handshake_timeout 之和。
+func InitNetConnPool(cfg *config.NetConnPoolConfig, connCfg *config.ConnConfig, logger Logger) (*ConnPools, *StartupReport, error) {
+	report := NewStartupReport("netconnpool", "TCP连接池模块")
+	report.Set("max_connections", "每个分组最大连接数", cfg.MaxConnections)
+	report.Set("initial_connections", "每个分组预热连接数", cfg.InitialConnections)
+	report.Set("acquire_timeout", "获取连接超时", cfg.AcquireTimeout)
+	report.Set("idle_timeout", "空闲连接超时", cfg.IdleTimeout)
+	report.Set("max_lifetime", "连接最大生存时间", cfg.MaxLifetime)
+	report.Set("health_check_interval", "健康检查间隔", cfg.HealthCheckInterval)
+	report.Set("health_check_timeout", "健康检查超时", cfg.HealthCheckTimeout)
+	report.Set("max_idle_conns", "每个分组最大空闲连接数", connCfg.MaxIdleConns)
+
+	p := &ConnPools{
+		cfg: netconnpool.Config{
+			Mode:           netconnpool.PoolModeClient,
+			MinConnections: cfg.InitialConnections,
+			// netconnpool 要求大于0，不复用时连接不会放回
+			MaxIdleConnections:  max(min(connCfg.MaxIdleConns, cfg.MaxConnections), 1),
+			ConnectionTimeout:   connCfg.ConnectTimeout.Duration() + connCfg.TLSHandshakeTimeout.Duration(),
+			IdleTimeout:         cfg.IdleTimeout.Duration(),
+			MaxLifetime:         cfg.MaxLifetime.Duration(),
+			HealthCheckInterval: cfg.HealthCheckInterval.Duration(),
+			HealthCheckTimeout:  cfg.HealthCheckTimeout.Duration(),
+			EnableStats:         true,
+			EnableHealthCheck:   cfg.HealthCheckInterval > 0,
+			HealthChecker: func(conn any) bool {
+				pc, ok := conn.(PooledConn)
+				return ok && pc.Healthy()
+			},
+			CloseConn: func(conn any) error {
+				if pc, ok := conn.(PooledConn); ok {
+					return pc.Close()
+				}
+				return nil
+			},
+		},
+		maxConnections: cfg.MaxConnections,
+		acquireTimeout: cfg.AcquireTimeout.Duration(),
+		idleTimeout:    cfg.IdleTimeout.Duration(),
+		reuse:          connCfg.MaxIdleConns > 0,
+		logger:         logger,
+		groups:         make(map[string]*connGroup),
+	}
+	if !p.reuse {
+		report.Note("conn.max_idle_conns 为0，连接用完即关闭，不复用")
+	}
+	return p, report, nil
 }
 
+// Start 启动空闲分组的清理
+func (p *ConnPools) Start() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stop != nil || p.closed || p.idleTimeout <= 0 {
+		return
+	}
+	p.stop = make(chan struct{})
+	p.done = make(chan struct{})
+	go p.sweepLoop(p.stop, p.done)
+}
+
+// Close 关闭所有分组和其中的连接
+func (p *ConnPools) Close() error {
+	p.mu.Lock()
+	p.closed = true
+	stop, done := p.stop, p.done
+	p.stop = nil
+	groups := p.groups
+	p.groups = make(map[string]*connGroup)
+	p.mu.Unlock()
+
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+	for _, g := range groups {
+		g.close()
+	}
+	return nil
+}
+
+// Get 从 key 分组取出一个连接，没有空闲连接时调用 dial 创建
+//
+// 分组不存在时用 dial 创建分组，分组之后创建的连接（包括预热的连接）都使用该 dial，
+// 因此 dial 只能依赖 key 所代表的信息。用完后调用 Put 归还。
+func (p *ConnPools) Get(ctx context.Context, key string, dial func(ctx context.Context) (PooledConn, error)) (*netconnpool.Connection, error) {
+	g, err := p.group(key)
+	if err != nil {
+		return nil, err
+	}
+	g.init.Do(func() {
+		cfg := p.cfg
+		cfg.Dialer = func(ctx context.Context) (any, error) { return dial(ctx) }
+		if g.pool, g.err = netconnpool.NewPool(&cfg); g.err != nil {
+			g.err = fmt.Errorf("创建连接池 %s 失败: %w", key, g.err)
+		}
+	})
+	if g.err != nil {
+		p.release(g)
+		return nil, g.err
+	}
+
+	if err := p.acquireSlot(ctx, g); err != nil {
+		p.release(g)
+		return nil, fmt.Errorf("获取连接 %s 失败: %w", key, err)
+	}
+	conn, err := g.pool.Get(ctx)
+	if err != nil {
+		<-g.slots
+		p.release(g)
+		return nil, err
+	}
+	return conn, nil
+}
+
+// Put 归还 Get 取出的连接，reusable 为 false 或不复用连接时关闭连接
+func (p *ConnPools) Put(key string, conn *netconnpool.Connection, reusable bool) {
+	p.mu.Lock()
+	g := p.groups[key]
+	p.mu.Unlock()
+	if g == nil || !reusable || !p.reuse {
+		// 已关闭的连接在归还时被 netconnpool 移除
+		conn.Close()
+	}
+	if g == nil { // 分组已关闭
+		return
+	}
+	if err := g.pool.Put(conn); err != nil {
+		p.logger.Debug("归还连接失败，group=%s, error=%v", key, err)
+	}
+	<-g.slots
+	p.release(g)
+}
+
+// Groups 返回当前的分组数
+func (p *ConnPools) Groups() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.groups)
+}
+
+// group 返回 key 分组并登记一个使用者，分组不存在时创建
+func (p *ConnPools) group(key string) (*connGroup, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return nil, errConnPoolsClosed
+	}
+	g := p.groups[key]
+	if g == nil {
+		if len(p.groups) >= maxConnGroups {
+			p.evictIdleLocked()
+		}
+		g = &connGroup{slots: make(chan struct{}, p.maxConnections)}
+		p.groups[key] = g
+	}
+	g.active++
+	g.lastUsed = time.Now()
+	return g, nil
+}
+
+// acquireSlot 占用分组的一个连接名额，max_connections 已满时最多等待 acquire_timeout
+func (p *ConnPools) acquireSlot(ctx context.Context, g *connGroup) error {
+	select {
+	case g.slots <- struct{}{}:
+		return nil
+	default:
+	}
+	timer := time.NewTimer(p.acquireTimeout)
+	defer timer.Stop()
+	select {
+	case g.slots <- struct{}{}:
+		return nil
+	case <-timer.C:
+		return fmt.Errorf("%v 内没有可用连接（max_connections=%d）", p.acquireTimeout, p.maxConnections)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// release 注销 group 登记的使用者
+func (p *ConnPools) release(g *connGroup) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	g.active--
+	g.lastUsed = time.Now()
+}
+
+// evictIdleLocked 关闭最久未使用的空闲分组，调用方持有 mu
+func (p *ConnPools) evictIdleLocked() {
+	var oldestKey string
+	var oldest *connGroup
+	for key, g := range p.groups {
+		if g.active == 0 && (oldest == nil || g.lastUsed.Before(oldest.lastUsed)) {
+			oldestKey, oldest = key, g
+		}
+	}
+	if oldest != nil {
+		delete(p.groups, oldestKey)
+		go oldest.close()
+	}
+}
+
+func (p *ConnPools) sweepLoop(stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(p.idleTimeout)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			p.sweep(time.Now())
+		}
+	}
+}
+
+// sweep 关闭连续 idle_timeout 没有使用的分组
+func (p *ConnPools) sweep(now time.Time) {
+	var idle []*connGroup
+	p.mu.Lock()
+	for key, g := range p.groups {
+		if g.active == 0 && now.Sub(g.lastUsed) >= p.idleTimeout {
+			delete(p.groups, key)
+			idle = append(idle, g)
+		}
+	}
+	p.mu.Unlock()
+	for _, g := range idle {
+		g.close()
+	}
+	if len(idle) > 0 {
+		p.logger.Debug("关闭 %d 个空闲的连接分组", len(idle))
+	}
+}
+
+// close 关闭分组的连接池，分组正在创建时等待创建完成
+func (g *connGroup) close() {
+	g.init.Do(func() { g.err = errConnPoolsClosed })
+	if g.pool != nil {
+		g.pool.Close()
+	}
+}
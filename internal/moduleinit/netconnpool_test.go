@@ -0,0 +1,139 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package moduleinit
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/vistone/crawler-system/internal/config"
+)
+
+type fakePooledConn struct {
+	closed atomic.Bool
+}
+
+func (c *fakePooledConn) Close() error  { c.closed.Store(true); return nil }
+func (c *fakePooledConn) Healthy() bool { return !c.closed.Load() }
+
+func newTestConnPools(t *testing.T, maxConnections, maxIdleConns int) *ConnPools {
+	t.Helper()
+	poolCfg := config.NetConnPoolConfig{
+		MaxConnections: maxConnections,
+		AcquireTimeout: config.Duration(50 * time.Millisecond),
+		IdleTimeout:    config.Duration(time.Minute),
+		MaxLifetime:    config.Duration(time.Hour),
+	}
+	connCfg := config.ConnConfig{ConnectTimeout: config.Duration(time.Second), MaxIdleConns: maxIdleConns}
+	p, _, err := InitNetConnPool(&poolCfg, &connCfg, &recordingLogger{})
+	if err != nil {
+		t.Fatalf("InitNetConnPool: %v", err)
+	}
+	t.Cleanup(func() { p.Close() })
+	return p
+}
+
+// countingDial 返回创建 fakePooledConn 的 dial 函数和已创建的连接数
+func countingDial() (func(context.Context) (PooledConn, error), *atomic.Int32) {
+	var dials atomic.Int32
+	return func(context.Context) (PooledConn, error) {
+		dials.Add(1)
+		return &fakePooledConn{}, nil
+	}, &dials
+}
+
+func TestConnPoolsReuse(t *testing.T) {
+	tests := []struct {
+		name         string
+		maxIdleConns int
+		reusable     bool
+		wantDials    int32
+	}{
+		{"复用归还的连接", 2, true, 1},
+		{"归还时不可复用", 2, false, 3},
+		{"max_idle_conns 为0", 0, true, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestConnPools(t, 4, tt.maxIdleConns)
+			dial, dials := countingDial()
+			for i := 0; i < 3; i++ {
+				conn, err := p.Get(context.Background(), "https://example.com:443#chrome", dial)
+				if err != nil {
+					t.Fatalf("Get: %v", err)
+				}
+				p.Put("https://example.com:443#chrome", conn, tt.reusable)
+			}
+			if got := dials.Load(); got != tt.wantDials {
+				t.Errorf("创建了 %d 个连接，want %d", got, tt.wantDials)
+			}
+		})
+	}
+}
+
+func TestConnPoolsMaxConnections(t *testing.T) {
+	p := newTestConnPools(t, 1, 1)
+	dial, _ := countingDial()
+	const key = "http://example.com:80#chrome"
+
+	conn, err := p.Get(context.Background(), key, dial)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if _, err := p.Get(context.Background(), key, dial); err == nil {
+		t.Fatal("max_connections 已满时 Get 应在 acquire_timeout 后失败")
+	}
+	// 其他分组不受影响
+	other, err := p.Get(context.Background(), "http://example.org:80#chrome", dial)
+	if err != nil {
+		t.Fatalf("其他分组 Get: %v", err)
+	}
+	p.Put("http://example.org:80#chrome", other, true)
+
+	done := make(chan error, 1)
+	go func() {
+		c, err := p.Get(context.Background(), key, dial)
+		if err == nil {
+			p.Put(key, c, true)
+		}
+		done <- err
+	}()
+	p.Put(key, conn, true)
+	if err := <-done; err != nil {
+		t.Fatalf("连接归还后等待中的 Get 失败: %v", err)
+	}
+}
+
+func TestConnPoolsSweepClosesIdleGroups(t *testing.T) {
+	p := newTestConnPools(t, 2, 2)
+	dial, _ := countingDial()
+	const key = "http://example.com:80#chrome"
+
+	conn, err := p.Get(context.Background(), key, dial)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	fake := conn.GetConn().(*fakePooledConn)
+
+	p.sweep(time.Now().Add(time.Hour))
+	if p.Groups() != 1 {
+		t.Fatal("有连接在使用中的分组不应被关闭")
+	}
+	p.Put(key, conn, true)
+
+	p.sweep(time.Now())
+	if p.Groups() != 1 {
+		t.Fatal("刚使用过的分组不应被关闭")
+	}
+	p.sweep(time.Now().Add(p.idleTimeout))
+	if p.Groups() != 0 {
+		t.Fatal("空闲超过 idle_timeout 的分组应被关闭")
+	}
+	if !fake.closed.Load() {
+		t.Error("关闭分组时应关闭其中的空闲连接")
+	}
+}
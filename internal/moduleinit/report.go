@@ -0,0 +1,63 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package moduleinit
+
+import (
+	"fmt"
+	"strings"
+)
+
+// StartupReport 模块初始化报告，记录生效的配置和初始化时检测到的资源
+type StartupReport struct {
+	Module    string       // 模块名
+	Title     string       // 显示名称
+	Settings  []ReportItem // 生效的配置
+	Resources []ReportItem // 初始化时检测到的资源，如本地IPv4/IPv6地址
+	Warnings  []string     // 需要关注的问题
+	Notes     []string     // 补充说明
+}
+
+// ReportItem 报告中的一项
+type ReportItem struct {
+	Key   string      // 机器可读的键，配置项与 TOML 键名一致
+	Label string      // 显示名称
+	Value interface{} // 值，列表类型为 []string
+}
+
+// NewStartupReport 创建模块初始化报告
+func NewStartupReport(module, title string) *StartupReport {
+	return &StartupReport{Module: module, Title: title}
+}
+
+// Set 记录一项生效的配置
+func (r *StartupReport) Set(key, label string, value interface{}) {
+	r.Settings = append(r.Settings, ReportItem{Key: key, Label: label, Value: value})
+}
+
+// Detect 记录一项初始化时检测到的资源
+func (r *StartupReport) Detect(key, label string, value interface{}) {
+	r.Resources = append(r.Resources, ReportItem{Key: key, Label: label, Value: value})
+}
+
+// Warn 记录一条警告
+func (r *StartupReport) Warn(format string, args ...interface{}) {
+	r.Warnings = append(r.Warnings, fmt.Sprintf(format, args...))
+}
+
+// Note 记录一条说明
+func (r *StartupReport) Note(format string, args ...interface{}) {
+	r.Notes = append(r.Notes, fmt.Sprintf(format, args...))
+}
+
+// Fields 以 key=value 形式返回配置和资源，用于单行日志
+func (r *StartupReport) Fields() string {
+	items := make([]string, 0, len(r.Settings)+len(r.Resources))
+	for _, list := range [][]ReportItem{r.Settings, r.Resources} {
+		for _, item := range list {
+			items = append(items, fmt.Sprintf("%s=%v", item.Key, item.Value))
+		}
+	}
+	return strings.Join(items, ", ")
+}
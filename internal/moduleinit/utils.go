@@ -4,7 +4,11 @@
 
 package moduleinit
 
-import "fmt"
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
 
 // getDisplayValue 获取显示值，如果为空则返回默认值
 func getDisplayValue(value, defaultValue string) string {
@@ -22,3 +26,28 @@ func getBrowserList(browsers []string) string {
 	return fmt.Sprintf("%v", browsers)
 }
 
+// getOSList 获取操作系统列表显示字符串
+func getOSList(oses []string) string {
+	if len(oses) == 0 {
+		return "全部操作系统"
+	}
+	return fmt.Sprintf("%v", oses)
+}
+
+// getSecretDisplay 获取敏感值的显示文本，不输出明文
+func getSecretDisplay(value, defaultValue string) string {
+	if value == "" {
+		return defaultValue
+	}
+	return "已配置（已隐藏）"
+}
+
+// getLevelList 获取按模块覆盖的日志级别显示字符串，按模块名排序
+func getLevelList(levels map[string]string) string {
+	pairs := make([]string, 0, len(levels))
+	for name, level := range levels {
+		pairs = append(pairs, name+"="+level)
+	}
+	sort.Strings(pairs)
+	return "[" + strings.Join(pairs, " ") + "]"
+}
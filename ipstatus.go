@@ -0,0 +1,30 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package crawler
+
+import (
+	"path/filepath"
+
+	"github.com/vistone/crawler-system/internal/logging"
+	"github.com/vistone/crawler-system/internal/moduleinit"
+)
+
+// ipStatusFileName 黑白名单持久化文件名，位于 system.data_dir 下
+const ipStatusFileName = "ip_status.json"
+
+// IPStatusStorePath 返回黑白名单持久化文件的路径
+func IPStatusStorePath(cfg *SystemConfig) string {
+	return filepath.Join(cfg.System.DataDir, ipStatusFileName)
+}
+
+// OpenIPStatusManager 不启动系统，直接打开黑白名单
+//
+// 与运行中的系统共享 IPStatusStorePath 指向的文件，用于命令行工具查看和修改黑白名单。
+// 修改在文件锁内基于文件的最新内容进行，不会覆盖运行中的系统同时写入的修改；
+// 运行中的系统下次读取黑白名单时发现文件变化并重新加载，无需重启。
+func OpenIPStatusManager(cfg *SystemConfig) (IPStatusManagerInterface, error) {
+	manager, _, err := moduleinit.InitIPStatusManager(&cfg.IPStatus, IPStatusStorePath(cfg), logging.Discard())
+	return manager, err
+}
@@ -0,0 +1,390 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package crawler
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	fhttp "github.com/bogdanfinn/fhttp"
+)
+
+// ipTestRequest 测试一个目标IP的请求，由 [ip_pool_test] 或 [blacklist_recovery] 配置得到
+type ipTestRequest struct {
+	url            string // 已将 {domain} 替换为域名的测试URL
+	method         string
+	timeout        time.Duration
+	useFingerprint bool
+}
+
+// ipTestVerdict 测试结果对黑白名单的影响
+type ipTestVerdict int
+
+const (
+	verdictNone      ipTestVerdict = iota // 状态码不在任何列表中，或没有收到响应
+	verdictWhitelist                      // 状态码在 success_status_codes 中
+	verdictBlacklist                      // 状态码在 forbidden_status_codes 中
+)
+
+// judgeStatus 按 ip_pool_test 的状态码列表判断测试结果
+func judgeStatus(status int, cfg *IPPoolTestConfig) ipTestVerdict {
+	switch {
+	case slices.Contains(cfg.SuccessStatusCodes, status):
+		return verdictWhitelist
+	case slices.Contains(cfg.ForbiddenStatusCodes, status):
+		return verdictBlacklist
+	}
+	return verdictNone
+}
+
+// startIPTests 启动目标IP测试
+//
+// 启动时和之后每隔 ip_pool_test.test_interval 测试一轮 target_domains 解析出的、不在黑名单中的IP；
+// 启用 blacklist_recovery 时每隔 check_interval 重新测试这些域名解析出的、在黑名单中的IP。
+// 未配置目标域名时不做任何事。
+func (s *System) startIPTests(cfg *SystemConfig) {
+	if len(cfg.IPPoolTest.TargetDomains) == 0 || s.Logger == nil || s.IPStatusManager == nil || s.ConnManager == nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	s.mu.Lock()
+	s.ipTestCancel, s.ipTestDone = cancel, done
+	s.mu.Unlock()
+
+	poolCfg := cfg.IPPoolTest
+	recoveryCfg := cfg.BlacklistRecovery
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		runEvery(ctx, poolCfg.TestInterval.Duration(), true, func() {
+			s.testIPPool(ctx, &poolCfg)
+		})
+	}()
+	if recoveryCfg.Enabled {
+		lastTested := make(map[string]time.Time)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			runEvery(ctx, recoveryCfg.CheckInterval.Duration(), false, func() {
+				s.recoverBlacklist(ctx, &recoveryCfg, &poolCfg, lastTested)
+			})
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+}
+
+// stopIPTests 停止目标IP测试，等待进行中的测试结束
+func (s *System) stopIPTests() {
+	s.mu.Lock()
+	cancel, done := s.ipTestCancel, s.ipTestDone
+	s.ipTestCancel, s.ipTestDone = nil, nil
+	s.mu.Unlock()
+
+	if cancel == nil {
+		return
+	}
+	cancel()
+	<-done
+}
+
+// runEvery 每隔 interval 调用一次 fn，直到 ctx 取消；immediately 为 true 时先调用一次
+func runEvery(ctx context.Context, interval time.Duration, immediately bool, fn func()) {
+	if immediately {
+		fn()
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			fn()
+		}
+	}
+}
+
+// testIPPool 测试一轮目标IP：状态码在 success_status_codes 中的IP加入白名单，
+// 在 forbidden_status_codes 中的加入黑名单；没有收到响应时间隔 retry_interval 重试，最多 retry_count 次
+func (s *System) testIPPool(ctx context.Context, cfg *IPPoolTestConfig) {
+	log := s.ModuleLogger("ipstatus")
+	var mu sync.Mutex
+	var tested, whitelisted, blacklisted, failed int
+
+	s.forEachTargetIP(ctx, cfg.TargetDomains, cfg.MaxConcurrent, func(status string) bool {
+		return status != "blacklist"
+	}, func(domain string, ip net.IP) {
+		req := ipTestRequest{
+			url:            strings.ReplaceAll(cfg.TestURL, "{domain}", domain),
+			method:         cfg.TestMethod,
+			timeout:        cfg.TestTimeout.Duration(),
+			useFingerprint: cfg.UseFingerprint,
+		}
+		var code int
+		var err error
+		for attempt := 0; attempt <= cfg.RetryCount; attempt++ {
+			if attempt > 0 && !sleepContext(ctx, cfg.RetryInterval.Duration()) {
+				return
+			}
+			if code, err = s.probeIP(ctx, ip, req); err == nil || ctx.Err() != nil {
+				break
+			}
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		verdict := verdictNone
+		if err != nil {
+			log.Debug("测试目标IP失败，domain=%s, ip=%s, error=%v", domain, ip, err)
+		} else {
+			verdict = judgeStatus(code, cfg)
+			log.Debug("测试目标IP完成，domain=%s, ip=%s, status=%d", domain, ip, code)
+		}
+		s.applyVerdict(ip.String(), verdict, fmt.Sprintf("IP测试 %s 返回 %d", req.url, code))
+
+		mu.Lock()
+		defer mu.Unlock()
+		tested++
+		switch {
+		case err != nil:
+			failed++
+		case verdict == verdictWhitelist:
+			whitelisted++
+		case verdict == verdictBlacklist:
+			blacklisted++
+		}
+	})
+	if ctx.Err() != nil {
+		return
+	}
+	log.Info("目标IP测试完成，tested=%d, whitelisted=%d, blacklisted=%d, no_response=%d", tested, whitelisted, blacklisted, failed)
+	s.refreshAvailability()
+}
+
+// recoverBlacklist 重新测试黑名单中的目标IP，状态码在 ip_pool_test.success_status_codes 中的IP移回白名单
+//
+// 同一个IP每 ip_test_interval 最多测试一次，lastTested 记录各IP最近的测试时间。
+func (s *System) recoverBlacklist(ctx context.Context, cfg *BlacklistRecoveryConfig, poolCfg *IPPoolTestConfig, lastTested map[string]time.Time) {
+	log := s.ModuleLogger("ipstatus")
+	blacklist := s.IPStatusManager.GetBlacklist()
+	for ip := range lastTested {
+		if _, ok := blacklist[ip]; !ok {
+			delete(lastTested, ip)
+		}
+	}
+	if len(blacklist) == 0 {
+		return
+	}
+
+	now := time.Now()
+	var mu sync.Mutex
+	var recovered int
+	s.forEachTargetIP(ctx, poolCfg.TargetDomains, cfg.MaxConcurrent, func(status string) bool {
+		return status == "blacklist"
+	}, func(domain string, ip net.IP) {
+		key := ip.String()
+		mu.Lock()
+		if last, ok := lastTested[key]; ok && now.Sub(last) < cfg.IPTestInterval.Duration() {
+			mu.Unlock()
+			return
+		}
+		lastTested[key] = now
+		mu.Unlock()
+
+		req := ipTestRequest{
+			url:            strings.ReplaceAll(cfg.TestURL, "{domain}", domain),
+			method:         cfg.TestMethod,
+			timeout:        cfg.TestTimeout.Duration(),
+			useFingerprint: cfg.UseFingerprint,
+		}
+		code, err := s.probeIP(ctx, ip, req)
+		if err != nil {
+			log.Debug("黑名单恢复测试失败，domain=%s, ip=%s, error=%v", domain, ip, err)
+			return
+		}
+		if judgeStatus(code, poolCfg) != verdictWhitelist {
+			log.Debug("黑名单恢复测试未通过，domain=%s, ip=%s, status=%d", domain, ip, code)
+			return
+		}
+		s.applyVerdict(key, verdictWhitelist, "")
+		mu.Lock()
+		recovered++
+		mu.Unlock()
+	})
+	if recovered > 0 {
+		log.Info("黑名单恢复完成，%d 个IP已移回白名单", recovered)
+		s.refreshAvailability()
+	}
+}
+
+// forEachTargetIP 对各域名解析出的、黑白名单状态满足 want 的IP并发调用 fn，同时最多 concurrency 个，全部结束后返回
+func (s *System) forEachTargetIP(ctx context.Context, domains []string, concurrency int, want func(status string) bool, fn func(domain string, ip net.IP)) {
+	log := s.ModuleLogger("ipstatus")
+	sem := make(chan struct{}, max(concurrency, 1))
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for _, domain := range domains {
+		ips, err := s.lookupHost(ctx, domain)
+		if err != nil {
+			log.Warn("解析测试域名失败，domain=%s, error=%v", domain, err)
+			continue
+		}
+		for _, ip := range ips {
+			if !want(s.IPStatusManager.GetStatus(ip.String())) {
+				continue
+			}
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() { <-sem }()
+				fn(domain, ip)
+			}()
+		}
+	}
+}
+
+// applyVerdict 按测试结果更新黑白名单，状态没有变化时不做任何事
+func (s *System) applyVerdict(ip string, verdict ipTestVerdict, reason string) {
+	var err error
+	switch status := s.IPStatusManager.GetStatus(ip); {
+	case verdict == verdictWhitelist && status != "whitelist":
+		err = s.IPStatusManager.AddToWhitelist(ip)
+	case verdict == verdictBlacklist && status != "blacklist":
+		err = s.IPStatusManager.AddToBlacklist(ip, reason)
+	}
+	if err != nil {
+		s.ModuleLogger("ipstatus").Warn("更新IP %s 的黑白名单状态失败，error=%v", ip, err)
+	}
+}
+
+// probeIP 连接指定的目标IP发送测试请求，返回响应状态码；没有收到响应时返回错误
+//
+// 不跟随重定向；use_fingerprint 时使用指纹的 TLS 握手和请求头，否则使用标准库的 HTTP 客户端。
+func (s *System) probeIP(ctx context.Context, ip net.IP, req ipTestRequest) (int, error) {
+	u, err := url.Parse(req.url)
+	if err != nil {
+		return 0, fmt.Errorf("解析测试URL失败: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return 0, fmt.Errorf("不支持的测试URL协议 %q，只支持 http 和 https", u.Scheme)
+	}
+	port := u.Port()
+	if port == "" {
+		port = map[string]string{"http": "80", "https": "443"}[u.Scheme]
+	}
+	cfg := s.CurrentConfig()
+	if req.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, req.timeout)
+		defer cancel()
+	}
+
+	release, err := s.ConnManager.Acquire(ctx, u.Hostname())
+	if err != nil {
+		return 0, fmt.Errorf("等待目标主机的连接名额失败: %w", err)
+	}
+	defer release()
+	conn, _, _, err := s.dialTarget(ctx, []net.IP{ip}, port, &cfg.Conn)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	deadline, _ := ctx.Deadline()
+	conn = s.ConnManager.WrapConn(conn, deadline)
+
+	if !req.useFingerprint {
+		return probePlain(ctx, conn, u, req.method, &cfg.Conn)
+	}
+
+	fp, err := s.FingerprintManager.Next(ctx, u.Hostname())
+	if err != nil {
+		return 0, fmt.Errorf("选择指纹失败: %w", err)
+	}
+	freq, err := buildFetchRequest(ctx, FetchRequest{Method: req.method, URL: req.url}, fp)
+	if err != nil {
+		return 0, err
+	}
+	var resp *fhttp.Response
+	if u.Scheme == "https" {
+		tlsConn, err := fingerprintHandshake(ctx, conn, u.Hostname(), fp, &cfg.Conn)
+		if err != nil {
+			return 0, err
+		}
+		resp, err = roundTripTLS(tlsConn, freq, fp.Profile)
+		if err != nil {
+			return 0, fmt.Errorf("发送测试请求失败: %w", err)
+		}
+	} else if resp, err = roundTripHTTP1(conn, bufio.NewReader(conn), freq); err != nil {
+		return 0, fmt.Errorf("发送测试请求失败: %w", err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// probePlain 在已建立的连接上用标准库的 HTTP 客户端发送测试请求
+func probePlain(ctx context.Context, conn net.Conn, u *url.URL, method string, cfg *ConnConfig) (int, error) {
+	var used bool
+	transport := &http.Transport{
+		DialContext: func(context.Context, string, string) (net.Conn, error) {
+			if used {
+				return nil, errors.New("测试连接已使用")
+			}
+			used = true
+			return conn, nil
+		},
+		TLSClientConfig:   &tls.Config{ServerName: u.Hostname(), InsecureSkipVerify: cfg.InsecureSkipVerify},
+		ForceAttemptHTTP2: true,
+		DisableKeepAlives: true,
+	}
+	defer transport.CloseIdleConnections()
+	client := &http.Client{
+		Transport:     transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), nil)
+	if err != nil {
+		return 0, fmt.Errorf("创建测试请求失败: %w", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("发送测试请求失败: %w", err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// sleepContext 等待 d，ctx 先结束时返回 false
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
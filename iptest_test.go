@@ -0,0 +1,162 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package crawler
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/vistone/crawler-system/internal/logging"
+	"github.com/vistone/crawler-system/internal/moduleinit"
+)
+
+func TestJudgeStatus(t *testing.T) {
+	cfg := &IPPoolTestConfig{SuccessStatusCodes: []int{200, 204}, ForbiddenStatusCodes: []int{403}}
+	tests := []struct {
+		status int
+		want   ipTestVerdict
+	}{
+		{200, verdictWhitelist},
+		{204, verdictWhitelist},
+		{403, verdictBlacklist},
+		{404, verdictNone},
+		{503, verdictNone},
+	}
+	for _, tt := range tests {
+		if got := judgeStatus(tt.status, cfg); got != tt.want {
+			t.Errorf("judgeStatus(%d) = %v, want %v", tt.status, got, tt.want)
+		}
+	}
+}
+
+// newIPTestSystem 创建只初始化IP测试所需模块的系统，测试URL指向本地的 HTTP 服务
+func newIPTestSystem(t *testing.T, handler http.HandlerFunc) (*System, string) {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	_, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+
+	path := filepath.Join(t.TempDir(), "config.toml")
+	writeTestConfig(t, path, `
+[domaindns]
+cache_enabled = false
+pollution_detection = false
+
+[local_ip_pool]
+health_check_enabled = false
+`)
+	s, err := NewSystem(context.Background(), path)
+	if err != nil {
+		t.Fatalf("NewSystem: %v", err)
+	}
+	s.Logger = logging.Discard()
+	if s.FingerprintManager, _, err = moduleinit.InitFingerprint(&s.Config.Fingerprint, s.Logger); err != nil {
+		t.Fatalf("InitFingerprint: %v", err)
+	}
+	if s.ConnManager, _, err = moduleinit.InitConn(&s.Config.Conn, s.Logger); err != nil {
+		t.Fatalf("InitConn: %v", err)
+	}
+	if s.IPStatusManager, _, err = moduleinit.InitIPStatusManager(&s.Config.IPStatus, "", s.Logger); err != nil {
+		t.Fatalf("InitIPStatusManager: %v", err)
+	}
+	s.DNSResolver = moduleinit.NewDNSResolver(&s.Config.DomainDNS, s.Logger)
+	return s, "http://{domain}:" + port
+}
+
+func TestProbeIP(t *testing.T) {
+	s, base := newIPTestSystem(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if strings.HasSuffix(r.URL.Path, "/redirect") {
+			http.Redirect(w, r, "/", http.StatusFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	ip := net.ParseIP("127.0.0.1")
+	url := strings.ReplaceAll(base, "{domain}", "localhost")
+
+	for _, fp := range []bool{false, true} {
+		req := ipTestRequest{url: url + "/", method: http.MethodHead, timeout: 5 * time.Second, useFingerprint: fp}
+		if code, err := s.probeIP(context.Background(), ip, req); err != nil || code != http.StatusNoContent {
+			t.Errorf("use_fingerprint=%v: probeIP = %d, %v, want 204", fp, code, err)
+		}
+		req.url = url + "/redirect"
+		if code, err := s.probeIP(context.Background(), ip, req); err != nil || code != http.StatusFound {
+			t.Errorf("use_fingerprint=%v: 重定向 probeIP = %d, %v, want 302（不跟随重定向）", fp, code, err)
+		}
+	}
+}
+
+func TestTestIPPoolAndBlacklistRecovery(t *testing.T) {
+	var status atomic.Int32
+	status.Store(http.StatusForbidden)
+	s, base := newIPTestSystem(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(int(status.Load()))
+	})
+	poolCfg := IPPoolTestConfig{
+		TargetDomains:        []string{"127.0.0.1"},
+		TestURL:              base + "/",
+		TestMethod:           http.MethodGet,
+		MaxConcurrent:        2,
+		TestTimeout:          Duration(5 * time.Second),
+		SuccessStatusCodes:   []int{200},
+		ForbiddenStatusCodes: []int{403},
+	}
+	ctx := context.Background()
+
+	s.testIPPool(ctx, &poolCfg)
+	if got := s.IPStatusManager.GetStatus("127.0.0.1"); got != "blacklist" {
+		t.Fatalf("返回 403 后IP状态为 %s，want blacklist", got)
+	}
+
+	// 黑名单中的IP不参与常规测试
+	status.Store(http.StatusOK)
+	s.testIPPool(ctx, &poolCfg)
+	if got := s.IPStatusManager.GetStatus("127.0.0.1"); got != "blacklist" {
+		t.Fatalf("常规测试不应改变黑名单IP的状态，got %s", got)
+	}
+
+	recoveryCfg := BlacklistRecoveryConfig{
+		Enabled:        true,
+		IPTestInterval: Duration(time.Hour),
+		MaxConcurrent:  1,
+		TestTimeout:    Duration(5 * time.Second),
+		TestURL:        base + "/",
+		TestMethod:     http.MethodHead,
+	}
+	lastTested := make(map[string]time.Time)
+	status.Store(http.StatusForbidden)
+	s.recoverBlacklist(ctx, &recoveryCfg, &poolCfg, lastTested)
+	if got := s.IPStatusManager.GetStatus("127.0.0.1"); got != "blacklist" {
+		t.Fatalf("恢复测试仍返回 403 时IP状态为 %s，want blacklist", got)
+	}
+
+	// ip_test_interval 内不重复测试
+	status.Store(http.StatusOK)
+	s.recoverBlacklist(ctx, &recoveryCfg, &poolCfg, lastTested)
+	if got := s.IPStatusManager.GetStatus("127.0.0.1"); got != "blacklist" {
+		t.Fatalf("ip_test_interval 内不应再次测试，IP状态为 %s", got)
+	}
+
+	lastTested["127.0.0.1"] = time.Now().Add(-2 * time.Hour)
+	s.recoverBlacklist(ctx, &recoveryCfg, &poolCfg, lastTested)
+	if got := s.IPStatusManager.GetStatus("127.0.0.1"); got != "whitelist" {
+		t.Fatalf("恢复测试通过后IP状态为 %s，want whitelist", got)
+	}
+	s.recoverBlacklist(ctx, &recoveryCfg, &poolCfg, lastTested)
+	if _, ok := lastTested["127.0.0.1"]; ok {
+		t.Error("移出黑名单的IP应从测试时间记录中删除")
+	}
+}
@@ -0,0 +1,424 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// State 系统运行状态
+type State int
+
+const (
+	StateCreated      State = iota // 已创建：配置已加载，模块尚未初始化
+	StateInitializing              // 初始化中
+	StateStandby                   // 待机：模块正常运行，但白名单为空，不参与爬取
+	StateRunning                   // 正常运行
+	StateDraining                  // 停止中：不再接受新任务，等待进行中的任务结束并关闭模块
+	StateStopped                   // 已停止
+	StateFailed                    // 失败：启动失败（已初始化的模块已回滚）或运行中出现不可恢复的错误，需要调用 Stop
+)
+
+var stateNames = map[State]string{
+	StateCreated:      "created",
+	StateInitializing: "initializing",
+	StateStandby:      "standby",
+	StateRunning:      "running",
+	StateDraining:     "draining",
+	StateStopped:      "stopped",
+	StateFailed:       "failed",
+}
+
+func (s State) String() string {
+	if name, ok := stateNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("State(%d)", int(s))
+}
+
+// stateTransitions 允许的状态转换
+var stateTransitions = map[State][]State{
+	StateCreated:      {StateInitializing, StateStopped},
+	StateInitializing: {StateStandby, StateRunning, StateFailed},
+	StateStandby:      {StateRunning, StateDraining, StateFailed},
+	StateRunning:      {StateStandby, StateDraining, StateFailed},
+	StateDraining:     {StateStopped, StateFailed},
+	StateFailed:       {StateDraining},
+	StateStopped:      nil,
+}
+
+// canTransition 判断是否允许从 from 转换到 to
+func canTransition(from, to State) bool {
+	for _, s := range stateTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// StateEvent 状态转换事件
+type StateEvent struct {
+	From   State
+	To     State
+	Time   time.Time
+	Reason string // 转换原因
+	Err    error  // 转换到 Failed 时的错误
+}
+
+func (e StateEvent) String() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s -> %s: %s（%v）", e.From, e.To, e.Reason, e.Err)
+	}
+	return fmt.Sprintf("%s -> %s: %s", e.From, e.To, e.Reason)
+}
+
+// stateSubscriber 状态事件订阅者
+//
+// 事件先进入无界队列，再由独立的 goroutine 按顺序投递到通道，
+// 订阅者处理缓慢时不会阻塞状态转换，也不会丢失事件。
+type stateSubscriber struct {
+	ch     chan StateEvent
+	done   chan struct{} // 退订时关闭
+	once   sync.Once
+	notify chan struct{}
+
+	mu    sync.Mutex
+	queue []StateEvent
+	final bool // 已收到最后一个事件，投递完后关闭通道
+}
+
+func newStateSubscriber() *stateSubscriber {
+	sub := &stateSubscriber{
+		ch:     make(chan StateEvent),
+		done:   make(chan struct{}),
+		notify: make(chan struct{}, 1),
+	}
+	go sub.run()
+	return sub
+}
+
+// push 追加事件，final 为 true 时投递完队列中的事件后关闭通道
+func (sub *stateSubscriber) push(e StateEvent, final bool) {
+	sub.mu.Lock()
+	if !sub.final {
+		sub.queue = append(sub.queue, e)
+		sub.final = final
+	}
+	sub.mu.Unlock()
+
+	select {
+	case sub.notify <- struct{}{}:
+	default:
+	}
+}
+
+// close 退订，丢弃未投递的事件并关闭通道
+func (sub *stateSubscriber) close() {
+	sub.once.Do(func() { close(sub.done) })
+}
+
+func (sub *stateSubscriber) run() {
+	defer close(sub.ch)
+	for {
+		sub.mu.Lock()
+		if len(sub.queue) == 0 {
+			final := sub.final
+			sub.mu.Unlock()
+			if final {
+				return
+			}
+			select {
+			case <-sub.notify:
+			case <-sub.done:
+				return
+			}
+			continue
+		}
+		e := sub.queue[0]
+		sub.queue = sub.queue[1:]
+		sub.mu.Unlock()
+
+		select {
+		case sub.ch <- e:
+		case <-sub.done:
+			return
+		}
+	}
+}
+
+// State 返回系统当前状态
+func (s *System) State() State {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	return s.state
+}
+
+// SubscribeState 订阅状态转换事件
+//
+// 返回的通道按发生顺序投递之后的所有转换事件，系统进入 Stopped 后通道关闭。
+// 调用返回的取消函数可提前退订并关闭通道。
+func (s *System) SubscribeState() (<-chan StateEvent, func()) {
+	sub := newStateSubscriber()
+
+	s.stateMu.Lock()
+	if s.state == StateStopped {
+		s.stateMu.Unlock()
+		sub.close()
+		return sub.ch, func() {}
+	}
+	if s.subscribers == nil {
+		s.subscribers = make(map[*stateSubscriber]struct{})
+	}
+	s.subscribers[sub] = struct{}{}
+	s.stateMu.Unlock()
+
+	return sub.ch, func() {
+		s.stateMu.Lock()
+		delete(s.subscribers, sub)
+		s.stateMu.Unlock()
+		sub.close()
+	}
+}
+
+// transition 转换到新状态并通知订阅者，不允许的转换返回错误
+func (s *System) transition(to State, reason string, cause error) error {
+	s.stateMu.Lock()
+	from := s.state
+	if !canTransition(from, to) {
+		s.stateMu.Unlock()
+		return fmt.Errorf("系统状态不允许从 %s 转换到 %s", from, to)
+	}
+	s.state = to
+	e := StateEvent{From: from, To: to, Time: time.Now(), Reason: reason, Err: cause}
+	final := to == StateStopped
+	for sub := range s.subscribers {
+		sub.push(e, final)
+	}
+	if final {
+		s.subscribers = nil
+	}
+	s.stateMu.Unlock()
+
+	if s.Logger != nil {
+		if cause != nil {
+			s.Logger.Error("系统状态变化，%s", e)
+		} else {
+			s.Logger.Info("系统状态变化，%s", e)
+		}
+	}
+	return nil
+}
+
+// fail 转换到 Failed 状态
+func (s *System) fail(reason string, err error) {
+	_ = s.transition(StateFailed, reason, err)
+}
+
+// Start 初始化并启动所有模块
+//
+// 只能在 Created 状态调用一次。ctx 限制初始化和启动的总时长。启动完成后，白名单有IP时进入 Running，
+// 白名单为空且 ip_status.allow_start_when_empty 为 true 时进入 Standby，否则启动失败。
+// 启动失败（包括超时或取消）时，已初始化的模块按初始化的逆序回滚，系统进入 Failed，
+// 返回的错误同时包含失败原因和回滚中的错误；之后仍需调用 Stop 进入 Stopped。
+func (s *System) Start(ctx context.Context) error {
+	if err := s.transition(StateInitializing, "开始初始化模块", nil); err != nil {
+		return err
+	}
+
+	if err := s.initModules(ctx); err != nil {
+		return s.abortStart(ctx, "模块初始化失败", fmt.Errorf("初始化系统失败: %w", err))
+	}
+	if err := s.startModules(ctx); err != nil {
+		return s.abortStart(ctx, "模块启动失败", fmt.Errorf("启动系统失败: %w", err))
+	}
+
+	next, reason, err := s.availability()
+	if err != nil {
+		return s.abortStart(ctx, "启动检查未通过", fmt.Errorf("启动系统失败: %w", err))
+	}
+	if err := s.transition(next, reason, nil); err != nil {
+		return err
+	}
+	s.stateMu.Lock()
+	s.startedAt = time.Now()
+	s.stateMu.Unlock()
+
+	cfg := s.CurrentConfig()
+	if s.Logger != nil {
+		s.Logger.Info("%s %s 已启动", cfg.System.Name, cfg.System.Version)
+	}
+	if cfg.IPStatus.WhitelistMonitoring {
+		s.startWhitelistMonitor(cfg.IPStatus.WhitelistMonitoringInterval.Duration())
+	}
+	s.startStatusReporter(&cfg.StatusReport)
+	s.startIPTests(cfg)
+	return nil
+}
+
+// abortStart 回滚已初始化的模块并进入 Failed，返回合并了回滚错误的启动错误
+func (s *System) abortStart(ctx context.Context, reason string, err error) error {
+	// 启动的 ctx 可能已超时，回滚不受其限制
+	if rollbackErr := s.rollbackModules(context.WithoutCancel(ctx)); rollbackErr != nil {
+		err = errors.Join(err, rollbackErr)
+	}
+	s.fail(reason, err)
+	return err
+}
+
+// availability 根据白名单确定系统应处于 Running 还是 Standby
+func (s *System) availability() (State, string, error) {
+	count := s.IPStatusManager.GetWhitelistCount()
+	if count > 0 {
+		return StateRunning, fmt.Sprintf("白名单IP数量 %d", count), nil
+	}
+	if !s.CurrentConfig().IPStatus.AllowStartWhenEmpty {
+		return StateFailed, "", fmt.Errorf("白名单为空且不允许启动（ip_status.allow_start_when_empty = false）")
+	}
+	return StateStandby, "白名单为空，不参与爬取", nil
+}
+
+// refreshAvailability 在 Running 和 Standby 之间切换
+func (s *System) refreshAvailability() {
+	current := s.State()
+	if current != StateRunning && current != StateStandby {
+		return
+	}
+	count := s.IPStatusManager.GetWhitelistCount()
+	switch {
+	case count > 0 && current == StateStandby:
+		_ = s.transition(StateRunning, fmt.Sprintf("白名单恢复，IP数量 %d", count), nil)
+	case count == 0 && current == StateRunning:
+		_ = s.transition(StateStandby, "白名单为空，停止参与爬取", nil)
+	}
+}
+
+// startWhitelistMonitor 定期检查白名单，切换 Running / Standby
+func (s *System) startWhitelistMonitor(interval time.Duration) {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	s.mu.Lock()
+	s.monitorStop, s.monitorDone = stop, done
+	s.mu.Unlock()
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				s.refreshAvailability()
+			}
+		}
+	}()
+}
+
+// stopWhitelistMonitor 停止白名单监控
+func (s *System) stopWhitelistMonitor() {
+	s.mu.Lock()
+	stop, done := s.monitorStop, s.monitorDone
+	s.monitorStop, s.monitorDone = nil, nil
+	s.mu.Unlock()
+
+	if stop == nil {
+		return
+	}
+	close(stop)
+	<-done
+}
+
+// restartWhitelistMonitor 按新的 ip_status 配置重启白名单监控
+//
+// 系统不在 Running 或 Standby 状态时只停止监控：未启动时由 Start 启动，正在停止时不再启动。
+func (s *System) restartWhitelistMonitor(cfg *IPStatusConfig) {
+	s.stopWhitelistMonitor()
+	switch s.State() {
+	case StateRunning, StateStandby:
+	default:
+		return
+	}
+	if cfg.WhitelistMonitoring {
+		s.startWhitelistMonitor(cfg.WhitelistMonitoringInterval.Duration())
+	}
+}
+
+// Stop 停止系统并释放所有模块
+//
+// 可在 Created、Standby、Running、Failed 状态调用，已停止时直接返回。
+// 系统先进入 Draining，不再接受新的爬取请求，停止后台任务并等待进行中的请求结束，
+// 然后按初始化的逆序停止模块（每个模块的停止时长受 system.module_stop_timeout 限制）并进入 Stopped，
+// 最后关闭日志模块，停止过程的日志都能写入日志文件；关闭日志模块的错误只出现在返回值中。
+// ctx 限制等待进行中的请求和被取消的初始化步骤的时长：等待请求超时后仍会停止模块；
+// 等待初始化步骤超时则进入 Failed，可再次调用 Stop。模块的停止不受 ctx 取消的影响，只受 module_stop_timeout 限制。
+// 出现错误时返回 *ShutdownError，列出未能正常停止的模块。
+func (s *System) Stop(ctx context.Context) error {
+	switch s.State() {
+	case StateStopped:
+		return nil
+	case StateCreated:
+		return s.transition(StateStopped, "系统未启动", nil)
+	}
+	if err := s.transition(StateDraining, "开始停止系统", nil); err != nil {
+		return err
+	}
+	if s.Logger != nil {
+		s.Logger.Info("正在关闭系统...")
+	}
+
+	s.stopWhitelistMonitor()
+	s.stopStatusReporter()
+	s.stopIPTests()
+	s.StopWatchConfig()
+
+	shutdownErr := &ShutdownError{}
+	if err := s.drainRequests(ctx); err != nil {
+		shutdownErr.Errs = append(shutdownErr.Errs, err)
+	}
+
+	// 等待被取消的初始化步骤结束，避免与其并发访问模块
+	s.mu.RLock()
+	pending := s.pendingInit
+	s.mu.RUnlock()
+	if pending != nil {
+		select {
+		case <-pending:
+		case <-ctx.Done():
+			err := fmt.Errorf("等待初始化步骤结束失败: %w", ctx.Err())
+			shutdownErr.Errs = append(shutdownErr.Errs, err)
+			s.fail("等待初始化步骤结束超时", shutdownErr)
+			return shutdownErr
+		}
+	}
+
+	failed, errs := s.stopInitialized(context.WithoutCancel(ctx))
+	shutdownErr.FailedModules = failed
+	shutdownErr.Errs = append(shutdownErr.Errs, errs...)
+	var err error
+	if len(shutdownErr.Errs) == 0 {
+		err = s.transition(StateStopped, "系统已停止", nil)
+	} else {
+		_ = s.transition(StateStopped, fmt.Sprintf("系统已停止，%d 个模块未能正常停止", len(failed)), shutdownErr)
+	}
+
+	// 日志模块最后关闭，停止过程中的日志（包括最后的状态变化）都能写入日志文件
+	if logsErr := s.stopLogsModule(context.WithoutCancel(ctx)); logsErr != nil {
+		shutdownErr.FailedModules = append(shutdownErr.FailedModules, logsModuleName)
+		shutdownErr.Errs = append(shutdownErr.Errs, logsErr)
+	}
+	if len(shutdownErr.Errs) > 0 {
+		return shutdownErr
+	}
+	return err
+}
@@ -0,0 +1,20 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package crawler
+
+import (
+	"github.com/vistone/crawler-system/internal/moduleinit"
+)
+
+// Logger 模块日志接口，ModuleLogger 返回的模块日志器实现该接口
+type Logger = moduleinit.Logger
+
+// ModuleLogger 返回指定模块的日志器
+//
+// 日志附带 module=<name>，级别优先使用 [logs.levels] 中该模块的设置（可热更新），
+// 未设置时使用 logs.level。日志模块初始化之后才可调用，自定义模块在 Init 中获取。
+func (s *System) ModuleLogger(name string) Logger {
+	return s.Logger.Named(name)
+}
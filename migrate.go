@@ -0,0 +1,163 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package crawler
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// CurrentConfigVersion 当前配置文件格式版本
+//
+// 没有 config_version 的配置文件视为版本 0。
+const CurrentConfigVersion = 2
+
+// configMigration 配置文件格式迁移，将 from 版本的文档升级到 from+1
+type configMigration struct {
+	from        int
+	description string
+	apply       func(doc *tomlDocument) error
+}
+
+// configMigrations 按版本排列的迁移步骤
+//
+// 修改配置结构时（重命名、移动配置项、改变单位），在此追加一步迁移并递增 CurrentConfigVersion。
+// 可用的文档操作见 tomlDocument：Set、RenameKey、RenameSection、MoveKey、DeleteKey。
+var configMigrations = []configMigration{
+	{
+		from:        0,
+		description: "写入 config_version，整数时长改为带单位的时长字符串",
+		apply:       migrateDurationUnits,
+	},
+	{
+		from:        1,
+		description: "删除没有实现的配置项",
+		apply:       migrateRemoveUnimplemented,
+	},
+}
+
+// MigrationReport 配置迁移结果
+type MigrationReport struct {
+	From    int      // 迁移前的版本
+	To      int      // 迁移后的版本
+	Applied []string // 执行的迁移步骤说明
+}
+
+// Changed 判断是否执行了迁移
+func (r *MigrationReport) Changed() bool {
+	return r.From != r.To
+}
+
+// MigrateConfig 将配置文件内容升级到当前格式版本
+//
+// 迁移按行编辑原文档，注释、空行和未涉及的配置项原样保留。
+// 文档已是当前版本时原样返回；版本高于当前程序支持的版本时返回错误。
+// include 引入的文件需要分别迁移。
+func MigrateConfig(data []byte) ([]byte, *MigrationReport, error) {
+	version, err := configFileVersion(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	report := &MigrationReport{From: version, To: version}
+	if version == CurrentConfigVersion {
+		return data, report, nil
+	}
+
+	doc := parseTOMLDocument(data)
+	for _, m := range configMigrations {
+		if m.from < version {
+			continue
+		}
+		if err := m.apply(doc); err != nil {
+			return nil, report, fmt.Errorf("配置从版本 %d 迁移到 %d 失败: %w", m.from, m.from+1, err)
+		}
+		report.To = m.from + 1
+		report.Applied = append(report.Applied, fmt.Sprintf("v%d -> v%d: %s", m.from, m.from+1, m.description))
+	}
+	doc.SetTopLevel("config_version", strconv.Itoa(report.To))
+
+	migrated := doc.Bytes()
+	// 迁移结果必须仍是合法的TOML
+	var check map[string]interface{}
+	if err := toml.Unmarshal(migrated, &check); err != nil {
+		return nil, report, fmt.Errorf("迁移后的配置不是合法的TOML: %w", err)
+	}
+	return migrated, report, nil
+}
+
+// configFileVersion 读取配置文件的格式版本
+func configFileVersion(data []byte) (int, error) {
+	var header struct {
+		ConfigVersion int `toml:"config_version"`
+	}
+	if err := toml.Unmarshal(data, &header); err != nil {
+		return 0, fmt.Errorf("解析配置文件失败: %w", err)
+	}
+	if header.ConfigVersion < 0 || header.ConfigVersion > CurrentConfigVersion {
+		return 0, fmt.Errorf("不支持的配置文件版本 %d（当前程序支持 0-%d）", header.ConfigVersion, CurrentConfigVersion)
+	}
+	return header.ConfigVersion, nil
+}
+
+// migrateDurationUnits v0 -> v1：整数时长改为带单位的时长字符串
+//
+// v0 中时长只能写整数，单位隐含在配置项中（多数为秒），如 rotation_interval = 300；
+// v1 统一写成 rotation_interval = "5m"。
+func migrateDurationUnits(doc *tomlDocument) error {
+	units := map[reflect.Type]time.Duration{
+		reflect.TypeOf(Duration(0)):     time.Second,
+		reflect.TypeOf(HourDuration(0)): time.Hour,
+		reflect.TypeOf(DayDuration(0)):  day,
+	}
+
+	walkConfigFields(DefaultConfig(), func(f configField) {
+		unit, ok := units[f.Field.Type]
+		if !ok {
+			return
+		}
+		section, key, _ := strings.Cut(f.Path, ".")
+		for _, table := range doc.tables(section) {
+			raw, ok := doc.Get(table, key)
+			if !ok {
+				continue
+			}
+			n, err := strconv.ParseInt(strings.TrimSpace(raw), 10, 64)
+			if err != nil {
+				continue // 已是时长字符串
+			}
+			d := reflect.New(f.Field.Type).Elem()
+			d.SetInt(n * int64(unit))
+			doc.Set(table, key, strconv.Quote(d.Interface().(fmt.Stringer).String()))
+		}
+	})
+	return nil
+}
+
+// removedInV2 v2 中删除的配置项（配置段 -> 键），这些配置项从未被任何模块读取
+var removedInV2 = map[string][]string{
+	"quic":          {"acquire_timeout", "health_check_interval", "health_check_timeout", "handshake_timeout", "enable_0rtt"},
+	"certificate":   {"letsencrypt_email", "letsencrypt_environment"},
+	"status_report": {"report_client_details", "compress_data"},
+	"crawler":       {"protocol_priority", "protocol_fallback"},
+	"system": {
+		"work_dir", "performance_monitoring", "performance_interval",
+		"health_check_enabled", "health_check_port", "metrics_enabled", "metrics_port",
+	},
+}
+
+// migrateRemoveUnimplemented v1 -> v2：删除没有实现的配置项，严格模式下它们会被当作未知配置项
+func migrateRemoveUnimplemented(doc *tomlDocument) error {
+	for section, keys := range removedInV2 {
+		for _, key := range keys {
+			doc.DeleteKey(section, key)
+		}
+	}
+	return nil
+}
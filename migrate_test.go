@@ -0,0 +1,246 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package crawler
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMigrateConfig(t *testing.T) {
+	tests := []struct {
+		name        string
+		in          string
+		want        string
+		wantFrom    int
+		wantTo      int
+		wantApplied int
+		wantErr     string
+	}{
+		{
+			name: "v0 迁移到当前版本",
+			in: `# 爬虫配置
+[fingerprint]
+rotation_interval = 300 # 轮换间隔
+
+[certificate]
+renewal_check_interval = 12
+renewal_before_days = 30
+
+[system]
+# 工作目录
+work_dir = "/tmp"
+name = "crawler"
+
+[profile.dev.conn]
+connect_timeout = 5
+`,
+			want: `config_version = 2
+
+# 爬虫配置
+[fingerprint]
+rotation_interval = "5m" # 轮换间隔
+
+[certificate]
+renewal_check_interval = "12h"
+renewal_before_days = "30d"
+
+[system]
+name = "crawler"
+
+[profile.dev.conn]
+connect_timeout = "5s"
+`,
+			wantFrom:    0,
+			wantTo:      CurrentConfigVersion,
+			wantApplied: 2,
+		},
+		{
+			name: "v1 删除没有实现的配置项",
+			in: `config_version = 1
+
+[quic]
+enable_0rtt = true
+max_connections = 10
+`,
+			want: `config_version = 2
+
+[quic]
+max_connections = 10
+`,
+			wantFrom:    1,
+			wantTo:      2,
+			wantApplied: 1,
+		},
+		{
+			name:     "当前版本原样返回",
+			in:       "config_version = 2\n\n[conn]\nconnect_timeout = 30 # 秒\n",
+			want:     "config_version = 2\n\n[conn]\nconnect_timeout = 30 # 秒\n",
+			wantFrom: 2,
+			wantTo:   2,
+		},
+		{
+			name:    "版本高于当前程序支持的版本",
+			in:      "config_version = 99\n",
+			wantErr: "不支持的配置文件版本 99",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, report, err := MigrateConfig([]byte(tt.in))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("MigrateConfig error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("MigrateConfig: %v", err)
+			}
+			if string(out) != tt.want {
+				t.Errorf("迁移结果:\n%s\nwant:\n%s", out, tt.want)
+			}
+			if report.From != tt.wantFrom || report.To != tt.wantTo || len(report.Applied) != tt.wantApplied {
+				t.Errorf("迁移报告 %+v，want 从 %d 到 %d，%d 个步骤", report, tt.wantFrom, tt.wantTo, tt.wantApplied)
+			}
+			if report.Changed() != (tt.wantFrom != tt.wantTo) {
+				t.Errorf("Changed() = %v，want %v", report.Changed(), tt.wantFrom != tt.wantTo)
+			}
+
+			// 迁移结果在严格模式下可以加载
+			path := filepath.Join(t.TempDir(), "config.toml")
+			writeTestConfig(t, path, string(out))
+			if _, err := LoadConfig(path, WithStrict(true)); err != nil {
+				t.Errorf("迁移结果加载失败: %v", err)
+			}
+		})
+	}
+}
+
+func TestMigrateConfigRenamesKey(t *testing.T) {
+	// 用重命名配置项的迁移步骤代替 v1 -> v2，验证迁移框架改写键名并保留注释
+	saved := configMigrations
+	t.Cleanup(func() { configMigrations = saved })
+	configMigrations = []configMigration{saved[0], {
+		from:        1,
+		description: "max_conection 改名为 max_connections",
+		apply: func(doc *tomlDocument) error {
+			doc.RenameKey("netconnpool", "max_conection", "max_connections")
+			return nil
+		},
+	}}
+
+	in := `config_version = 1
+
+[netconnpool]
+# 每个目标的连接上限
+max_conection = 10 # 旧键名
+
+[profile.prod.netconnpool]
+max_conection = 50
+`
+	want := `config_version = 2
+
+[netconnpool]
+# 每个目标的连接上限
+max_connections = 10 # 旧键名
+
+[profile.prod.netconnpool]
+max_connections = 50
+`
+	out, report, err := MigrateConfig([]byte(in))
+	if err != nil {
+		t.Fatalf("MigrateConfig: %v", err)
+	}
+	if string(out) != want {
+		t.Fatalf("迁移结果:\n%s\nwant:\n%s", out, want)
+	}
+	if len(report.Applied) != 1 || !strings.Contains(report.Applied[0], "v1 -> v2: max_conection 改名为 max_connections") {
+		t.Fatalf("执行的迁移步骤 %v", report.Applied)
+	}
+}
+
+func TestTOMLDocumentEdits(t *testing.T) {
+	in := `[server]
+listen_address = ":8080" # 监听地址
+
+# 旧的配置段
+[old]
+a = 1
+
+[profile.dev.old]
+a = 2
+`
+	tests := []struct {
+		name string
+		edit func(doc *tomlDocument)
+		want string
+	}{
+		{
+			name: "Set 保留行尾注释",
+			edit: func(doc *tomlDocument) { doc.Set("server", "listen_address", `":9090"`) },
+			want: `[server]
+listen_address = ":9090" # 监听地址
+
+# 旧的配置段
+[old]
+a = 1
+
+[profile.dev.old]
+a = 2
+`,
+		},
+		{
+			name: "RenameSection 同时改写 profile 中的配置段",
+			edit: func(doc *tomlDocument) { doc.RenameSection("old", "new") },
+			want: `[server]
+listen_address = ":8080" # 监听地址
+
+# 旧的配置段
+[new]
+a = 1
+
+[profile.dev.new]
+a = 2
+`,
+		},
+		{
+			name: "MoveKey 移动到另一个配置段",
+			edit: func(doc *tomlDocument) { doc.MoveKey("server", "listen_address", "old", "addr") },
+			want: `[server]
+
+# 旧的配置段
+[old]
+a = 1
+addr = ":8080" # 监听地址
+
+[profile.dev.old]
+a = 2
+`,
+		},
+		{
+			name: "DeleteKey 删除各 profile 中的同名配置项",
+			edit: func(doc *tomlDocument) { doc.DeleteKey("old", "a") },
+			want: `[server]
+listen_address = ":8080" # 监听地址
+
+# 旧的配置段
+[old]
+
+[profile.dev.old]
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := parseTOMLDocument([]byte(in))
+			tt.edit(doc)
+			if got := string(doc.Bytes()); got != tt.want {
+				t.Fatalf("编辑结果:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
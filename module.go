@@ -0,0 +1,362 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Module 系统模块
+//
+// 内置的9个模块和通过 RegisterModule 注册的自定义模块都实现此接口。
+// 系统按依赖关系拓扑排序：依赖的模块先初始化、先启动、后停止，互不依赖的模块并发初始化。
+// 除日志模块外，所有模块隐式依赖日志模块，Init 时 sys.Logger 总是可用。
+type Module interface {
+	// Name 模块名，在系统内唯一
+	Name() string
+	// DependsOn 依赖的模块名
+	DependsOn() []string
+	// Init 初始化模块，可通过 sys 读取配置（CurrentConfig）、日志器和已初始化的依赖模块
+	Init(ctx context.Context, sys *System) error
+	// Start 启动后台任务
+	Start(ctx context.Context) error
+	// Stop 停止后台任务并释放资源；系统停止时所有已初始化的模块都会被调用，即使没有启动
+	Stop(ctx context.Context) error
+	// Health 检查模块健康状态，健康时返回 nil
+	Health(ctx context.Context) error
+}
+
+// logsModuleName 日志模块名，其他模块隐式依赖它
+const logsModuleName = "logs"
+
+// moduleRegistry 模块注册表
+type moduleRegistry struct {
+	modules []Module // 注册顺序
+	byName  map[string]Module
+}
+
+func newModuleRegistry() *moduleRegistry {
+	return &moduleRegistry{byName: make(map[string]Module)}
+}
+
+// register 注册模块，模块名为空或重复时返回错误
+func (r *moduleRegistry) register(m Module) error {
+	name := m.Name()
+	if name == "" {
+		return fmt.Errorf("模块名不能为空")
+	}
+	if _, ok := r.byName[name]; ok {
+		return fmt.Errorf("模块 %s 已注册", name)
+	}
+	r.modules = append(r.modules, m)
+	r.byName[name] = m
+	return nil
+}
+
+// dependencies 返回模块的全部依赖（含隐式依赖的日志模块）
+func (r *moduleRegistry) dependencies(m Module) []string {
+	deps := m.DependsOn()
+	if m.Name() == logsModuleName {
+		return deps
+	}
+	if _, ok := r.byName[logsModuleName]; !ok {
+		return deps
+	}
+	for _, d := range deps {
+		if d == logsModuleName {
+			return deps
+		}
+	}
+	return append([]string{logsModuleName}, deps...)
+}
+
+// levels 按依赖关系分层：每层的模块只依赖前面各层的模块，层内按注册顺序排列
+//
+// 依赖未注册的模块或存在循环依赖时返回错误。
+func (r *moduleRegistry) levels() ([][]Module, error) {
+	indegree := make(map[string]int, len(r.modules))
+	dependents := make(map[string][]string)
+	var errs []error
+	for _, m := range r.modules {
+		for _, d := range r.dependencies(m) {
+			if _, ok := r.byName[d]; !ok {
+				errs = append(errs, fmt.Errorf("模块 %s 依赖的模块 %s 未注册", m.Name(), d))
+				continue
+			}
+			indegree[m.Name()]++
+			dependents[d] = append(dependents[d], m.Name())
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	var levels [][]Module
+	placed := 0
+	for placed < len(r.modules) {
+		var level []Module
+		for _, m := range r.modules {
+			if indegree[m.Name()] == 0 {
+				level = append(level, m)
+			}
+		}
+		if len(level) == 0 {
+			return nil, fmt.Errorf("模块存在循环依赖: %s", r.findCycle(indegree))
+		}
+		for _, m := range level {
+			indegree[m.Name()] = -1 // 标记为已排序
+			for _, d := range dependents[m.Name()] {
+				indegree[d]--
+			}
+		}
+		levels = append(levels, level)
+		placed += len(level)
+	}
+	return levels, nil
+}
+
+// findCycle 在未排序的模块中找出一个依赖环，返回 "a -> b -> a" 形式的描述
+func (r *moduleRegistry) findCycle(indegree map[string]int) string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int)
+	var stack []string
+	var cycle []string
+
+	var visit func(name string) bool
+	visit = func(name string) bool {
+		state[name] = visiting
+		stack = append(stack, name)
+		for _, d := range r.dependencies(r.byName[name]) {
+			if indegree[d] < 0 {
+				continue // 已排序的模块不在环上
+			}
+			switch state[d] {
+			case visiting:
+				for i, n := range stack {
+					if n == d {
+						cycle = append(append([]string{}, stack[i:]...), d)
+						return true
+					}
+				}
+			case unvisited:
+				if visit(d) {
+					return true
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[name] = visited
+		return false
+	}
+
+	for _, m := range r.modules {
+		if indegree[m.Name()] >= 0 && state[m.Name()] == unvisited && visit(m.Name()) {
+			break
+		}
+	}
+	return strings.Join(cycle, " -> ")
+}
+
+// RegisterModule 注册自定义模块
+//
+// 只能在 Start 之前调用。模块会与内置模块一起按依赖关系初始化、启动和停止，
+// 依赖关系（未注册的依赖、循环依赖）在 Start 时检查。
+func (s *System) RegisterModule(m Module) error {
+	if state := s.State(); state != StateCreated {
+		return fmt.Errorf("只能在系统启动前注册模块，当前状态: %s", state)
+	}
+	s.modulesMu.Lock()
+	defer s.modulesMu.Unlock()
+	return s.modules.register(m)
+}
+
+// Module 按名称返回已注册的模块
+func (s *System) Module(name string) (Module, bool) {
+	s.modulesMu.Lock()
+	defer s.modulesMu.Unlock()
+	m, ok := s.modules.byName[name]
+	return m, ok
+}
+
+// Health 检查所有已初始化模块的健康状态，返回模块名到错误的映射（健康的模块为 nil）
+func (s *System) Health(ctx context.Context) map[string]error {
+	result := make(map[string]error)
+	for _, m := range s.initializedModules() {
+		result[m.Name()] = m.Health(ctx)
+	}
+	return result
+}
+
+// initializedModules 返回已初始化的模块（按初始化完成顺序）
+func (s *System) initializedModules() []Module {
+	s.modulesMu.Lock()
+	defer s.modulesMu.Unlock()
+	return append([]Module(nil), s.initialized...)
+}
+
+// initModules 按依赖关系逐层初始化模块，同一层的模块并发初始化
+//
+// 模块的 Init 在 ctx 取消后仍可能继续执行，此时不再等待，
+// 并记录到 pendingInit，Stop 会等待它们结束后再停止模块。
+func (s *System) initModules(ctx context.Context) error {
+	s.modulesMu.Lock()
+	levels, err := s.modules.levels()
+	s.modulesMu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	total := 0
+	for _, level := range levels {
+		if err := s.initLevel(ctx, level); err != nil {
+			return err
+		}
+		total += len(level)
+	}
+
+	s.registerBuiltinReloadHooks()
+
+	s.Logger.Info("系统初始化完成，modules=%d, levels=%d", total, len(levels))
+	return nil
+}
+
+// initLevel 并发初始化同一层的模块
+func (s *System) initLevel(ctx context.Context, level []Module) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	errs := make([]error, len(level))
+	var wg sync.WaitGroup
+	for i, m := range level {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := m.Init(ctx, s); err != nil {
+				errs[i] = fmt.Errorf("初始化模块 %s 失败: %w", m.Name(), err)
+				return
+			}
+			s.modulesMu.Lock()
+			s.initialized = append(s.initialized, m)
+			s.modulesMu.Unlock()
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		s.collectReports(level, errs)
+		return errors.Join(errs...)
+	case <-ctx.Done():
+		s.mu.Lock()
+		s.pendingInit = done
+		s.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// startModules 按初始化顺序启动模块
+func (s *System) startModules(ctx context.Context) error {
+	for _, m := range s.initializedModules() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := m.Start(ctx); err != nil {
+			return fmt.Errorf("启动模块 %s 失败: %w", m.Name(), err)
+		}
+	}
+	return nil
+}
+
+// rollbackModules Start 失败时按初始化的逆序停止已初始化的模块
+//
+// 日志模块保持打开，以便记录启动失败，由之后的 Stop 关闭。
+// 仍在后台执行的初始化步骤（ctx 取消时被放弃的）完成后加入的模块不在此回滚，由 Stop 停止。
+func (s *System) rollbackModules(ctx context.Context) error {
+	count := len(s.initializedModules())
+	if count == 0 {
+		return nil
+	}
+	if s.Logger != nil {
+		s.Logger.Warn("启动失败，回滚已初始化的模块，modules=%d", count)
+	}
+	if _, errs := s.stopInitialized(ctx); len(errs) > 0 {
+		return fmt.Errorf("回滚已初始化的模块失败: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// stopInitialized 按初始化的逆序停止日志模块以外的已初始化模块，每个模块的停止时长受 system.module_stop_timeout 限制
+//
+// 模块在停止前逐个从已初始化列表中移除，与仍在执行的初始化步骤并发时不会遗漏或重复停止。
+// 日志模块留在列表中，由 stopLogsModule 最后关闭。返回未能正常停止的模块及其错误。
+func (s *System) stopInitialized(ctx context.Context) (failed []string, errs []error) {
+	timeout := s.CurrentConfig().System.ModuleStopTimeout.Duration()
+	for {
+		m := s.popInitialized(false)
+		if m == nil {
+			break
+		}
+
+		start := time.Now()
+		if err := stopModule(ctx, m, timeout); err != nil {
+			failed = append(failed, m.Name())
+			errs = append(errs, fmt.Errorf("停止模块 %s 失败: %w", m.Name(), err))
+			if s.Logger != nil {
+				s.ModuleLogger(m.Name()).Error("停止模块失败，error=%v", err)
+			}
+			continue
+		}
+		if s.Logger != nil {
+			s.ModuleLogger(m.Name()).Info("模块已停止，elapsed=%s", time.Since(start).Round(time.Millisecond))
+		}
+	}
+	return failed, errs
+}
+
+// stopLogsModule 停止日志模块（关闭日志文件和访问日志），此后的日志不再写入文件
+//
+// 在其他模块都已停止、停止过程的日志都已写出后调用。
+func (s *System) stopLogsModule(ctx context.Context) error {
+	m := s.popInitialized(true)
+	if m == nil {
+		return nil
+	}
+	if err := stopModule(ctx, m, s.CurrentConfig().System.ModuleStopTimeout.Duration()); err != nil {
+		return fmt.Errorf("停止模块 %s 失败: %w", m.Name(), err)
+	}
+	return nil
+}
+
+// popInitialized 从已初始化列表中取出最后初始化的模块，logs 为 true 时只取日志模块，否则跳过日志模块；
+// 没有可取的模块时返回 nil
+func (s *System) popInitialized(logs bool) Module {
+	s.modulesMu.Lock()
+	defer s.modulesMu.Unlock()
+	for i := len(s.initialized) - 1; i >= 0; i-- {
+		m := s.initialized[i]
+		if (m.Name() == logsModuleName) != logs {
+			continue
+		}
+		s.initialized = slices.Delete(s.initialized, i, i+1)
+		return m
+	}
+	return nil
+}
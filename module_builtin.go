@@ -0,0 +1,121 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package crawler
+
+import "context"
+
+// builtinModule 内置模块，各生命周期方法为 nil 时不做任何事
+type builtinModule struct {
+	name      string
+	dependsOn []string
+	init      func(s *System) (*StartupReport, error)
+	start     func(s *System) error
+	stop      func(s *System) error
+	health    func(s *System) error
+
+	sys    *System        // Init 时记录，供其余生命周期方法使用
+	report *StartupReport // Init 生成的启动报告
+}
+
+func (m *builtinModule) Name() string        { return m.name }
+func (m *builtinModule) DependsOn() []string { return m.dependsOn }
+
+func (m *builtinModule) Init(_ context.Context, sys *System) error {
+	m.sys = sys
+	report, err := m.init(sys)
+	m.report = report
+	return err
+}
+
+func (m *builtinModule) StartupReport() *StartupReport { return m.report }
+
+func (m *builtinModule) Start(context.Context) error { return m.call(m.start) }
+func (m *builtinModule) Stop(context.Context) error  { return m.call(m.stop) }
+func (m *builtinModule) Health(context.Context) error {
+	return m.call(m.health)
+}
+
+func (m *builtinModule) call(fn func(s *System) error) error {
+	if fn == nil || m.sys == nil {
+		return nil
+	}
+	return fn(m.sys)
+}
+
+// builtinModules 返回内置的9个模块，按原有的初始化顺序排列
+func builtinModules() []Module {
+	return []Module{
+		&builtinModule{
+			name: "logs",
+			init: (*System).initLogs,
+			stop: (*System).closeLogs,
+		},
+		&builtinModule{name: "fingerprint", init: (*System).initFingerprint},
+		&builtinModule{
+			name:  "domaindns",
+			init:  (*System).initDomainDNS,
+			start: (*System).startDNSMonitor,
+			stop:  (*System).stopDNSMonitor,
+		},
+		&builtinModule{
+			name:  "localippool",
+			init:  (*System).initLocalIPPool,
+			start: func(s *System) error { s.LocalIPPool.Start(); return nil },
+			stop:  func(s *System) error { return s.LocalIPPool.Close() },
+		},
+		&builtinModule{
+			name:  "certs",
+			init:  (*System).initCerts,
+			start: func(s *System) error { s.CertManager.Start(); return nil },
+			stop:  func(s *System) error { s.CertManager.Stop(); return nil },
+		},
+		&builtinModule{
+			name:   "ipstatus",
+			init:   (*System).initIPStatusManager,
+			health: func(s *System) error { return s.IPStatusManager.CheckSystemHealth() },
+		},
+		&builtinModule{
+			name:      "conn",
+			dependsOn: []string{"fingerprint", "domaindns", "localippool", "certs", "ipstatus"},
+			init:      (*System).initConn,
+		},
+		&builtinModule{
+			name:      "netconnpool",
+			dependsOn: []string{"conn", "fingerprint", "domaindns", "localippool"},
+			init:      (*System).initNetConnPool,
+			start:     func(s *System) error { s.NetConnPool.Start(); return nil },
+			stop:      func(s *System) error { return s.NetConnPool.Close() },
+		},
+		&builtinModule{
+			name:      "quic",
+			dependsOn: []string{"conn", "fingerprint", "domaindns", "localippool"},
+			init:      (*System).initQUICPool,
+			stop: func(s *System) error {
+				s.QUICPool.Close()
+				return nil
+			},
+		},
+	}
+}
+
+// startDNSMonitor 启动DNS监控器，未配置目标域名时没有监控器
+func (s *System) startDNSMonitor() error {
+	if s.DNSMonitor == nil {
+		return nil
+	}
+	s.DNSMonitor.Start()
+	s.ModuleLogger("domaindns").Info("DNS监控器已启动")
+	return nil
+}
+
+// stopDNSMonitor 停止DNS监控器
+func (s *System) stopDNSMonitor() error {
+	if s.DNSMonitor == nil {
+		return nil
+	}
+	s.DNSMonitor.Stop()
+	s.ModuleLogger("domaindns").Info("DNS监控器已停止")
+	return nil
+}
@@ -0,0 +1,266 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package crawler
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vistone/crawler-system/internal/logging"
+)
+
+// moduleCalls 记录测试模块的停止顺序
+type moduleCalls struct {
+	mu    sync.Mutex
+	stops []string
+}
+
+func (c *moduleCalls) stop(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stops = append(c.stops, name)
+}
+
+func (c *moduleCalls) stopped() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return slices.Clone(c.stops)
+}
+
+// fakeModule 测试用模块
+type fakeModule struct {
+	name    string
+	deps    []string
+	initErr error
+	stopErr error
+	started chan struct{} // 不为 nil 时 Init 开始执行时关闭
+	release chan struct{} // 不为 nil 时 Init 等待其关闭
+	calls   *moduleCalls
+}
+
+func (m *fakeModule) Name() string        { return m.name }
+func (m *fakeModule) DependsOn() []string { return m.deps }
+
+func (m *fakeModule) Init(context.Context, *System) error {
+	if m.started != nil {
+		close(m.started)
+	}
+	if m.release != nil {
+		<-m.release
+	}
+	return m.initErr
+}
+
+func (m *fakeModule) Start(context.Context) error { return nil }
+
+func (m *fakeModule) Stop(context.Context) error {
+	m.calls.stop(m.name)
+	return m.stopErr
+}
+
+func (m *fakeModule) Health(context.Context) error { return nil }
+
+// newModuleTestSystem 创建只包含给定模块的系统
+func newModuleTestSystem(t *testing.T, modules ...*fakeModule) *System {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.toml")
+	writeTestConfig(t, path, "")
+	s, err := NewSystem(context.Background(), path)
+	if err != nil {
+		t.Fatalf("NewSystem: %v", err)
+	}
+	s.Logger = logging.Discard()
+	s.modules = newModuleRegistry()
+	for _, m := range modules {
+		if err := s.RegisterModule(m); err != nil {
+			t.Fatalf("RegisterModule(%s): %v", m.name, err)
+		}
+	}
+	return s
+}
+
+func moduleNames(modules []Module) []string {
+	names := make([]string, len(modules))
+	for i, m := range modules {
+		names[i] = m.Name()
+	}
+	return names
+}
+
+func TestModuleRegistryLevels(t *testing.T) {
+	tests := []struct {
+		name    string
+		modules []*fakeModule
+		want    [][]string
+		wantErr string
+	}{
+		{
+			name: "按依赖分层",
+			modules: []*fakeModule{
+				{name: "a"}, {name: "d", deps: []string{"b", "c"}}, {name: "b", deps: []string{"a"}}, {name: "c", deps: []string{"a"}},
+			},
+			want: [][]string{{"a"}, {"b", "c"}, {"d"}},
+		},
+		{
+			name:    "依赖未注册的模块",
+			modules: []*fakeModule{{name: "a", deps: []string{"missing"}}},
+			wantErr: "模块 a 依赖的模块 missing 未注册",
+		},
+		{
+			name: "循环依赖",
+			modules: []*fakeModule{
+				{name: "a"}, {name: "b", deps: []string{"a", "d"}}, {name: "c", deps: []string{"b"}}, {name: "d", deps: []string{"c"}},
+				{name: "e", deps: []string{"b"}},
+			},
+			wantErr: "模块存在循环依赖: b -> d -> c -> b",
+		},
+		{
+			name:    "依赖自身",
+			modules: []*fakeModule{{name: "a", deps: []string{"a"}}},
+			wantErr: "模块存在循环依赖: a -> a",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newModuleRegistry()
+			for _, m := range tt.modules {
+				if err := r.register(m); err != nil {
+					t.Fatalf("register(%s): %v", m.name, err)
+				}
+			}
+			levels, err := r.levels()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("levels() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("levels(): %v", err)
+			}
+			var got [][]string
+			for _, level := range levels {
+				got = append(got, moduleNames(level))
+			}
+			if !slices.EqualFunc(got, tt.want, slices.Equal) {
+				t.Fatalf("levels() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInitModulesFailurePartwayThroughLevelRollsBack(t *testing.T) {
+	calls := &moduleCalls{}
+	errC := errors.New("c 初始化失败")
+	s := newModuleTestSystem(t,
+		&fakeModule{name: "a", calls: calls},
+		&fakeModule{name: "b", deps: []string{"a"}, calls: calls},
+		&fakeModule{name: "c", deps: []string{"a"}, initErr: errC, calls: calls},
+		&fakeModule{name: "d", deps: []string{"a"}, calls: calls},
+		&fakeModule{name: "e", deps: []string{"b"}, calls: calls},
+	)
+
+	err := s.initModules(context.Background())
+	if !errors.Is(err, errC) || !strings.Contains(err.Error(), "初始化模块 c 失败") {
+		t.Fatalf("initModules error = %v, want 包含模块 c 的错误", err)
+	}
+	initialized := moduleNames(s.initializedModules())
+	if got := slices.Sorted(slices.Values(initialized)); !slices.Equal(got, []string{"a", "b", "d"}) {
+		t.Fatalf("已初始化的模块 %v，want a、b、d（同层其他模块完成初始化，后续层不再初始化）", initialized)
+	}
+
+	if err := s.rollbackModules(context.Background()); err != nil {
+		t.Fatalf("rollbackModules: %v", err)
+	}
+	slices.Reverse(initialized)
+	if got := calls.stopped(); !slices.Equal(got, initialized) {
+		t.Fatalf("回滚顺序 %v，want 初始化的逆序 %v", got, initialized)
+	}
+	if len(s.initializedModules()) != 0 {
+		t.Fatal("回滚后仍有已初始化的模块")
+	}
+}
+
+func TestStopAggregatesModuleErrors(t *testing.T) {
+	calls := &moduleCalls{}
+	errB := errors.New("b 停止失败")
+	errC := errors.New("c 停止失败")
+	s := newModuleTestSystem(t,
+		&fakeModule{name: "a", calls: calls},
+		&fakeModule{name: "b", deps: []string{"a"}, stopErr: errB, calls: calls},
+		&fakeModule{name: "c", deps: []string{"b"}, stopErr: errC, calls: calls},
+	)
+	if err := s.initModules(context.Background()); err != nil {
+		t.Fatalf("initModules: %v", err)
+	}
+	for _, state := range []State{StateInitializing, StateRunning} {
+		if err := s.transition(state, "测试", nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := s.Stop(context.Background())
+	var shutdownErr *ShutdownError
+	if !errors.As(err, &shutdownErr) {
+		t.Fatalf("Stop error = %v, want *ShutdownError", err)
+	}
+	if want := []string{"c", "b"}; !slices.Equal(shutdownErr.FailedModules, want) {
+		t.Errorf("FailedModules = %v, want %v", shutdownErr.FailedModules, want)
+	}
+	if !errors.Is(err, errB) || !errors.Is(err, errC) {
+		t.Errorf("Stop error = %v, want 同时包含 b 和 c 的错误", err)
+	}
+	if !strings.Contains(err.Error(), "未能正常停止的模块: c, b") {
+		t.Errorf("Stop error = %q, want 列出未能正常停止的模块", err)
+	}
+	if want := []string{"c", "b", "a"}; !slices.Equal(calls.stopped(), want) {
+		t.Errorf("停止顺序 %v，want %v（模块停止失败后继续停止其余模块）", calls.stopped(), want)
+	}
+	if s.State() != StateStopped {
+		t.Errorf("状态 %s，want %s", s.State(), StateStopped)
+	}
+}
+
+func TestStopWaitsForCanceledInit(t *testing.T) {
+	calls := &moduleCalls{}
+	started, release := make(chan struct{}), make(chan struct{})
+	s := newModuleTestSystem(t,
+		&fakeModule{name: "slow", started: started, release: release, calls: calls},
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	startErr := make(chan error, 1)
+	go func() { startErr <- s.Start(ctx) }()
+	<-started
+	cancel()
+	if err := <-startErr; !errors.Is(err, context.Canceled) {
+		t.Fatalf("Start error = %v, want context.Canceled", err)
+	}
+
+	// 初始化步骤仍在执行时 Stop 等待到 ctx 超时
+	stopCtx, stopCancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer stopCancel()
+	if err := s.Stop(stopCtx); err == nil || !strings.Contains(err.Error(), "等待初始化步骤结束失败") {
+		t.Fatalf("Stop error = %v, want 等待初始化步骤超时", err)
+	}
+	if s.State() != StateFailed {
+		t.Fatalf("状态 %s，want %s", s.State(), StateFailed)
+	}
+
+	// 初始化步骤结束后加入的模块由 Stop 停止
+	close(release)
+	if err := s.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if want := []string{"slow"}; !slices.Equal(calls.stopped(), want) {
+		t.Fatalf("停止了 %v，want %v", calls.stopped(), want)
+	}
+}
@@ -0,0 +1,350 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package crawler
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/vistone/crawler-system/internal/logging"
+)
+
+// ConfigChange 单个配置项的变更
+type ConfigChange struct {
+	Path string      // TOML路径
+	Old  interface{} // 旧值
+	New  interface{} // 新值
+}
+
+func (c ConfigChange) String() string {
+	return fmt.Sprintf("%s: %v -> %v", c.Path, c.Old, c.New)
+}
+
+// DiffConfig 比较两份配置，按声明顺序返回所有发生变化的配置项
+func DiffConfig(old, new *SystemConfig) []ConfigChange {
+	newValues := make(map[string]reflect.Value)
+	walkConfigFields(new, func(f configField) {
+		newValues[f.Path] = f.Value
+	})
+
+	var changes []ConfigChange
+	walkConfigFields(old, func(f configField) {
+		nv := newValues[f.Path]
+		if !reflect.DeepEqual(f.Value.Interface(), nv.Interface()) {
+			changes = append(changes, ConfigChange{
+				Path: f.Path,
+				Old:  f.Value.Interface(),
+				New:  nv.Interface(),
+			})
+		}
+	})
+	return changes
+}
+
+// ReloadHook 模块热更新钩子
+type ReloadHook struct {
+	// Module 模块名，用于日志
+	Module string
+	// Fields 可热更新的配置项（TOML路径），以 ".*" 结尾表示整个配置段
+	Fields []string
+	// Apply 在相关配置项变化时以合并后的新配置调用
+	Apply func(cfg *SystemConfig) error
+}
+
+// matches 判断配置项是否由该钩子负责
+func (h *ReloadHook) matches(path string) bool {
+	for _, f := range h.Fields {
+		if f == path {
+			return true
+		}
+		if section, ok := strings.CutSuffix(f, ".*"); ok && strings.HasPrefix(path, section+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// ReloadResult 一次热更新的结果
+type ReloadResult struct {
+	Applied         []ConfigChange // 已在线生效的变更
+	RestartRequired []ConfigChange // 需要重启才能生效的变更
+	Failed          []ConfigChange // 模块应用失败的变更
+}
+
+// RegisterReloadHook 注册模块热更新钩子
+func (s *System) RegisterReloadHook(hook ReloadHook) {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+	s.reloadHooks = append(s.reloadHooks, hook)
+}
+
+// CurrentConfig 返回当前生效的配置
+//
+// 热更新会整体替换配置对象，调用方不应修改返回值。
+func (s *System) CurrentConfig() *SystemConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.Config
+}
+
+// Reload 重新加载配置文件并热更新
+//
+// 新配置需要通过校验；可热更新的配置项交给对应模块的钩子应用，
+// 其余变更记录为需要重启，当前运行配置保持旧值。
+func (s *System) Reload() (*ReloadResult, error) {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	newCfg, err := LoadConfig(s.configPath, s.loadOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("重新加载配置失败: %w", err)
+	}
+
+	current := s.CurrentConfig()
+	changes := DiffConfig(current, newCfg)
+	result := &ReloadResult{}
+	if len(changes) == 0 {
+		s.Logger.Info("配置未发生变化，path=%s", s.configPath)
+		return result, nil
+	}
+
+	// 按钩子分组，没有钩子负责的变更需要重启
+	merged := *current
+	grouped := make([][]ConfigChange, len(s.reloadHooks))
+	for _, c := range changes {
+		idx := -1
+		for i := range s.reloadHooks {
+			if s.reloadHooks[i].matches(c.Path) {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			result.RestartRequired = append(result.RestartRequired, c)
+			continue
+		}
+		grouped[idx] = append(grouped[idx], c)
+		copyConfigField(&merged, newCfg, c.Path)
+	}
+
+	// 依次调用钩子，失败的钩子对应的变更回退到旧值
+	var errs []error
+	for i, group := range grouped {
+		if len(group) == 0 {
+			continue
+		}
+		hook := &s.reloadHooks[i]
+		if err := hook.Apply(&merged); err != nil {
+			errs = append(errs, fmt.Errorf("模块 %s 热更新失败: %w", hook.Module, err))
+			for _, c := range group {
+				copyConfigField(&merged, current, c.Path)
+			}
+			result.Failed = append(result.Failed, group...)
+			continue
+		}
+		result.Applied = append(result.Applied, group...)
+	}
+
+	s.mu.Lock()
+	s.Config = &merged
+	s.mu.Unlock()
+
+	for _, c := range result.Applied {
+		s.Logger.Info("配置已在线生效，%s", c)
+	}
+	for _, c := range result.RestartRequired {
+		s.Logger.Warn("配置需要重启才能生效，%s", c)
+	}
+	for _, c := range result.Failed {
+		s.Logger.Error("配置应用失败，%s", c)
+	}
+
+	return result, errors.Join(errs...)
+}
+
+// copyConfigField 将 src 中指定路径的配置项复制到 dst
+func copyConfigField(dst, src *SystemConfig, path string) {
+	df, ok := lookupConfigField(dst, path)
+	if !ok {
+		return
+	}
+	sf, ok := lookupConfigField(src, path)
+	if !ok {
+		return
+	}
+	df.Value.Set(sf.Value)
+}
+
+// registerBuiltinReloadHooks 注册内置模块的热更新钩子
+func (s *System) registerBuiltinReloadHooks() {
+	s.RegisterReloadHook(ReloadHook{
+		Module: "logs",
+		Fields: []string{"logs.level", "logs.levels"},
+		Apply: func(cfg *SystemConfig) error {
+			s.Logger.SetLevel(logging.ParseLevel(cfg.Logs.Level))
+			s.Logger.SetModuleLevels(logging.ParseLevels(cfg.Logs.Levels))
+			return nil
+		},
+	})
+
+	s.RegisterReloadHook(ReloadHook{
+		Module: "fingerprint",
+		Fields: []string{
+			"fingerprint.selection_strategy",
+			"fingerprint.enable_rotation",
+			"fingerprint.rotation_interval",
+			"fingerprint.browsers",
+			"fingerprint.operating_systems",
+			"fingerprint.os_randomization",
+			"fingerprint.ua_randomization",
+			"fingerprint.library_path",
+			"fingerprint.health_half_life",
+		},
+		Apply: func(cfg *SystemConfig) error {
+			if s.FingerprintManager == nil {
+				return fmt.Errorf("指纹模块未初始化")
+			}
+			fp := cfg.Fingerprint
+			return s.FingerprintManager.UpdateConfig(&fp)
+		},
+	})
+
+	s.RegisterReloadHook(ReloadHook{
+		Module: "ip_status",
+		Fields: []string{"ip_status.*"},
+		Apply: func(cfg *SystemConfig) error {
+			if s.IPStatusManager == nil {
+				return fmt.Errorf("黑白名单模块未初始化")
+			}
+			s.IPStatusManager.SetMinWhitelistCount(cfg.IPStatus.MinWhitelistCount)
+			s.IPStatusManager.SetAllowStartWhenEmpty(cfg.IPStatus.AllowStartWhenEmpty)
+			s.IPStatusManager.SetWhitelistMonitoring(cfg.IPStatus.WhitelistMonitoring)
+			s.IPStatusManager.SetWhitelistMonitoringInterval(cfg.IPStatus.WhitelistMonitoringInterval.Duration())
+			s.restartWhitelistMonitor(&cfg.IPStatus)
+			return nil
+		},
+	})
+
+	// Fetch 每次请求时从 CurrentConfig 读取超时和重试设置，替换配置即可生效；
+	// 并发、队列和速率限制由请求限流器在线调整
+	s.RegisterReloadHook(ReloadHook{
+		Module: "crawler",
+		Fields: []string{
+			"crawler.default_timeout",
+			"crawler.max_retries",
+			"crawler.retry_interval",
+			"crawler.concurrency",
+			"crawler.rate_limit",
+			"crawler.queue_enabled",
+			"crawler.queue_size",
+		},
+		Apply: func(cfg *SystemConfig) error {
+			s.limiter.update(&cfg.Crawler)
+			return nil
+		},
+	})
+}
+
+// WatchConfig 启动配置文件监听，文件变化或收到 SIGHUP 信号时调用 Reload
+//
+// 文件变化通过定期检查修改时间和大小发现，interval 为检查间隔（<=0 时使用 2 秒）。
+// 同时监听 fingerprint.library_path 中的自定义指纹库，文件变化时重新加载指纹库。
+// 重复调用会先停止之前的监听；Close 时自动停止。
+func (s *System) WatchConfig(interval time.Duration) {
+	if interval <= 0 {
+		interval = 2 * time.Second
+	}
+	s.StopWatchConfig()
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	s.mu.Lock()
+	s.watchStop, s.watchDone = stop, done
+	s.mu.Unlock()
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	go func() {
+		defer close(done)
+		defer signal.Stop(hup)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		last, _ := os.Stat(s.configPath)
+		for {
+			select {
+			case <-stop:
+				return
+			case <-hup:
+				s.Logger.Info("收到 SIGHUP 信号，重新加载配置")
+				s.reloadAndLog()
+				s.reloadFingerprintLibrary()
+			case <-ticker.C:
+				s.reloadFingerprintLibrary()
+				info, err := os.Stat(s.configPath)
+				if err != nil || !fileChanged(last, info) {
+					continue
+				}
+				last = info
+				s.Logger.Info("检测到配置文件变化，重新加载配置，path=%s", s.configPath)
+				s.reloadAndLog()
+			}
+		}
+	}()
+
+	s.Logger.Info("配置文件监听已启动，path=%s, interval=%v", s.configPath, interval)
+}
+
+// StopWatchConfig 停止配置文件监听
+func (s *System) StopWatchConfig() {
+	s.mu.Lock()
+	stop, done := s.watchStop, s.watchDone
+	s.watchStop, s.watchDone = nil, nil
+	s.mu.Unlock()
+
+	if stop == nil {
+		return
+	}
+	close(stop)
+	<-done
+}
+
+// reloadAndLog 执行热更新，失败时保留当前配置并记录日志
+func (s *System) reloadAndLog() {
+	result, err := s.Reload()
+	if err != nil {
+		s.Logger.Error("配置热更新失败，error=%v", err)
+	}
+	if result != nil {
+		s.Logger.Info("配置热更新完成，applied=%d, restart_required=%d, failed=%d",
+			len(result.Applied), len(result.RestartRequired), len(result.Failed))
+	}
+}
+
+// reloadFingerprintLibrary 自定义指纹库文件变化时重新加载，失败时保留当前指纹库并记录日志
+func (s *System) reloadFingerprintLibrary() {
+	if s.FingerprintManager == nil {
+		return
+	}
+	if _, err := s.FingerprintManager.ReloadLibrary(); err != nil {
+		s.Logger.Error("重新加载指纹库失败，error=%v", err)
+	}
+}
+
+// fileChanged 判断文件的修改时间或大小是否变化
+func fileChanged(last, cur os.FileInfo) bool {
+	if last == nil {
+		return true
+	}
+	return !cur.ModTime().Equal(last.ModTime()) || cur.Size() != last.Size()
+}
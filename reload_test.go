@@ -0,0 +1,249 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package crawler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/vistone/crawler-system/internal/logging"
+	"github.com/vistone/crawler-system/internal/moduleinit"
+)
+
+// fakeIPStatusManager 记录热更新设置的黑白名单管理器
+type fakeIPStatusManager struct {
+	IPStatusManagerInterface
+	minWhitelistCount int
+}
+
+func (m *fakeIPStatusManager) SetMinWhitelistCount(count int)               { m.minWhitelistCount = count }
+func (m *fakeIPStatusManager) SetAllowStartWhenEmpty(bool)                  {}
+func (m *fakeIPStatusManager) SetWhitelistMonitoring(bool)                  {}
+func (m *fakeIPStatusManager) SetWhitelistMonitoringInterval(time.Duration) {}
+
+// newReloadTestSystem 创建只初始化热更新所需模块的系统，配置文件写入临时目录
+func newReloadTestSystem(t *testing.T, content string) (*System, string, *fakeIPStatusManager) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.toml")
+	writeTestConfig(t, path, content)
+
+	s, err := NewSystem(context.Background(), path)
+	if err != nil {
+		t.Fatalf("NewSystem: %v", err)
+	}
+	s.Logger = logging.Discard()
+	fm, _, err := moduleinit.InitFingerprint(&s.Config.Fingerprint, s.Logger)
+	if err != nil {
+		t.Fatalf("InitFingerprint: %v", err)
+	}
+	s.FingerprintManager = fm
+	ipStatus := &fakeIPStatusManager{}
+	s.IPStatusManager = ipStatus
+	s.registerBuiltinReloadHooks()
+	return s, path, ipStatus
+}
+
+func writeTestConfig(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReloadClassifiesChanges(t *testing.T) {
+	s, path, ipStatus := newReloadTestSystem(t, "")
+	writeTestConfig(t, path, `
+[logs]
+level = "debug"
+
+[fingerprint]
+selection_strategy = "least_used"
+
+[ip_status]
+min_whitelist_count = 3
+
+[conn]
+connect_timeout = "3s"
+
+[ip_pool_test]
+test_url = "https://{domain}/health"
+
+[blacklist_recovery]
+check_interval = "10m"
+
+[status_report]
+report_interval = "9s"
+
+[crawler]
+default_timeout = "12s"
+concurrency = 20
+rate_limit = 5
+deduplication_enabled = true
+`)
+
+	result, err := s.Reload()
+	if err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+
+	got := make(map[string]string)
+	for _, c := range result.Applied {
+		got[c.Path] = "applied"
+	}
+	for _, c := range result.RestartRequired {
+		got[c.Path] = "restart"
+	}
+	for _, c := range result.Failed {
+		got[c.Path] = "failed"
+	}
+	want := map[string]string{
+		"logs.level":                        "applied",
+		"fingerprint.selection_strategy":    "applied",
+		"ip_status.min_whitelist_count":     "applied",
+		"conn.connect_timeout":              "restart",
+		"ip_pool_test.test_url":             "restart",
+		"blacklist_recovery.check_interval": "restart",
+		"status_report.report_interval":     "restart",
+		"crawler.default_timeout":           "applied",
+		"crawler.concurrency":               "applied",
+		"crawler.rate_limit":                "applied",
+		"crawler.deduplication_enabled":     "restart",
+	}
+	for path, class := range want {
+		if got[path] != class {
+			t.Errorf("%s: got %q, want %q", path, got[path], class)
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("classified %d changes, want %d: %v", len(got), len(want), got)
+	}
+
+	cfg := s.CurrentConfig()
+	if cfg.Crawler.DefaultTimeout.Duration() != 12*time.Second {
+		t.Errorf("crawler.default_timeout = %v, want 12s", cfg.Crawler.DefaultTimeout)
+	}
+	if cfg.Crawler.Concurrency != 20 {
+		t.Errorf("crawler.concurrency = %d, want 20", cfg.Crawler.Concurrency)
+	}
+	if cfg.Crawler.DeduplicationEnabled {
+		t.Errorf("crawler.deduplication_enabled changed in the running config, want the old value until restart")
+	}
+	if ipStatus.minWhitelistCount != 3 {
+		t.Errorf("min_whitelist_count applied = %d, want 3", ipStatus.minWhitelistCount)
+	}
+	if s.FingerprintManager.GetConfig().SelectionStrategy != "least_used" {
+		t.Errorf("fingerprint selection_strategy not applied")
+	}
+}
+
+func TestReloadChangesRequestLimits(t *testing.T) {
+	s, path, _ := newReloadTestSystem(t, `
+[crawler]
+concurrency = 1
+rate_limit = 1
+queue_enabled = false
+`)
+	ctx := context.Background()
+	release, err := s.limiter.acquire(ctx)
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	defer release()
+	if _, err := s.limiter.acquire(ctx); err == nil {
+		t.Fatal("concurrency = 1 时第二个请求应被拒绝")
+	}
+
+	writeTestConfig(t, path, `
+[crawler]
+concurrency = 2
+rate_limit = 1000
+queue_enabled = false
+`)
+	result, err := s.Reload()
+	if err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if len(result.Applied) != 2 || len(result.RestartRequired) != 0 {
+		t.Fatalf("Applied=%v RestartRequired=%v, want concurrency 和 rate_limit 在线生效", result.Applied, result.RestartRequired)
+	}
+
+	// 按旧的 rate_limit = 1，第二个请求要等 1 秒
+	start := time.Now()
+	second, err := s.limiter.acquire(ctx)
+	if err != nil {
+		t.Fatalf("concurrency 调大后第二个请求: %v", err)
+	}
+	defer second()
+	if elapsed := time.Since(start); elapsed > 200*time.Millisecond {
+		t.Fatalf("rate_limit 调大后第二个请求等待了 %v", elapsed)
+	}
+}
+
+func TestReloadFailedHookKeepsOldValues(t *testing.T) {
+	s, path, _ := newReloadTestSystem(t, "")
+	writeTestConfig(t, path, `
+[fingerprint]
+operating_systems = ["ios"]
+browsers = ["chrome"]
+`)
+
+	result, err := s.Reload()
+	if err == nil {
+		t.Fatal("Reload succeeded, want the fingerprint hook to fail (no chrome profile for ios)")
+	}
+	if len(result.Failed) != 2 || len(result.Applied) != 0 {
+		t.Fatalf("Failed=%v Applied=%v, want both fingerprint changes failed", result.Failed, result.Applied)
+	}
+	if got := s.CurrentConfig().Fingerprint.OperatingSystems; len(got) != 0 {
+		t.Errorf("operating_systems = %v after failed reload, want the old value", got)
+	}
+}
+
+func TestReloadRestartsWhitelistMonitor(t *testing.T) {
+	s, path, _ := newReloadTestSystem(t, "")
+	for _, state := range []State{StateInitializing, StateRunning} {
+		if err := s.transition(state, "测试", nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s.startWhitelistMonitor(time.Hour)
+	t.Cleanup(s.stopWhitelistMonitor)
+	monitor := func() chan struct{} {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return s.monitorStop
+	}
+	before := monitor()
+
+	writeTestConfig(t, path, `
+[ip_status]
+whitelist_monitoring_interval = "2h"
+`)
+	result, err := s.Reload()
+	if err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if len(result.Applied) != 1 || result.Applied[0].Path != "ip_status.whitelist_monitoring_interval" {
+		t.Fatalf("Applied = %v, want ip_status.whitelist_monitoring_interval", result.Applied)
+	}
+	if after := monitor(); after == nil || after == before {
+		t.Fatal("whitelist_monitoring_interval 变化后白名单监控没有重启")
+	}
+
+	writeTestConfig(t, path, `
+[ip_status]
+whitelist_monitoring = false
+whitelist_monitoring_interval = "2h"
+`)
+	if _, err := s.Reload(); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if monitor() != nil {
+		t.Fatal("whitelist_monitoring = false 后白名单监控仍在运行")
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package crawler
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/vistone/crawler-system/internal/moduleinit"
+)
+
+type (
+	// StartupReport 模块初始化报告，记录生效的配置和初始化时检测到的资源
+	StartupReport = moduleinit.StartupReport
+	// ReportItem 启动报告中的一项
+	ReportItem = moduleinit.ReportItem
+)
+
+// NewStartupReport 创建模块初始化报告，供自定义模块实现 StartupReporter 使用
+func NewStartupReport(module, title string) *StartupReport {
+	return moduleinit.NewStartupReport(module, title)
+}
+
+// StartupReporter 模块可选实现的接口，Init 成功后系统通过它收集启动报告
+//
+// 内置模块都实现了此接口；返回 nil 表示没有报告。
+type StartupReporter interface {
+	StartupReport() *StartupReport
+}
+
+// ReportRenderer 渲染启动报告，在每个模块的报告输出到日志后调用
+type ReportRenderer func(r *StartupReport)
+
+// SetReportRenderer 设置启动报告的渲染器，只能在 Start 之前调用
+//
+// 启动报告总是以 key=value 形式输出到日志（info 级别，警告为 warn 级别），
+// 渲染器用于额外的展示，如 ConsoleReportRenderer 输出的控制台横幅。
+func (s *System) SetReportRenderer(r ReportRenderer) error {
+	if state := s.State(); state != StateCreated {
+		return fmt.Errorf("只能在系统启动前设置报告渲染器，当前状态: %s", state)
+	}
+	s.modulesMu.Lock()
+	defer s.modulesMu.Unlock()
+	s.reportRenderer = r
+	return nil
+}
+
+// StartupReports 返回已初始化模块的启动报告，按初始化顺序排列
+func (s *System) StartupReports() []*StartupReport {
+	s.modulesMu.Lock()
+	defer s.modulesMu.Unlock()
+	return append([]*StartupReport(nil), s.reports...)
+}
+
+// collectReports 收集一层中初始化成功的模块的启动报告，输出到日志并交给渲染器
+func (s *System) collectReports(level []Module, errs []error) {
+	s.modulesMu.Lock()
+	render := s.reportRenderer
+	s.modulesMu.Unlock()
+
+	for i, m := range level {
+		reporter, ok := m.(StartupReporter)
+		if errs[i] != nil || !ok {
+			continue
+		}
+		report := reporter.StartupReport()
+		if report == nil {
+			continue
+		}
+		if report.Module == "" {
+			report.Module = m.Name()
+		}
+
+		s.modulesMu.Lock()
+		s.reports = append(s.reports, report)
+		s.modulesMu.Unlock()
+
+		if s.Logger != nil {
+			log := s.ModuleLogger(report.Module)
+			log.Info("模块初始化完成，%s", report.Fields())
+			for _, w := range report.Warnings {
+				log.Warn("%s", w)
+			}
+			for _, n := range report.Notes {
+				log.Info("%s", n)
+			}
+		}
+		if render != nil {
+			render(report)
+		}
+	}
+}
+
+// reportIcons 控制台横幅中各内置模块的图标
+var reportIcons = map[string]string{
+	"logs":           "📝",
+	"fingerprint":    "🔐",
+	"domaindns":      "🌐",
+	"localippool":    "🌍",
+	"certs":          "🔒",
+	"ipstatus":       "📋",
+	"conn":           "🔌",
+	"netconnpool":    "🌐",
+	"quic":           "⚡",
+	ServerModuleName: "🛰️",
+}
+
+// ConsoleReportRenderer 返回把启动报告渲染为控制台横幅的渲染器
+func ConsoleReportRenderer(w io.Writer) ReportRenderer {
+	rule := strings.Repeat("━", 80)
+	return func(r *StartupReport) {
+		icon, ok := reportIcons[r.Module]
+		if !ok {
+			icon = "📦"
+		}
+		var b strings.Builder
+		fmt.Fprintln(&b, rule)
+		fmt.Fprintf(&b, "%s [%s] %s初始化\n", icon, r.Module, r.Title)
+		fmt.Fprintln(&b, rule)
+		for _, item := range r.Settings {
+			fmt.Fprintf(&b, "  ✓ %s: %s\n", item.Label, formatReportValue(item.Value))
+		}
+		if len(r.Resources) > 0 {
+			fmt.Fprintln(&b, "  📊 检测结果:")
+			for _, item := range r.Resources {
+				fmt.Fprintf(&b, "    - %s: %s\n", item.Label, formatReportValue(item.Value))
+			}
+		}
+		for _, warning := range r.Warnings {
+			fmt.Fprintf(&b, "  ⚠️  警告: %s\n", warning)
+		}
+		for _, note := range r.Notes {
+			fmt.Fprintf(&b, "  ℹ️  说明: %s\n", note)
+		}
+		fmt.Fprintln(&b, rule)
+		io.WriteString(w, b.String())
+	}
+}
+
+// formatReportValue 格式化报告中的值，较长的列表只显示前5项
+func formatReportValue(v interface{}) string {
+	list, ok := v.([]string)
+	if !ok {
+		return fmt.Sprint(v)
+	}
+	if len(list) == 0 {
+		return "无"
+	}
+	if len(list) > 5 {
+		return fmt.Sprintf("%s ... (共%d个)", strings.Join(list[:5], ", "), len(list))
+	}
+	return strings.Join(list, ", ")
+}
@@ -0,0 +1,193 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// shutdownSignals 触发优雅停止的信号
+var shutdownSignals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+
+// Run 启动系统并阻塞运行，直到 ctx 取消或收到停止信号，返回停止结果
+//
+// 系统处于 Created 状态时先调用 Start。运行期间处理以下信号：
+//   - SIGINT / SIGTERM：停止接受新请求，最多等待 system.shutdown_timeout 让进行中的请求结束，然后停止所有模块
+//   - SIGHUP：重新加载配置（已调用 WatchConfig 时由配置监听处理）
+//   - SIGUSR1：将状态快照输出到日志（仅类 Unix 系统）
+//
+// 停止过程中再次收到 SIGINT / SIGTERM 时立即以退出码 1 结束进程。
+func (s *System) Run(ctx context.Context) error {
+	if s.State() == StateCreated {
+		if err := s.Start(ctx); err != nil {
+			// 已初始化的模块已回滚，Stop 只将系统转为 Stopped
+			_ = s.Stop(context.WithoutCancel(ctx))
+			return err
+		}
+	}
+
+	sigs := make(chan os.Signal, 1)
+	notifySignals(sigs, shutdownSignals...)
+	notifySignals(sigs, syscall.SIGHUP)
+	notifySignals(sigs, statusSignals...)
+	defer signal.Stop(sigs)
+
+	s.Logger.Info("系统运行中，发送 SIGINT/SIGTERM 停止，SIGHUP 重新加载配置")
+
+	reason := s.waitForShutdown(ctx, sigs)
+	return s.shutdown(ctx, sigs, reason)
+}
+
+// notifySignals 将 sigs 转发到 c；sigs 为空时不做任何事（signal.Notify 不传信号会转发所有信号）
+func notifySignals(c chan<- os.Signal, sigs ...os.Signal) {
+	if len(sigs) == 0 {
+		return
+	}
+	signal.Notify(c, sigs...)
+}
+
+// waitForShutdown 处理运行期间的信号，直到 ctx 取消或收到停止信号，返回停止原因
+func (s *System) waitForShutdown(ctx context.Context, sigs <-chan os.Signal) string {
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Sprintf("上下文结束（%v）", ctx.Err())
+		case sig := <-sigs:
+			switch {
+			case isSignal(sig, shutdownSignals):
+				return fmt.Sprintf("收到信号 %v", sig)
+			case sig == syscall.SIGHUP:
+				if s.watchingConfig() {
+					continue // 配置监听同样收到 SIGHUP，避免重复加载
+				}
+				s.Logger.Info("收到 SIGHUP 信号，重新加载配置")
+				s.reloadAndLog()
+			case isSignal(sig, statusSignals):
+				s.logStatus()
+			}
+		}
+	}
+}
+
+// shutdown 在后台停止系统并等待结束，期间再次收到停止信号时立即退出进程
+func (s *System) shutdown(ctx context.Context, sigs <-chan os.Signal, reason string) error {
+	timeout := s.CurrentConfig().System.ShutdownTimeout.Duration()
+	s.Logger.Info("%s，开始停止系统，等待进行中的请求最多 %v（再次发送信号立即退出）", reason, timeout)
+
+	stopCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- s.Stop(stopCtx)
+	}()
+
+	for {
+		select {
+		case err := <-done:
+			return err
+		case sig := <-sigs:
+			switch {
+			case isSignal(sig, shutdownSignals):
+				s.Logger.Error("停止过程中再次收到信号 %v，立即退出", sig)
+				os.Exit(1)
+			case isSignal(sig, statusSignals):
+				s.logStatus()
+			}
+		}
+	}
+}
+
+// watchingConfig 判断配置文件监听是否在运行
+func (s *System) watchingConfig() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.watchStop != nil
+}
+
+func isSignal(sig os.Signal, set []os.Signal) bool {
+	for _, s := range set {
+		if sig == s {
+			return true
+		}
+	}
+	return false
+}
+
+// StatusSnapshot 系统状态快照
+type StatusSnapshot struct {
+	Time             time.Time
+	State            State
+	Uptime           time.Duration  // 自 Start 完成起的运行时长
+	InFlightRequests int            // 进行中的爬取请求数
+	WhitelistCount   int            // 白名单IP数量
+	Modules          []ModuleStatus // 已初始化的模块，按初始化顺序
+}
+
+// ModuleStatus 模块健康状态
+type ModuleStatus struct {
+	Name string
+	Err  error // 健康检查错误，健康时为 nil
+}
+
+// Snapshot 返回系统当前的状态快照
+func (s *System) Snapshot(ctx context.Context) StatusSnapshot {
+	snap := StatusSnapshot{
+		Time:             time.Now(),
+		State:            s.State(),
+		InFlightRequests: s.InFlightRequests(),
+	}
+	s.stateMu.Lock()
+	if !s.startedAt.IsZero() {
+		snap.Uptime = snap.Time.Sub(s.startedAt)
+	}
+	s.stateMu.Unlock()
+	if s.IPStatusManager != nil {
+		snap.WhitelistCount = s.IPStatusManager.GetWhitelistCount()
+	}
+
+	for _, m := range s.initializedModules() {
+		snap.Modules = append(snap.Modules, ModuleStatus{Name: m.Name(), Err: m.Health(ctx)})
+	}
+	return snap
+}
+
+// logStatus 将状态快照输出到日志
+func (s *System) logStatus() {
+	s.reportStatus(s.Logger.Info)
+}
+
+// reportStatus 将状态快照按 [status_report] 配置输出到 logf，模块异常的行总是以 Warn 级别输出
+func (s *System) reportStatus(logf func(format string, args ...interface{})) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	snap := s.Snapshot(ctx)
+
+	logf("系统状态快照，state=%s, uptime=%v, in_flight=%d, whitelist=%d, modules=%d",
+		snap.State, snap.Uptime.Round(time.Second), snap.InFlightRequests, snap.WhitelistCount, len(snap.Modules))
+	if cfg := &s.CurrentConfig().StatusReport; cfg.ReportIPList && s.IPStatusManager != nil {
+		logf("  %s", whitelistReport(s.IPStatusManager.GetWhitelistIPs(), cfg.MaxReportIPs))
+	}
+	for _, m := range snap.Modules {
+		if m.Err != nil {
+			s.Logger.Warn("  模块 %s: 异常，error=%v", m.Name, m.Err)
+		} else {
+			logf("  模块 %s: 正常", m.Name)
+		}
+	}
+}
+
+// whitelistReport 返回状态报告中的白名单IP列表，超过 max 个时只报告数量
+func whitelistReport(ips []string, max int) string {
+	if len(ips) > max {
+		return fmt.Sprintf("白名单IP %d 个，超过 status_report.max_report_ips (%d)，不列出", len(ips), max)
+	}
+	return fmt.Sprintf("白名单IP %d 个: %s", len(ips), strings.Join(ips, ", "))
+}
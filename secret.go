@@ -0,0 +1,70 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package crawler
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/vistone/crawler-system/internal/config"
+)
+
+// Secret 敏感配置值，支持 env:NAME 和 file:/path 引用，通过 fmt 输出或序列化时总是脱敏
+type Secret = config.Secret
+
+var secretType = reflect.TypeOf(Secret(""))
+
+// resolveSecret 解析 env: 和 file: 引用，其他值原样返回
+func resolveSecret(raw string) (string, error) {
+	switch {
+	case strings.HasPrefix(raw, "env:"):
+		name := strings.TrimPrefix(raw, "env:")
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("环境变量 %s 未设置", name)
+		}
+		return value, nil
+	case strings.HasPrefix(raw, "file:"):
+		path := strings.TrimPrefix(raw, "file:")
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("读取密钥文件失败: %w", err)
+		}
+		return strings.TrimSpace(string(data)), nil
+	default:
+		return raw, nil
+	}
+}
+
+// resolveSecrets 解析配置中所有 Secret 字段的引用
+func resolveSecrets(cfg *SystemConfig) error {
+	var errs []error
+	walkConfigFields(cfg, func(f configField) {
+		if f.Value.Type() != secretType {
+			return
+		}
+		value, err := resolveSecret(f.Value.String())
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", f.Path, err))
+			return
+		}
+		f.Value.SetString(value)
+	})
+	return errors.Join(errs...)
+}
+
+// Redacted 返回脱敏后的配置副本，所有 Secret 字段替换为脱敏文本，可直接用于支持包或日志输出
+func (c *SystemConfig) Redacted() *SystemConfig {
+	redacted := *c
+	walkConfigFields(&redacted, func(f configField) {
+		if f.Value.Type() == secretType && f.Value.String() != "" {
+			f.Value.SetString(config.RedactedText)
+		}
+	})
+	return &redacted
+}
@@ -0,0 +1,216 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package crawler
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/vistone/quic"
+
+	"github.com/vistone/crawler-system/internal/logging"
+)
+
+// ServerModuleName QUIC服务端模块名
+const ServerModuleName = "quic_server"
+
+// 接收客户端流的轮询超时和出错后的退避等待（与 net/http.Server 相同，从 5ms 倍增到 1s）
+const (
+	acceptPollTimeout = time.Second
+	acceptMinDelay    = 5 * time.Millisecond
+	acceptMaxDelay    = time.Second
+)
+
+// StreamHandler 处理客户端打开的一个QUIC流，返回后流被关闭
+type StreamHandler func(ctx context.Context, id string, conn net.Conn)
+
+// serverModule QUIC服务端模块，按 [server] 配置监听客户端连接
+type serverModule struct {
+	handler StreamHandler
+
+	sys    *System
+	log    Logger
+	report *StartupReport
+	pool   *quic.Pool
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewServerModule 创建QUIC服务端模块，通过 RegisterModule 注册后随系统启动和停止
+//
+// 模块使用 [certificate] server_domain 的证书监听 [server] listen_address，
+// client_auth_enabled 时要求客户端提供由 client_cert_path 签发的证书。
+// 客户端打开的每个流交给 handler 处理；handler 为 nil 时记录日志后关闭流。
+func NewServerModule(handler StreamHandler) Module {
+	return &serverModule{handler: handler}
+}
+
+func (m *serverModule) Name() string        { return ServerModuleName }
+func (m *serverModule) DependsOn() []string { return []string{"certs", "ipstatus"} }
+
+func (m *serverModule) Init(_ context.Context, sys *System) error {
+	cfg := sys.CurrentConfig()
+	if !cfg.Server.QUICEnabled {
+		return fmt.Errorf("QUIC服务端未启用（server.quic_enabled = false）")
+	}
+
+	tlsConfig, err := serverTLSConfig(sys, cfg)
+	if err != nil {
+		return err
+	}
+
+	// quic 库在后台监听，不返回监听错误，这里提前检查地址是否可用
+	pc, err := net.ListenPacket("udp", cfg.Server.ListenAddress)
+	if err != nil {
+		return fmt.Errorf("监听 %s 失败: %w", cfg.Server.ListenAddress, err)
+	}
+	pc.Close()
+
+	m.sys = sys
+	m.log = sys.ModuleLogger(ServerModuleName)
+	m.pool = quic.NewServerPool(cfg.Server.MaxClients, "", tlsConfig, cfg.Server.ListenAddress, cfg.Server.ClientTimeout.Duration())
+
+	m.report = NewStartupReport(ServerModuleName, "QUIC服务端")
+	m.report.Set("listen_address", "监听地址", cfg.Server.ListenAddress)
+	m.report.Set("max_clients", "最大客户端连接数", cfg.Server.MaxClients)
+	m.report.Set("client_timeout", "客户端连接超时", cfg.Server.ClientTimeout)
+	m.report.Set("client_auth_enabled", "客户端认证", cfg.Server.ClientAuthEnabled)
+	m.report.Detect("server_domain", "证书域名", cfg.Certificate.ServerDomain)
+	if m.handler == nil {
+		m.report.Note("未设置流处理函数，客户端打开的流将被直接关闭")
+	}
+	return nil
+}
+
+func (m *serverModule) StartupReport() *StartupReport { return m.report }
+
+func (m *serverModule) Start(context.Context) error {
+	m.pool.ServerManager()
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancel = cancel
+	m.wg.Add(1)
+	go m.accept(ctx)
+	m.log.Info("QUIC服务端已启动，listen_address=%s", m.sys.CurrentConfig().Server.ListenAddress)
+	return nil
+}
+
+func (m *serverModule) Stop(ctx context.Context) error {
+	if m.cancel != nil {
+		m.cancel()
+	}
+	if m.pool != nil {
+		m.pool.Close()
+	}
+	done := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("等待客户端流处理结束失败: %w", ctx.Err())
+	}
+}
+
+func (m *serverModule) Health(context.Context) error {
+	if m.pool == nil {
+		return errors.New("QUIC服务端未初始化")
+	}
+	return nil
+}
+
+// accept 接收客户端打开的流并交给 handler 处理，直到 ctx 取消
+//
+// IncomingGet 在轮询超时和连接池不可用时返回同样的错误：等满超时说明只是没有新的流，
+// 提前返回的错误会持续出现，按指数退避等待后重试，避免空转。
+func (m *serverModule) accept(ctx context.Context) {
+	defer m.wg.Done()
+	var delay time.Duration
+	for ctx.Err() == nil {
+		start := time.Now()
+		id, conn, err := m.pool.IncomingGet(acceptPollTimeout)
+		if err != nil {
+			if time.Since(start) >= acceptPollTimeout {
+				delay = 0
+				continue
+			}
+			delay = min(max(2*delay, acceptMinDelay), acceptMaxDelay)
+			m.log.Warn("接收客户端流失败，%v 后重试，error=%v", delay, err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(delay):
+			}
+			continue
+		}
+		delay = 0
+		m.wg.Add(1)
+		go func() {
+			defer m.wg.Done()
+			m.serve(ctx, id, conn)
+		}()
+	}
+}
+
+// serve 处理一个客户端流，结束后关闭流并记录访问日志
+func (m *serverModule) serve(ctx context.Context, id string, conn net.Conn) {
+	start := time.Now()
+	cc := &countingConn{Conn: conn}
+	defer func() {
+		conn.Close()
+		m.sys.AccessLog.LogSession(logging.SessionRecord{
+			Time:       start,
+			ClientID:   id,
+			RemoteAddr: conn.RemoteAddr().String(),
+			BytesIn:    cc.in.Load(),
+			BytesOut:   cc.out.Load(),
+			Duration:   time.Since(start),
+		})
+	}()
+	if m.handler == nil {
+		m.log.Info("收到客户端流，未设置处理函数，直接关闭，id=%s, remote=%s", id, conn.RemoteAddr())
+		return
+	}
+	m.handler(ctx, id, cc)
+}
+
+// serverTLSConfig 构造服务端TLS配置：服务端证书来自证书管理器，客户端认证时加载CA证书
+func serverTLSConfig(sys *System, cfg *SystemConfig) (*tls.Config, error) {
+	domain := cfg.Certificate.ServerDomain
+	if domain == "" {
+		return nil, fmt.Errorf("QUIC服务端需要设置 certificate.server_domain")
+	}
+	cert, err := sys.CertManager.GetOrRequestCertificate(domain)
+	if err != nil {
+		return nil, fmt.Errorf("获取 %s 的证书失败: %w", domain, err)
+	}
+	pair, err := tls.LoadX509KeyPair(cert.CertPath, cert.KeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("加载服务端证书失败: %w", err)
+	}
+	tlsConfig := &tls.Config{Certificates: []tls.Certificate{pair}}
+
+	if cfg.Server.ClientAuthEnabled {
+		data, err := os.ReadFile(cfg.Server.ClientCertPath)
+		if err != nil {
+			return nil, fmt.Errorf("读取客户端认证证书失败: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("客户端认证证书 %s 中没有有效的PEM证书", cfg.Server.ClientCertPath)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return tlsConfig, nil
+}
@@ -0,0 +1,52 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package crawler
+
+import (
+	"context"
+	"crypto/tls"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/vistone/quic"
+)
+
+// countingLogger 统计 Warn 日志条数
+type countingLogger struct {
+	warns atomic.Int32
+}
+
+func (l *countingLogger) Debug(string, ...interface{}) {}
+func (l *countingLogger) Info(string, ...interface{})  {}
+func (l *countingLogger) Warn(string, ...interface{})  { l.warns.Add(1) }
+func (l *countingLogger) Error(string, ...interface{}) {}
+
+func TestServerAcceptBacksOffOnPersistentErrors(t *testing.T) {
+	// 已关闭的连接池上 IncomingGet 立即返回错误
+	pool := quic.NewServerPool(1, "", &tls.Config{}, "127.0.0.1:0", time.Second)
+	pool.Close()
+	log := &countingLogger{}
+	m := &serverModule{pool: pool, log: log}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	m.wg.Add(1)
+	done := make(chan struct{})
+	go func() {
+		m.accept(ctx)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("ctx 取消后 accept 没有退出")
+	}
+
+	// 5ms 起倍增的退避在 200ms 内最多重试 6 次左右，没有退避时会重试成千上万次
+	if n := log.warns.Load(); n < 2 || n > 8 {
+		t.Fatalf("200ms 内重试了 %d 次，want 按指数退避重试", n)
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ShutdownError 停止系统时出现的错误
+//
+// FailedModules 列出未能正常停止（返回错误或超时）的模块，Unwrap 返回所有错误，
+// 可用 errors.Is / errors.As 检查其中的具体错误。
+type ShutdownError struct {
+	FailedModules []string // 未能正常停止的模块，按停止顺序
+	Errs          []error  // 等待请求、等待初始化和停止各模块时的错误
+}
+
+func (e *ShutdownError) Error() string {
+	var b strings.Builder
+	b.WriteString("停止系统时出现错误")
+	if len(e.FailedModules) > 0 {
+		fmt.Fprintf(&b, "（未能正常停止的模块: %s）", strings.Join(e.FailedModules, ", "))
+	}
+	b.WriteString(": ")
+	b.WriteString(errors.Join(e.Errs...).Error())
+	return b.String()
+}
+
+func (e *ShutdownError) Unwrap() []error {
+	return e.Errs
+}
+
+// BeginRequest 登记一个进行中的爬取请求，请求结束时必须调用返回的 done（可重复调用）
+//
+// 系统不在 Running 状态时返回错误：Standby 不参与爬取，Draining 后不再接受新请求。
+// Stop 会等待所有已登记的请求结束后再停止模块。
+func (s *System) BeginRequest() (done func(), err error) {
+	return s.beginRequest(StateRunning)
+}
+
+// beginRequest 在系统处于给定状态之一时登记进行中的请求
+func (s *System) beginRequest(states ...State) (func(), error) {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	if !slices.Contains(states, s.state) {
+		return nil, fmt.Errorf("系统当前状态为 %s，不接受爬取请求", s.state)
+	}
+	if s.inflight == 0 {
+		s.inflightIdle = make(chan struct{})
+	}
+	s.inflight++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.stateMu.Lock()
+			defer s.stateMu.Unlock()
+			s.inflight--
+			if s.inflight == 0 {
+				close(s.inflightIdle)
+			}
+		})
+	}, nil
+}
+
+// InFlightRequests 返回进行中的爬取请求数
+func (s *System) InFlightRequests() int {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	return s.inflight
+}
+
+// drainRequests 等待进行中的爬取请求结束，ctx 结束时返回错误
+func (s *System) drainRequests(ctx context.Context) error {
+	s.stateMu.Lock()
+	n, idle := s.inflight, s.inflightIdle
+	s.stateMu.Unlock()
+	if n == 0 {
+		return nil
+	}
+
+	if s.Logger != nil {
+		s.Logger.Info("等待进行中的爬取请求结束，requests=%d", n)
+	}
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("等待进行中的爬取请求结束失败，剩余 %d 个: %w", s.InFlightRequests(), ctx.Err())
+	}
+}
+
+// stopModule 停止单个模块，超过 timeout 时不再等待并返回超时错误
+//
+// 超时的 Stop 仍在后台执行到结束。
+func stopModule(ctx context.Context, m Module, timeout time.Duration) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- m.Stop(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("停止超时（%s）: %w", timeout, ctx.Err())
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package crawler
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestStopClosesLogsLast(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	writeTestConfig(t, path, "")
+	s, err := NewSystem(context.Background(), path)
+	if err != nil {
+		t.Fatalf("NewSystem: %v", err)
+	}
+
+	var stopped []string
+	var stateAtLogsStop State
+	module := func(name string) *builtinModule {
+		return &builtinModule{name: name, sys: s, stop: func(s *System) error {
+			stopped = append(stopped, name)
+			if name == logsModuleName {
+				stateAtLogsStop = s.State()
+			}
+			return nil
+		}}
+	}
+	s.initialized = []Module{module(logsModuleName), module("fingerprint"), module("conn")}
+	for _, state := range []State{StateInitializing, StateRunning} {
+		if err := s.transition(state, "测试", nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := s.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if want := []string{"conn", "fingerprint", logsModuleName}; !slices.Equal(stopped, want) {
+		t.Fatalf("停止顺序 %v，want %v", stopped, want)
+	}
+	if stateAtLogsStop != StateStopped {
+		t.Fatalf("关闭日志模块时系统状态为 %s，want %s（状态变化应在关闭日志前写出）", stateAtLogsStop, StateStopped)
+	}
+}
+
+func TestRollbackKeepsLogsOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	writeTestConfig(t, path, "")
+	s, err := NewSystem(context.Background(), path)
+	if err != nil {
+		t.Fatalf("NewSystem: %v", err)
+	}
+
+	var stopped []string
+	module := func(name string) *builtinModule {
+		return &builtinModule{name: name, sys: s, stop: func(*System) error {
+			stopped = append(stopped, name)
+			return nil
+		}}
+	}
+	s.initialized = []Module{module(logsModuleName), module("fingerprint")}
+	if err := s.rollbackModules(context.Background()); err != nil {
+		t.Fatalf("rollbackModules: %v", err)
+	}
+	if want := []string{"fingerprint"}; !slices.Equal(stopped, want) {
+		t.Fatalf("回滚停止了 %v，want %v（日志模块由 Stop 关闭）", stopped, want)
+	}
+	if got := s.initializedModules(); len(got) != 1 || got[0].Name() != logsModuleName {
+		t.Fatalf("回滚后已初始化的模块 %v，want 只剩日志模块", got)
+	}
+}
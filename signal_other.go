@@ -0,0 +1,12 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !unix
+
+package crawler
+
+import "os"
+
+// statusSignals 触发状态快照输出的信号，非类 Unix 系统没有 SIGUSR1
+var statusSignals []os.Signal
@@ -0,0 +1,31 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build unix
+
+package crawler
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestNotifySignalsEmptyListRelaysNothing(t *testing.T) {
+	sigs := make(chan os.Signal, 1)
+	notifySignals(sigs)
+	defer signal.Stop(sigs)
+
+	// SIGWINCH 默认被忽略，发送给自身不影响测试进程
+	if err := syscall.Kill(os.Getpid(), syscall.SIGWINCH); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case sig := <-sigs:
+		t.Fatalf("空信号列表仍转发了信号 %v", sig)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,15 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build unix
+
+package crawler
+
+import (
+	"os"
+	"syscall"
+)
+
+// statusSignals 触发状态快照输出的信号
+var statusSignals = []os.Signal{syscall.SIGUSR1}
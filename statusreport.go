@@ -0,0 +1,66 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package crawler
+
+import "time"
+
+// startStatusReporter 按 [status_report] 配置定期输出状态报告
+//
+// 定期报告写入 Debug 级别日志；report_on_change 时每次系统状态变化后立即以 Info 级别报告。
+func (s *System) startStatusReporter(cfg *StatusReportConfig) {
+	interval := cfg.ReportInterval.Duration()
+	if s.Logger == nil || (interval <= 0 && !cfg.ReportOnChange) {
+		return
+	}
+	var events <-chan StateEvent
+	unsubscribe := func() {}
+	if cfg.ReportOnChange {
+		events, unsubscribe = s.SubscribeState()
+	}
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	s.mu.Lock()
+	s.reportStop, s.reportDone = stop, done
+	s.mu.Unlock()
+
+	go func() {
+		defer close(done)
+		defer unsubscribe()
+		var tick <-chan time.Time
+		if interval > 0 {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			tick = ticker.C
+		}
+		for {
+			select {
+			case <-stop:
+				return
+			case <-tick:
+				s.reportStatus(s.Logger.Debug)
+			case _, ok := <-events:
+				if !ok {
+					events = nil
+					continue
+				}
+				s.reportStatus(s.Logger.Info)
+			}
+		}
+	}()
+}
+
+// stopStatusReporter 停止状态报告
+func (s *System) stopStatusReporter() {
+	s.mu.Lock()
+	stop, done := s.reportStop, s.reportDone
+	s.reportStop, s.reportDone = nil, nil
+	s.mu.Unlock()
+
+	if stop == nil {
+		return
+	}
+	close(stop)
+	<-done
+}
@@ -0,0 +1,36 @@
+// Copyright 2025 vistone. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package crawler
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWhitelistReport(t *testing.T) {
+	tests := []struct {
+		name string
+		ips  []string
+		max  int
+		want string
+		not  string
+	}{
+		{"列出IP", []string{"1.1.1.1", "8.8.8.8"}, 2, "1.1.1.1, 8.8.8.8", "超过"},
+		{"超过上限只报告数量", []string{"1.1.1.1", "8.8.8.8"}, 1, "白名单IP 2 个，超过 status_report.max_report_ips (1)", "1.1.1.1"},
+		{"上限为0", []string{"1.1.1.1"}, 0, "超过", "1.1.1.1"},
+		{"空白名单", nil, 0, "白名单IP 0 个", "超过"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := whitelistReport(tt.ips, tt.max)
+			if !strings.Contains(got, tt.want) {
+				t.Errorf("whitelistReport() = %q，应包含 %q", got, tt.want)
+			}
+			if strings.Contains(got, tt.not) {
+				t.Errorf("whitelistReport() = %q，不应包含 %q", got, tt.not)
+			}
+		})
+	}
+}
@@ -5,17 +5,15 @@
 package crawler
 
 import (
+	"context"
 	"fmt"
+	"sync"
+	"time"
 
-	"github.com/vistone/certs"
 	"github.com/vistone/domaindns"
-	"github.com/vistone/fingerprint"
-	"github.com/vistone/localippool"
-	"github.com/vistone/logs"
-	"github.com/vistone/netconnpool"
 	"github.com/vistone/quic"
 
-	"github.com/vistone/crawler-system/internal/config"
+	"github.com/vistone/crawler-system/internal/logging"
 	"github.com/vistone/crawler-system/internal/moduleinit"
 )
 
@@ -24,15 +22,52 @@ type System struct {
 	Config *SystemConfig
 
 	// 9个核心模块
-	Logger             *logs.Logger
+	Logger             *logging.Logger
 	FingerprintManager *moduleinit.FingerprintManager
 	DNSMonitor         domaindns.DomainMonitor
-	LocalIPPool        localippool.IPPool
+	DNSResolver        *moduleinit.DNSResolver
+	LocalIPPool        *moduleinit.LocalIPPool
 	ConnManager        *moduleinit.ConnManager
-	NetConnPool        *netconnpool.Pool
+	NetConnPool        *moduleinit.ConnPools
 	QUICPool           *quic.Pool
-	CertManager        *certs.Manager
+	CertManager        *moduleinit.CertManager
 	IPStatusManager    IPStatusManagerInterface
+
+	AccessLog *logging.AccessLog // 访问日志，随日志模块打开和关闭，未启用时为 nil
+
+	limiter *requestLimiter // 爬取请求的并发和速率限制
+	dedup   *requestDeduper // 爬取请求去重，未启用时为 nil
+
+	configPath  string       // 配置文件路径，用于热更新
+	loadOpts    []LoadOption // 加载配置时使用的选项，热更新时复用
+	mu          sync.RWMutex // 保护 Config、配置监听状态、后台任务的停止通道和 pendingInit
+	reloadMu    sync.Mutex   // 串行化热更新
+	reloadHooks []ReloadHook
+	watchStop   chan struct{}
+	watchDone   chan struct{}
+	monitorStop chan struct{} // 白名单监控
+	monitorDone chan struct{}
+	reportStop  chan struct{} // 状态报告
+	reportDone  chan struct{}
+	pendingInit chan struct{} // 被取消但仍在执行的初始化步骤，结束时关闭
+
+	ipTestCancel context.CancelFunc // 目标IP测试和黑名单恢复
+	ipTestDone   chan struct{}
+
+	stateMu     sync.Mutex // 保护 state、subscribers 和 startedAt
+	state       State
+	startedAt   time.Time // Start 完成的时间
+	subscribers map[*stateSubscriber]struct{}
+
+	inflight     int           // 进行中的爬取请求数，由 stateMu 保护
+	inflightIdle chan struct{} // 进行中的请求全部结束时关闭
+
+	modulesMu   sync.Mutex // 保护 modules 和 initialized
+	modules     *moduleRegistry
+	initialized []Module // 已初始化的模块，按初始化完成顺序
+
+	reports        []*StartupReport // 已初始化模块的启动报告，由 modulesMu 保护
+	reportRenderer ReportRenderer
 }
 
 // IPStatusManagerInterface 黑白名单管理器接口
@@ -40,351 +75,151 @@ type IPStatusManagerInterface interface {
 	AddToWhitelist(ip string) error
 	RemoveFromWhitelist(ip string, reason string) error
 	AddToBlacklist(ip string, reason string) error
+	RemoveFromBlacklist(ip string) error
 	GetStatus(ip string) string
 	GetWhitelistIPs() []string
 	GetWhitelistCount() int
+	GetBlacklist() map[string]string
 	CheckSystemHealth() error
 	SetMinWhitelistCount(count int)
 	SetAllowStartWhenEmpty(allow bool)
 	SetWhitelistMonitoring(enabled bool)
-	SetWhitelistMonitoringInterval(interval int)
+	SetWhitelistMonitoringInterval(interval time.Duration)
 }
 
 // NewSystem 创建系统实例
-func NewSystem(configPath string) (*System, error) {
-	// 1. 加载配置
-	cfg, err := LoadConfig(configPath)
-	if err != nil {
-		return nil, fmt.Errorf("加载配置失败: %w", err)
+//
+// 只加载并校验配置并注册内置模块，系统处于 Created 状态；
+// 可调用 RegisterModule 注册自定义模块，调用 Start 初始化并启动各模块，Stop 停止。
+func NewSystem(ctx context.Context, configPath string, opts ...LoadOption) (*System, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
 
-	system := &System{
-		Config: cfg,
-	}
-
-	// 2. 初始化所有模块
-	if err := system.Initialize(); err != nil {
-		return nil, fmt.Errorf("初始化系统失败: %w", err)
-	}
-
-	return system, nil
-}
-
-// Initialize 初始化所有模块
-func (s *System) Initialize() error {
-	// 步骤1: 初始化日志系统（最先初始化，其他模块都需要）
-	if err := s.initLogs(); err != nil {
-		return fmt.Errorf("初始化日志系统失败: %w", err)
-	}
-
-	// 步骤2: 初始化独立模块（可以并行初始化）
-	if err := s.initFingerprint(); err != nil {
-		return fmt.Errorf("初始化指纹模块失败: %w", err)
-	}
-
-	if err := s.initDomainDNS(); err != nil {
-		return fmt.Errorf("初始化DNS解析模块失败: %w", err)
-	}
-
-	if err := s.initLocalIPPool(); err != nil {
-		return fmt.Errorf("初始化本地IP池模块失败: %w", err)
-	}
-
-	if err := s.initCerts(); err != nil {
-		return fmt.Errorf("初始化证书模块失败: %w", err)
-	}
-
-	if err := s.initIPStatusManager(); err != nil {
-		return fmt.Errorf("初始化黑白名单模块失败: %w", err)
-	}
-
-	// 步骤3: 初始化依赖模块
-	if err := s.initConn(); err != nil {
-		return fmt.Errorf("初始化连接模块失败: %w", err)
-	}
-
-	// 步骤4: 初始化连接池模块
-	if err := s.initNetConnPool(); err != nil {
-		return fmt.Errorf("初始化TCP连接池模块失败: %w", err)
-	}
-
-	if err := s.initQUICPool(); err != nil {
-		return fmt.Errorf("初始化QUIC连接池模块失败: %w", err)
-	}
-
-	s.Logger.Info("系统初始化完成，modules=%d", 9)
-	return nil
-}
-
-// 配置转换函数
-func convertLogsConfig(cfg *LogsConfig) *config.LogsConfig {
-	return &config.LogsConfig{
-		Level:       cfg.Level,
-		FileEnabled: cfg.FileEnabled,
-		FilePath:    cfg.FilePath,
-		MaxSize:     cfg.MaxSize,
-		MaxBackups:  cfg.MaxBackups,
-		Compress:    cfg.Compress,
-		Format:      cfg.Format,
-		ShowCaller:  cfg.ShowCaller,
-	}
-}
-
-func convertFingerprintConfig(cfg *FingerprintConfig) *config.FingerprintConfig {
-	return &config.FingerprintConfig{
-		SelectionStrategy: cfg.SelectionStrategy,
-		EnableRotation:    cfg.EnableRotation,
-		RotationInterval:  cfg.RotationInterval,
-		LibraryPath:       cfg.LibraryPath,
-		Browsers:          cfg.Browsers,
-		OSRandomization:   cfg.OSRandomization,
-		UARandomization:   cfg.UARandomization,
-	}
-}
-
-func convertDomainDNSConfig(cfg *DomainDNSConfig) *config.DomainDNSConfig {
-	return &config.DomainDNSConfig{
-		DNSServers:         cfg.DNSServers,
-		CacheEnabled:       cfg.CacheEnabled,
-		CacheTTL:           cfg.CacheTTL,
-		Timeout:            cfg.Timeout,
-		MaxRetries:         cfg.MaxRetries,
-		RetryInterval:      cfg.RetryInterval,
-		PollutionDetection: cfg.PollutionDetection,
-		IPv6Enabled:        cfg.IPv6Enabled,
-		IPInfoToken:        cfg.IPInfoToken,
-	}
-}
-
-func convertLocalIPPoolConfig(cfg *LocalIPPoolConfig) *config.LocalIPPoolConfig {
-	return &config.LocalIPPoolConfig{
-		IPs:                   cfg.IPs,
-		SelectionStrategy:     cfg.SelectionStrategy,
-		HealthCheckEnabled:    cfg.HealthCheckEnabled,
-		HealthCheckInterval:   cfg.HealthCheckInterval,
-		HealthCheckTimeout:    cfg.HealthCheckTimeout,
-		MaxFailures:           cfg.MaxFailures,
-		RecoveryCheckInterval: cfg.RecoveryCheckInterval,
-	}
-}
-
-func convertCertificateConfig(cfg *CertificateConfig) *config.CertificateConfig {
-	return &config.CertificateConfig{
-		ServerDomain:           cfg.ServerDomain,
-		CertStoragePath:        cfg.CertStoragePath,
-		Provider:               cfg.Provider,
-		AutoRenewal:            cfg.AutoRenewal,
-		RenewalCheckInterval:   cfg.RenewalCheckInterval,
-		RenewalBeforeDays:      cfg.RenewalBeforeDays,
-		LetsEncryptEmail:       cfg.LetsEncryptEmail,
-		LetsEncryptEnvironment: cfg.LetsEncryptEnvironment,
-		SelfSignedValidityDays: cfg.SelfSignedValidityDays,
-		AutoDetectLocalIP:      cfg.AutoDetectLocalIP,
-	}
-}
-
-func convertIPStatusConfig(cfg *IPStatusConfig) *config.IPStatusConfig {
-	return &config.IPStatusConfig{
-		MinWhitelistCount:           cfg.MinWhitelistCount,
-		AllowStartWhenEmpty:         cfg.AllowStartWhenEmpty,
-		WhitelistMonitoring:         cfg.WhitelistMonitoring,
-		WhitelistMonitoringInterval: cfg.WhitelistMonitoringInterval,
-	}
-}
-
-func convertConnConfig(cfg *ConnConfig) *config.ConnConfig {
-	return &config.ConnConfig{
-		ConnectTimeout:      cfg.ConnectTimeout,
-		ReadTimeout:         cfg.ReadTimeout,
-		WriteTimeout:        cfg.WriteTimeout,
-		KeepAlive:           cfg.KeepAlive,
-		KeepAliveTime:       cfg.KeepAliveTime,
-		MaxIdleConns:        cfg.MaxIdleConns,
-		MaxConnsPerHost:     cfg.MaxConnsPerHost,
-		TLSHandshakeTimeout: cfg.TLSHandshakeTimeout,
-		InsecureSkipVerify:  cfg.InsecureSkipVerify,
+	cfg, err := LoadConfig(configPath, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("加载配置失败: %w", err)
 	}
-}
 
-func convertNetConnPoolConfig(cfg *NetConnPoolConfig) *config.NetConnPoolConfig {
-	return &config.NetConnPoolConfig{
-		MaxConnections:      cfg.MaxConnections,
-		InitialConnections:  cfg.InitialConnections,
-		AcquireTimeout:      cfg.AcquireTimeout,
-		IdleTimeout:         cfg.IdleTimeout,
-		MaxLifetime:         cfg.MaxLifetime,
-		HealthCheckInterval: cfg.HealthCheckInterval,
-		HealthCheckTimeout:  cfg.HealthCheckTimeout,
+	s := &System{
+		Config:     cfg,
+		configPath: configPath,
+		loadOpts:   opts,
+		state:      StateCreated,
+		modules:    newModuleRegistry(),
+		limiter:    newRequestLimiter(&cfg.Crawler),
+		dedup:      newRequestDeduper(&cfg.Crawler),
 	}
-}
-
-func convertQUICConfig(cfg *QUICConfig) *config.QUICConfig {
-	return &config.QUICConfig{
-		MaxConnections:      cfg.MaxConnections,
-		InitialConnections:  cfg.InitialConnections,
-		AcquireTimeout:      cfg.AcquireTimeout,
-		IdleTimeout:         cfg.IdleTimeout,
-		MaxLifetime:         cfg.MaxLifetime,
-		HealthCheckInterval: cfg.HealthCheckInterval,
-		HealthCheckTimeout:  cfg.HealthCheckTimeout,
-		HandshakeTimeout:    cfg.HandshakeTimeout,
-		Enable0RTT:          cfg.Enable0RTT,
+	for _, m := range builtinModules() {
+		if err := s.modules.register(m); err != nil {
+			return nil, err
+		}
 	}
+	return s, nil
 }
 
 // initLogs 初始化日志系统（模块1）
-func (s *System) initLogs() error {
-	cfg := convertLogsConfig(&s.Config.Logs)
-	logger, err := moduleinit.InitLogs(cfg)
+func (s *System) initLogs() (*StartupReport, error) {
+	logger, report, err := moduleinit.InitLogs(&s.Config.Logs)
+	if err != nil {
+		return nil, err
+	}
+	accessLog, err := openAccessLog(&s.Config.Server, logger.Named("logs"))
 	if err != nil {
-		return err
+		logger.Close()
+		return nil, err
 	}
 	s.Logger = logger
-	return nil
+	s.AccessLog = accessLog
+	reportAccessLog(report, &s.Config.Server)
+	return report, nil
 }
 
 // initFingerprint 初始化指纹模块（模块2）
-func (s *System) initFingerprint() error {
-	cfg := convertFingerprintConfig(&s.Config.Fingerprint)
-	fm, err := moduleinit.InitFingerprint(cfg, s.Logger)
+func (s *System) initFingerprint() (*StartupReport, error) {
+	fm, report, err := moduleinit.InitFingerprint(&s.Config.Fingerprint, s.ModuleLogger("fingerprint"))
 	if err != nil {
-		return err
+		return nil, err
 	}
 	s.FingerprintManager = fm
-	return nil
-}
-
-// GetRandomFingerprint 获取随机指纹（包装fingerprint库）
-// 注意：这个方法应该在FingerprintManager中实现，而不是在System中
-// 这里暂时保留作为占位符
-func GetRandomFingerprint(fm *moduleinit.FingerprintManager) (*fingerprint.FingerprintResult, error) {
-	return fingerprint.GetRandomFingerprint()
+	return report, nil
 }
 
 // initDomainDNS 初始化DNS解析模块（模块3）
-func (s *System) initDomainDNS() error {
-	cfg := convertDomainDNSConfig(&s.Config.DomainDNS)
+func (s *System) initDomainDNS() (*StartupReport, error) {
 	targetDomains := s.Config.IPPoolTest.TargetDomains
-	monitor, err := moduleinit.InitDomainDNS(cfg, targetDomains, s.Logger)
+	monitor, report, err := moduleinit.InitDomainDNS(&s.Config.DomainDNS, targetDomains, s.ModuleLogger("domaindns"))
 	if err != nil {
-		return err
+		return nil, err
 	}
 	s.DNSMonitor = monitor
-	return nil
+	s.DNSResolver = moduleinit.NewDNSResolver(&s.Config.DomainDNS, s.ModuleLogger("domaindns"))
+	return report, nil
 }
 
 // initLocalIPPool 初始化本地IP池模块（模块4）
-func (s *System) initLocalIPPool() error {
-	cfg := convertLocalIPPoolConfig(&s.Config.LocalIPPool)
-	pool, err := moduleinit.InitLocalIPPool(cfg, s.Logger)
+func (s *System) initLocalIPPool() (*StartupReport, error) {
+	pool, report, err := moduleinit.InitLocalIPPool(&s.Config.LocalIPPool, s.ModuleLogger("localippool"))
 	if err != nil {
-		return err
+		return nil, err
 	}
 	s.LocalIPPool = pool
-	return nil
+	return report, nil
 }
 
 // initCerts 初始化证书模块（模块5）
-func (s *System) initCerts() error {
-	cfg := convertCertificateConfig(&s.Config.Certificate)
-	manager, err := moduleinit.InitCerts(cfg, s.Logger)
+func (s *System) initCerts() (*StartupReport, error) {
+	manager, report, err := moduleinit.InitCerts(&s.Config.Certificate, s.ModuleLogger("certs"))
 	if err != nil {
-		return err
+		return nil, err
 	}
 	s.CertManager = manager
-	return nil
+	return report, nil
 }
 
 // initIPStatusManager 初始化黑白名单模块（模块6）
-func (s *System) initIPStatusManager() error {
-	cfg := convertIPStatusConfig(&s.Config.IPStatus)
-	manager, err := moduleinit.InitIPStatusManager(cfg, s.Logger)
+func (s *System) initIPStatusManager() (*StartupReport, error) {
+	manager, report, err := moduleinit.InitIPStatusManager(&s.Config.IPStatus, IPStatusStorePath(s.Config), s.ModuleLogger("ipstatus"))
 	if err != nil {
-		return err
+		return nil, err
 	}
 	s.IPStatusManager = manager
-	return nil
+	return report, nil
 }
 
 // initConn 初始化连接模块（模块7）
-func (s *System) initConn() error {
-	cfg := convertConnConfig(&s.Config.Conn)
-	cm, err := moduleinit.InitConn(cfg, s.Logger)
+func (s *System) initConn() (*StartupReport, error) {
+	cm, report, err := moduleinit.InitConn(&s.Config.Conn, s.ModuleLogger("conn"))
 	if err != nil {
-		return err
+		return nil, err
 	}
 	s.ConnManager = cm
-	return nil
+	return report, nil
 }
 
 // initNetConnPool 初始化TCP连接池模块（模块8）
-func (s *System) initNetConnPool() error {
-	cfg := convertNetConnPoolConfig(&s.Config.NetConnPool)
-	pool, err := moduleinit.InitNetConnPool(cfg, s.Logger)
+func (s *System) initNetConnPool() (*StartupReport, error) {
+	pool, report, err := moduleinit.InitNetConnPool(&s.Config.NetConnPool, &s.Config.Conn, s.ModuleLogger("netconnpool"))
 	if err != nil {
-		return err
+		return nil, err
 	}
 	s.NetConnPool = pool
-	return nil
+	return report, nil
 }
 
 // initQUICPool 初始化QUIC连接池模块（模块9）
-func (s *System) initQUICPool() error {
-	cfg := convertQUICConfig(&s.Config.QUIC)
-	pool, err := moduleinit.InitQUICPool(cfg, s.Logger)
+func (s *System) initQUICPool() (*StartupReport, error) {
+	pool, report, err := moduleinit.InitQUICPool(&s.Config.QUIC, s.ModuleLogger("quic"))
 	if err != nil {
-		return err
+		return nil, err
 	}
 	s.QUICPool = pool
-	return nil
+	return report, nil
 }
 
-// Close 关闭系统，释放资源
-func (s *System) Close() error {
-	if s.Logger != nil {
-		s.Logger.Info("正在关闭系统...")
-	}
-
-	// 关闭连接池
-	if s.NetConnPool != nil {
-		if err := s.NetConnPool.Close(); err != nil {
-			if s.Logger != nil {
-				s.Logger.Error("关闭TCP连接池失败，error=%v", err)
-			}
-		}
-	}
-
-	if s.QUICPool != nil {
-		s.QUICPool.Close() // QUIC池的Close方法可能没有返回值
-	}
-
-	// 停止DNS监控器
-	if s.DNSMonitor != nil {
-		s.DNSMonitor.Stop()
-		if s.Logger != nil {
-			s.Logger.Info("DNS监控器已停止")
-		}
-	}
-
-	// 关闭本地IP池
-	if s.LocalIPPool != nil {
-		if err := s.LocalIPPool.Close(); err != nil {
-			if s.Logger != nil {
-				s.Logger.Error("关闭本地IP池失败，error=%v", err)
-			}
-		}
-	}
-
-	// 关闭证书管理器
-	// certs.Manager 可能没有Close方法，暂时不关闭
-	if s.CertManager != nil {
-		// 证书管理器可能不需要显式关闭
-		_ = s.CertManager
-	}
-
-	if s.Logger != nil {
-		s.Logger.Info("系统关闭完成")
-	}
-
-	return nil
+// Close 停止系统并释放资源，等价于 Stop(ctx)
+//
+// 等待进行中的爬取请求结束，按依赖关系的逆序停止各模块，返回的 *ShutdownError 列出未能正常停止的模块。
+func (s *System) Close(ctx context.Context) error {
+	return s.Stop(ctx)
 }